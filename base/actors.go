@@ -80,6 +80,23 @@ type IActionContext interface {
 	DebugInit()
 }
 
+// actionOverrideContext wraps an action context
+// to expose another action
+type actionOverrideContext struct {
+	IActionContext
+	action *blueprint.Action
+}
+
+func (a *actionOverrideContext) GetAction() *blueprint.Action {
+	return a.action
+}
+
+// WithAction func. Returns actx exposing action instead of his own
+// action, eg. a copy with the resolved parameters or a sub action
+func WithAction(actx IActionContext, action *blueprint.Action) IActionContext {
+	return &actionOverrideContext{IActionContext: actx, action: action}
+}
+
 // IActor interface
 type IActor interface {
 	RunAction(action *blueprint.Action) (*ActionOutput, error)
@@ -96,6 +113,12 @@ type ActionOutput struct {
 	Records []*StorageRecord
 }
 
+// ICommandOutput interface. Implemented by the outputs of the
+// actions running commands, to expose what they wrote
+type ICommandOutput interface {
+	CommandOutput() (stdout string, stderr string)
+}
+
 // NewActionOutput func.
 func NewActionOutput(action *blueprint.Action, storageRecordValue interface{}, storageRecordValueID *string) *ActionOutput {
 	aout := &ActionOutput{
@@ -45,6 +45,7 @@ type IEvent interface {
 }
 
 type EventListener struct {
+	mu      sync.Mutex
 	events  chan IEvent
 	discard chan IEvent
 	// when ReadUntil or WaitUntil are
@@ -52,10 +53,42 @@ type EventListener struct {
 	// false and all events sended to
 	// this listener will be discarded
 	reading bool
+	// armed listeners keep the events
+	// until the next read, see Arm
+	armed bool
+}
+
+// Arm keeps the events sent to the listener until the next
+// ReadUntil or WaitUntil, so the events dispatched between
+// the start of something and the wait for it are not lost
+func (e *EventListener) Arm() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.armed = true
+}
+
+// startReading marks the listener as being read, panics if
+// it is already
+func (e *EventListener) startReading() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.reading {
+		panic("hey dev, this is your fault, never call listener two times!")
+	}
+	e.reading = true
+}
+
+func (e *EventListener) stopReading() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.reading = false
+	e.armed = false
 }
 
 func (e *EventListener) EventChan() chan IEvent {
-	if !e.reading {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.reading && !e.armed {
 		go func() {
 			<-e.discard
 		}()
@@ -74,11 +107,8 @@ func (e *EventListener) EventChan() chan IEvent {
 // true if EventCode gets found. Return false if events
 // chan gets empty without any ocurrence of EventCode
 func (e *EventListener) ReadUntil(ec EventCode) bool {
-	if e.reading {
-		panic("hey dev, this is your fault, never call listener two times!")
-	}
-	e.reading = true
-	defer func() { e.reading = false }()
+	e.startReading()
+	defer e.stopReading()
 	for {
 		select {
 		case evt := <-e.events:
@@ -95,11 +125,8 @@ func (e *EventListener) ReadUntil(ec EventCode) bool {
 // Waits for ocurrence of any of given EventCode, returns
 // the first EventCode found
 func (e *EventListener) WaitUntil(ecs []EventCode) EventCode {
-	if e.reading {
-		panic("hey dev, this is your fault, never call listener two times!")
-	}
-	e.reading = true
-	defer func() { e.reading = false }()
+	e.startReading()
+	defer e.stopReading()
 	for {
 		evt := <-e.events
 		for _, ec := range ecs {
@@ -180,7 +180,10 @@ type StorageRecord struct {
 	// To access values of non Literal vars, use {{ refname.attr.subattr }}.
 	// If .ValueID is empty and Literal is false, on {{ refname }} access
 	// an err should generated
-	Literal  bool   `json:"-"`
+	Literal bool `json:"-"`
+	// the value holds sensitive data, like a private key. It
+	// can be interpolated but is masked when the store is shown
+	Secret   bool   `json:"-"`
 	ErrorStr string `json:"error"`
 }
 
@@ -265,10 +268,13 @@ type IStore interface {
 	GetPrivateVar(varname string) interface{}
 	SetPrivateVar(varname string, value interface{})
 	Merge(IStore)
+	MergeWithPolicy(IStore, blueprint.JoinMergePolicy) error
 	GetActionOutputByActionID(actionID *string) (*ActionOutput, error)
 	Insert(record *StorageRecord, providerPrefix string) error
 	Push(record *StorageRecord, providerPrefix string) error
 	Interpolate(sourcetext *string) error
+	ResolveJSON(text string) (json.RawMessage, error)
+	SetTemplateCache(cache *blueprint.TemplateCache)
 	GetPlain() (map[string]string, error)
 	GetRawJSONValues() (map[string]json.RawMessage, error)
 	DumpValuesToShellFile() (*os.File, error)
@@ -42,6 +42,18 @@ import (
 // JoinThreadsActionName const
 const JoinThreadsActionName = "join_threads"
 const DebugActionName = "debug"
+const WaitForEventActionName = "wait_for_event"
+const WaitUntilActionName = "wait_until"
+
+// CallbackPrivateVarPrefix const. The callback of a wait_for_event
+// action is saved in the store as a private var with this prefix
+const CallbackPrivateVarPrefix = "CALLBACK_"
+
+// ExecutionUUIDPrivateVar and BlueprintNamePrivateVar consts. The
+// runtime saves the execution uuid and the blueprint name as private
+// vars so providers can use them (e.g. to tag resources)
+const ExecutionUUIDPrivateVar = "EXECUTION_UUID"
+const BlueprintNamePrivateVar = "BLUEPRINT_NAME"
 
 type wrappedBlueprint struct {
 	ExecutionUUID *string         `json:"execution_uuid"`
@@ -57,6 +69,8 @@ type Blueprint struct {
 	Raw             *[]byte
 	BuilderErrors   int `json:"n_errors"`
 	BuilderWarnings int `json:"n_warnings"`
+	// Name of the blueprint, filled from the bp url
+	Name string `json:"-"`
 }
 
 // Action struct
@@ -64,7 +78,10 @@ type Action struct {
 	// Filled internally.
 	Parents          []*Action
 	JoinThreadsPoint bool
+	JoinParameters   *JoinThreadsParameters
+	Templates        *TemplateCache
 	DebugPoint       bool
+	CallbackPoint    bool
 	KnowParentIDs    map[string]bool
 	SafeID           *string
 	// GENERICS //
@@ -216,6 +233,9 @@ func NewIRBFromAny(bpurl *BlueprintURL, irbConf *IRBGenConfig) (*IRBlueprint, er
 	default:
 		return nil, fmt.Errorf("unknown bp url")
 	}
+	if bp.Name == "" {
+		bp.Name = bpurl.Name()
+	}
 
 	irb, err := GenerateIRB(bp, irbConf)
 	if err != nil {
@@ -472,6 +492,9 @@ func GenerateIRB(bp *Blueprint, irbConf *IRBGenConfig) (*IRBlueprint, error) {
 		if irb.Actions[bp.Actions[i].ActionID].ActionName == DebugActionName {
 			irb.Actions[bp.Actions[i].ActionID].DebugPoint = true
 		}
+		if irb.Actions[bp.Actions[i].ActionID].ActionName == WaitForEventActionName && irb.Actions[bp.Actions[i].ActionID].Provider == "generic" {
+			irb.Actions[bp.Actions[i].ActionID].CallbackPoint = true
+		}
 	}
 
 	if irb.StartAction == nil {
@@ -486,6 +509,12 @@ func GenerateIRB(bp *Blueprint, irbConf *IRBGenConfig) (*IRBlueprint, error) {
 			action.Parameters = []byte("{}")
 		}
 
+		// precompile {{ }} templates, reporting syntax errors
+		action.Templates = NewTemplateCache()
+		for _, err := range action.Templates.compileParameters(action.Parameters) {
+			errors = append(errors, &iRBError{actionID: action.ActionID, wErr: err})
+		}
+
 		if action.ActionName == "condition" && action.Provider == "generic" {
 			action.NextAction.ConditionalNext = true
 		}
@@ -559,6 +588,12 @@ func GenerateIRB(bp *Blueprint, irbConf *IRBGenConfig) (*IRBlueprint, error) {
 	for _, action := range irb.JoinThreadPoints {
 		knowParents := buildDirectAscendants(action)
 		action.KnowParentIDs = knowParents
+		jparams, err := ParseJoinThreadsParameters(action)
+		if err != nil {
+			errors = append(errors, &iRBError{actionID: action.ActionID, wErr: err})
+			continue
+		}
+		action.JoinParameters = jparams
 	}
 
 	// detect loops
@@ -0,0 +1,115 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package blueprint
+
+import (
+	"fmt"
+
+	"github.com/develatio/nebulant-cli/util"
+)
+
+// JoinStrategy string
+type JoinStrategy string
+
+const (
+	// wait for all the running parents
+	JoinStrategyAll JoinStrategy = "all"
+	// continue with the first arriving branch
+	JoinStrategyAny JoinStrategy = "any"
+	// continue when N branches have arrived
+	JoinStrategyNOfM JoinStrategy = "n_of_m"
+)
+
+// JoinMergePolicy string
+type JoinMergePolicy string
+
+const (
+	// the last arriving branch overrides the values
+	JoinMergeLastWriterWins JoinMergePolicy = "last_writer_wins"
+	// two branches writing different values into the
+	// same reference name makes the join fail
+	JoinMergeFailOnConflict JoinMergePolicy = "fail_on_conflict"
+	// conflicting values are collected into a stack var
+	JoinMergeCollect JoinMergePolicy = "collect"
+)
+
+// JoinThreadsParameters struct. Parameters of the
+// join_threads action, parsed on GenerateIRB
+type JoinThreadsParameters struct {
+	Strategy JoinStrategy `json:"strategy"`
+	// number of branches needed by n_of_m strategy
+	N int `json:"n"`
+	// max seconds to wait for the branches, 0 means no timeout
+	Timeout int64 `json:"timeout"`
+	// cancel the branches that has not reached the join
+	// point yet. Defaults to true with any and n_of_m
+	CancelPending *bool           `json:"cancel_pending"`
+	MergePolicy   JoinMergePolicy `json:"merge_policy"`
+}
+
+// Validate func
+func (j *JoinThreadsParameters) Validate() error {
+	switch j.Strategy {
+	case JoinStrategyAll, JoinStrategyAny:
+	case JoinStrategyNOfM:
+		if j.N <= 0 {
+			return fmt.Errorf("join_threads: n should be greater than 0 with n_of_m strategy")
+		}
+	default:
+		return fmt.Errorf("join_threads: unknown strategy " + string(j.Strategy))
+	}
+	switch j.MergePolicy {
+	case JoinMergeLastWriterWins, JoinMergeFailOnConflict, JoinMergeCollect:
+	default:
+		return fmt.Errorf("join_threads: unknown merge policy " + string(j.MergePolicy))
+	}
+	if j.Timeout < 0 {
+		return fmt.Errorf("join_threads: timeout cannot be negative")
+	}
+	return nil
+}
+
+// ShouldCancelPending func
+func (j *JoinThreadsParameters) ShouldCancelPending() bool {
+	if j.CancelPending != nil {
+		return *j.CancelPending
+	}
+	return j.Strategy != JoinStrategyAll
+}
+
+// ParseJoinThreadsParameters func. Read the join_threads
+// parameters of the action filling the defaults. The defaults
+// keeps the legacy behavior: wait for all and last writer wins.
+func ParseJoinThreadsParameters(action *Action) (*JoinThreadsParameters, error) {
+	params := &JoinThreadsParameters{
+		Strategy:    JoinStrategyAll,
+		MergePolicy: JoinMergeLastWriterWins,
+	}
+	if len(action.Parameters) <= 0 {
+		return params, nil
+	}
+	if err := util.UnmarshalValidJSON(action.Parameters, params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
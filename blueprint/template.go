@@ -0,0 +1,319 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package blueprint
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bhmj/jsonslice"
+)
+
+// TemplateNodeType int
+type TemplateNodeType int
+
+const (
+	// TemplateNodeLiteral const, plain text
+	TemplateNodeLiteral TemplateNodeType = iota
+	// TemplateNodeReference const, {{ REFNAME.path }}
+	TemplateNodeReference
+	// TemplateNodeEnv const, {{ ENV.VARNAME }}
+	TemplateNodeEnv
+	// TemplateNodeRuntime const, {{ RUNTIME.os }}
+	TemplateNodeRuntime
+)
+
+// TemplateStageType int
+type TemplateStageType int
+
+const (
+	// TemplateStageMagic const, paths like .__json or .__haserror
+	TemplateStageMagic TemplateStageType = iota
+	// TemplateStageJSONPath const, JSONPath expression
+	TemplateStageJSONPath
+)
+
+// RuntimeVarNames are the vars available through {{ RUNTIME.name }}
+var RuntimeVarNames = map[string]bool{
+	"os":          true,
+	"arch":        true,
+	"numcpu":      true,
+	"version":     true,
+	"versiondate": true,
+}
+
+var magicPaths = map[string]bool{
+	"$.__haserror": true,
+	"$.__error":    true,
+	"$.__internal": true,
+	"$.__plain":    true,
+	"$.__json":     true,
+	"$.__id":       true,
+}
+
+// TemplatePathStage struct. A stage of the path of a reference. Stages
+// are separated by pipes, every JSONPath stage is applied to the
+// result of the previous one.
+type TemplatePathStage struct {
+	Type TemplateStageType
+	// Path always starts with $
+	Path string
+}
+
+// TemplateNode struct
+type TemplateNode struct {
+	Type TemplateNodeType
+	// Raw text of the node, like "{{ REFNAME.path }}"
+	Source string
+	// Reference, env var or runtime var name
+	Name string
+	// Path stages of the reference, empty to use the whole value
+	Stages []*TemplatePathStage
+}
+
+// Template struct. Compiled form of a text with {{ }} placeholders.
+type Template struct {
+	Source string
+	Nodes  []*TemplateNode
+}
+
+// HasPlaceholders returns true if the template has something to interpolate
+func (t *Template) HasPlaceholders() bool {
+	for _, node := range t.Nodes {
+		if node.Type != TemplateNodeLiteral {
+			return true
+		}
+	}
+	return false
+}
+
+// CompileTemplate func. Parses the {{ }} placeholders of src. Text that
+// looks like an unclosed placeholder is kept as literal text.
+func CompileTemplate(src string) (*Template, error) {
+	tpl := &Template{Source: src}
+	if !strings.Contains(src, "{{") {
+		tpl.Nodes = []*TemplateNode{{Type: TemplateNodeLiteral, Source: src}}
+		return tpl, nil
+	}
+	literalStart := 0
+	i := 0
+	for i < len(src)-1 {
+		if src[i] != '{' || src[i+1] != '{' {
+			i++
+			continue
+		}
+		// placeholders can not contain braces
+		j := i + 2
+		for j < len(src) && src[j] != '{' && src[j] != '}' {
+			j++
+		}
+		if j+1 >= len(src) || src[j] != '}' || src[j+1] != '}' {
+			i++
+			continue
+		}
+		if literalStart < i {
+			tpl.Nodes = append(tpl.Nodes, &TemplateNode{Type: TemplateNodeLiteral, Source: src[literalStart:i]})
+		}
+		node, err := compilePlaceholder(src[i:j+2], src[i+2:j])
+		if err != nil {
+			return nil, err
+		}
+		tpl.Nodes = append(tpl.Nodes, node)
+		i = j + 2
+		literalStart = i
+	}
+	if literalStart < len(src) {
+		tpl.Nodes = append(tpl.Nodes, &TemplateNode{Type: TemplateNodeLiteral, Source: src[literalStart:]})
+	}
+	return tpl, nil
+}
+
+func compilePlaceholder(source string, inner string) (*TemplateNode, error) {
+	node := &TemplateNode{Source: source}
+	refpath := strings.TrimSpace(inner)
+
+	// Catch AWS_EC2 from AWS_EC2.foo.bar or AWS_EC2[0]
+	end := 0
+	for end < len(refpath) && !strings.ContainsRune(".[|", rune(refpath[end])) {
+		if refpath[end] == '\\' && end+1 < len(refpath) {
+			end++
+		}
+		end++
+	}
+	refname := strings.TrimSpace(refpath[:end])
+	if len(refname) <= 0 {
+		return nil, fmt.Errorf("cannot determine reference in %s", source)
+	}
+	rest := refpath[end:]
+
+	switch strings.ToLower(refname) {
+	case "env":
+		node.Type = TemplateNodeEnv
+		node.Name = strings.TrimSpace(strings.TrimPrefix(rest, "."))
+		if len(node.Name) <= 0 {
+			return nil, fmt.Errorf("environment var access with empty var name " + refname)
+		}
+		return node, nil
+	case "runtime":
+		node.Type = TemplateNodeRuntime
+		name := strings.TrimSpace(strings.TrimPrefix(rest, "."))
+		if len(name) <= 0 {
+			return nil, fmt.Errorf("runtime var access with empty var name")
+		}
+		node.Name = strings.ToLower(name)
+		if _, exists := RuntimeVarNames[node.Name]; !exists {
+			return nil, fmt.Errorf("Unknown runtime var name " + name)
+		}
+		return node, nil
+	}
+
+	node.Type = TemplateNodeReference
+	node.Name = refname
+	if len(rest) <= 0 {
+		return node, nil
+	}
+	// add root char ($) to initial path,
+	// this replaces AWS_EC2.foo.bar by $.foo.bar
+	for _, path := range splitPathStages("$" + rest) {
+		path = strings.TrimSpace(path)
+		if len(path) <= 0 {
+			return nil, fmt.Errorf("empty path stage in %s", source)
+		}
+		stage := &TemplatePathStage{Type: TemplateStageJSONPath, Path: path}
+		if magicPaths[strings.ToLower(path)] || strings.HasPrefix(path, "$.__plain.") {
+			stage.Type = TemplateStageMagic
+		} else if _, err := jsonslice.Get([]byte("{}"), path); err != nil {
+			return nil, fmt.Errorf("Invalid path " + path + " " + err.Error())
+		}
+		node.Stages = append(node.Stages, stage)
+	}
+	return node, nil
+}
+
+// splitPathStages splits the path by pipes, except escaped
+// or quoted ones
+func splitPathStages(path string) []string {
+	var stages []string
+	start := 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case '"':
+			if end := strings.IndexByte(path[i+1:], '"'); end >= 0 {
+				i += end + 1
+			}
+		case '|':
+			stages = append(stages, path[start:i])
+			start = i + 1
+		}
+	}
+	return append(stages, path[start:])
+}
+
+// TemplateCache struct. Compiled templates of the parameters of an
+// action, keyed by source.
+type TemplateCache struct {
+	mu        sync.Mutex
+	templates map[string]*Template
+	// hasRefs is true if the parameters have something to interpolate
+	hasRefs bool
+}
+
+// NewTemplateCache func
+func NewTemplateCache() *TemplateCache {
+	return &TemplateCache{templates: make(map[string]*Template)}
+}
+
+// Get returns the compiled template of src. Only the strings of the
+// parameters are kept in the cache, any other text (like the content
+// of a file) is compiled on every call. A nil cache compiles src on
+// every call.
+func (c *TemplateCache) Get(src string) (*Template, error) {
+	if c == nil || !strings.Contains(src, "{{") {
+		return CompileTemplate(src)
+	}
+	c.mu.Lock()
+	tpl, exists := c.templates[src]
+	c.mu.Unlock()
+	if exists {
+		return tpl, nil
+	}
+	return CompileTemplate(src)
+}
+
+// Empty reports whether the parameters of the action have nothing
+// to interpolate
+func (c *TemplateCache) Empty() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.hasRefs
+}
+
+// compileParameters precompiles every string with references found
+// into the parameters of the action, returning the syntax errors
+func (c *TemplateCache) compileParameters(params json.RawMessage) []error {
+	var v interface{}
+	if err := json.Unmarshal(params, &v); err != nil {
+		// bad parameters are reported by the action validators
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var errs []error
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch vv := v.(type) {
+		case string:
+			if !strings.Contains(vv, "{{") {
+				// nothing to interpolate
+				return
+			}
+			c.hasRefs = true
+			if _, exists := c.templates[vv]; exists {
+				return
+			}
+			tpl, err := CompileTemplate(vv)
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			c.templates[vv] = tpl
+		case []interface{}:
+			for _, item := range vv {
+				walk(item)
+			}
+		case map[string]interface{}:
+			for _, item := range vv {
+				walk(item)
+			}
+		}
+	}
+	walk(v)
+	return errs
+}
@@ -0,0 +1,157 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package blueprint_test
+
+import (
+	"testing"
+
+	"github.com/develatio/nebulant-cli/blueprint"
+)
+
+func TestCompileTemplate(t *testing.T) {
+	tpl, err := blueprint.CompileTemplate("a {{ A.b | $[0] }} {{ ENV.HOME }} {{{ RUNTIME.os }}} {{ unclosed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		typ    blueprint.TemplateNodeType
+		source string
+		name   string
+		stages int
+	}{
+		{blueprint.TemplateNodeLiteral, "a ", "", 0},
+		{blueprint.TemplateNodeReference, "{{ A.b | $[0] }}", "A", 2},
+		{blueprint.TemplateNodeLiteral, " ", "", 0},
+		{blueprint.TemplateNodeEnv, "{{ ENV.HOME }}", "HOME", 0},
+		{blueprint.TemplateNodeLiteral, " {", "", 0},
+		{blueprint.TemplateNodeRuntime, "{{ RUNTIME.os }}", "os", 0},
+		{blueprint.TemplateNodeLiteral, "} {{ unclosed", "", 0},
+	}
+	if len(tpl.Nodes) != len(expected) {
+		t.Fatalf("expected %d nodes, got %d", len(expected), len(tpl.Nodes))
+	}
+	for i, e := range expected {
+		node := tpl.Nodes[i]
+		if node.Type != e.typ || node.Source != e.source || node.Name != e.name || len(node.Stages) != e.stages {
+			t.Errorf("node %d: expected %+v, got %+v", i, e, node)
+		}
+	}
+	stages := tpl.Nodes[1].Stages
+	if stages[0].Path != "$.b" || stages[1].Path != "$[0]" {
+		t.Errorf("bad path stages %v %v", stages[0].Path, stages[1].Path)
+	}
+
+	tpl, err = blueprint.CompileTemplate(`{{ A.__json | $.a["b|c"] }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stages = tpl.Nodes[0].Stages
+	if len(stages) != 2 || stages[0].Type != blueprint.TemplateStageMagic || stages[1].Path != `$.a["b|c"]` {
+		t.Errorf("quoted pipes should not split stages")
+	}
+
+	tpl, _ = blueprint.CompileTemplate("no placeholders")
+	if tpl.HasPlaceholders() {
+		t.Errorf("literal text should not have placeholders")
+	}
+}
+
+func TestCompileTemplateErrors(t *testing.T) {
+	for _, src := range []string{
+		"{{ }}",
+		"{{ . }}",
+		"{{ ENV. }}",
+		"{{ RUNTIME.unknown }}",
+		"{{ A.b | }}",
+		"{{ A.b[ }}",
+	} {
+		if _, err := blueprint.CompileTemplate(src); err == nil {
+			t.Errorf("%s should fail", src)
+		}
+	}
+}
+
+func TestGenerateIRBTemplateErrors(t *testing.T) {
+	bp, err := blueprint.NewFromBytes([]byte(`{"actions": [{
+		"provider": "generic",
+		"action_id": "a",
+		"action": "log",
+		"first_action": true,
+		"parameters": {"content": "{{ RUNTIME. }}"},
+		"next_action": {}
+	}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = blueprint.GenerateIRB(bp, &blueprint.IRBGenConfig{})
+	if err == nil {
+		t.Fatalf("template syntax errors should be reported")
+	}
+	irberrs, ok := err.(blueprint.IRBErrors)
+	if !ok || len(irberrs) != 1 || irberrs[0].ActionID() != "a" {
+		t.Errorf("expected one error for action a, got %v", err)
+	}
+}
+
+func TestGenerateIRBTemplatesOnlyWithReferences(t *testing.T) {
+	bp, err := blueprint.NewFromBytes([]byte(`{"actions": [{
+		"provider": "generic",
+		"action_id": "a",
+		"action": "log",
+		"first_action": true,
+		"parameters": {"content": "plain text"},
+		"next_action": {"ok": ["b"]}
+	}, {
+		"provider": "generic",
+		"action_id": "b",
+		"action": "log",
+		"parameters": {"content": "hi {{ NAME }}", "level": "info"},
+		"next_action": {}
+	}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	irb, err := blueprint.GenerateIRB(bp, &blueprint.IRBGenConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !irb.Actions["a"].Templates.Empty() {
+		t.Errorf("action without references should have no compiled templates")
+	}
+	if irb.Actions["b"].Templates.Empty() {
+		t.Errorf("action with references should have compiled templates")
+	}
+
+	// only the strings of the parameters are cached
+	cache := irb.Actions["b"].Templates
+	tpl1, _ := cache.Get("hi {{ NAME }}")
+	tpl2, _ := cache.Get("hi {{ NAME }}")
+	if tpl1 == nil || tpl1 != tpl2 {
+		t.Errorf("parameter templates should be cached")
+	}
+	tpl1, _ = cache.Get("file {{ NAME }}")
+	tpl2, _ = cache.Get("file {{ NAME }}")
+	if tpl1 == nil || tpl1 == tpl2 {
+		t.Errorf("other texts should not be cached")
+	}
+}
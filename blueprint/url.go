@@ -26,7 +26,9 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 )
 
 type BlueprintURL struct {
@@ -99,3 +101,31 @@ func ParseURL(path string) (*BlueprintURL, error) {
 
 	return out, nil
 }
+
+// Name func. Human readable name of the blueprint: the file name
+// without extension or the org/collection/blueprint path
+func (b *BlueprintURL) Name() string {
+	if b.Scheme == "file" {
+		name := filepath.Base(b.FilePath)
+		return strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	parts := []string{}
+	for _, part := range []string{b.OrganizationSlug, b.CollectionSlug, b.BlueprintSlug} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// ParseRef func. Parses a blueprint reference from a config file.
+// Relative file paths are relative to baseDir.
+func ParseRef(ref string, file bool, baseDir string) (*BlueprintURL, error) {
+	if !file {
+		return ParseURL(ref)
+	}
+	if !filepath.IsAbs(ref) {
+		ref = filepath.Join(baseDir, ref)
+	}
+	return ParsePath(ref)
+}
@@ -0,0 +1,269 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cast
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ActionLogIndexFile const. Name of the index into the log dir. Every
+// line is the state of a run, the last line of a run is the final one.
+const ActionLogIndexFile = "index.jsonl"
+
+// ActionLogs writes the logs of every action run to his own file,
+// so the interleaved output of the threads can be read by action
+var ActionLogs = &actionLogs{
+	running:  make(map[string]*ActionLogRun),
+	attempts: make(map[string]int),
+	runIDs:   make(map[string]int64),
+}
+
+var actionLogNameRe = regexp.MustCompile(`[^-a-zA-Z0-9_.]+`)
+
+// ActionLogRun struct. Entry of the index, one by action run
+type ActionLogRun struct {
+	ExecutionUUID string `json:"execution_uuid"`
+	// sequential into the execution
+	RunID      int64      `json:"run_id"`
+	ThreadID   string     `json:"thread_id,omitempty"`
+	ActionID   string     `json:"action_id"`
+	ActionName string     `json:"action_name"`
+	Provider   string     `json:"provider"`
+	Attempt    int        `json:"attempt"`
+	File       string     `json:"file"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	mu         sync.Mutex
+	f          *os.File
+}
+
+type actionLogs struct {
+	mu  sync.Mutex
+	dir string
+	// running runs by execution and thread, a thread
+	// runs only one action at a time
+	running  map[string]*ActionLogRun
+	attempts map[string]int
+	runIDs   map[string]int64
+	indexMu  sync.Mutex
+	index    *os.File
+}
+
+// SetDir func. Enables the capture of the action logs into dir
+func (a *actionLogs) SetDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	index, err := os.OpenFile(filepath.Join(dir, ActionLogIndexFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600) // #nosec G304 -- path from user flag
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.dir = dir
+	a.indexMu.Lock()
+	defer a.indexMu.Unlock()
+	if a.index != nil {
+		a.index.Close() // #nosec G104 -- Unhandle is OK here
+	}
+	a.index = index
+	return nil
+}
+
+// Enabled func
+func (a *actionLogs) Enabled() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.dir != ""
+}
+
+func actionLogKey(eid string, id string) string {
+	return eid + "/" + id
+}
+
+// Begin func. Opens the log file of a new run of the action into
+// the dir of his execution. Returns nil if the capture is disabled.
+// On error the run is also returned, so End can close it.
+func (a *actionLogs) Begin(eid string, threadID string, actionID string, actionName string, provider string) (*ActionLogRun, error) {
+	a.mu.Lock()
+	if a.dir == "" {
+		a.mu.Unlock()
+		return nil, nil
+	}
+	dir := filepath.Join(a.dir, actionLogNameRe.ReplaceAllString(eid, "_"))
+	a.attempts[actionLogKey(eid, actionID)]++
+	a.runIDs[eid]++
+	run := &ActionLogRun{
+		ExecutionUUID: eid,
+		RunID:         a.runIDs[eid],
+		ThreadID:      threadID,
+		ActionID:      actionID,
+		ActionName:    actionName,
+		Provider:      provider,
+		Attempt:       a.attempts[actionLogKey(eid, actionID)],
+		Status:        "running",
+		StartedAt:     time.Now().UTC(),
+	}
+	run.File = filepath.Join(filepath.Base(dir), fmt.Sprintf("%d_%s_%s_%d.log", run.RunID,
+		actionLogNameRe.ReplaceAllString(actionID, "_"), actionLogNameRe.ReplaceAllString(actionName, "_"), run.Attempt))
+	a.running[actionLogKey(eid, threadID)] = run
+	logDir := a.dir
+	a.mu.Unlock()
+
+	// the file is opened unlocked, the run
+	// is not written until he has it
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return run, err
+	}
+	f, err := os.OpenFile(filepath.Join(logDir, run.File), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600) // #nosec G304 -- sanitized names into the log dir
+	if err != nil {
+		return run, err
+	}
+	run.f = f
+	fmt.Fprintf(f, "# action %s (%s/%s) attempt %d\n# execution %s, run %d\n# started at %s\n\n",
+		actionID, provider, actionName, run.Attempt, eid, run.RunID, run.StartedAt.Format(time.RFC3339Nano))
+	return run, a.appendIndex(run)
+}
+
+// Section func. Appends a named block of output, like the stdout
+// of a script, to the log file of the run
+func (a *actionLogs) Section(run *ActionLogRun, name string, data string) {
+	if run == nil || data == "" {
+		return
+	}
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if run.f == nil {
+		return
+	}
+	fmt.Fprintf(run.f, "\n----- %s -----\n%s", name, data)
+	if !strings.HasSuffix(data, "\n") {
+		fmt.Fprint(run.f, "\n")
+	}
+}
+
+// End func. Closes the log file of the run and appends
+// his final state to the index
+func (a *actionLogs) End(run *ActionLogRun, err error) error {
+	if run == nil {
+		return nil
+	}
+	a.mu.Lock()
+	key := actionLogKey(run.ExecutionUUID, run.ThreadID)
+	if a.running[key] == run {
+		delete(a.running, key)
+	}
+	a.mu.Unlock()
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	now := time.Now().UTC()
+	run.FinishedAt = &now
+	run.Status = "ok"
+	if err != nil {
+		run.Status = "ko"
+		run.Error = err.Error()
+	}
+	if run.f != nil {
+		fmt.Fprintf(run.f, "\n# finished at %s, status %s\n", now.Format(time.RFC3339Nano), run.Status)
+		if run.Error != "" {
+			fmt.Fprintf(run.f, "# error: %s\n", run.Error)
+		}
+		run.f.Close() // #nosec G104 -- Unhandle is OK here
+		run.f = nil
+	}
+	return a.appendIndex(run)
+}
+
+// capture writes the log into the file of the run of his thread.
+// Called on log emission, before the level filter, so the files
+// also get the debug messages.
+func (a *actionLogs) capture(bdata *BusData) {
+	if bdata.ExecutionUUID == nil || bdata.ActionID == nil || bdata.M == nil {
+		return
+	}
+	threadID := ""
+	if bdata.ThreadID != nil {
+		threadID = *bdata.ThreadID
+	}
+	a.mu.Lock()
+	run, exists := a.running[actionLogKey(*bdata.ExecutionUUID, threadID)]
+	a.mu.Unlock()
+	if !exists || run.ActionID != *bdata.ActionID {
+		return
+	}
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if run.f == nil {
+		return
+	}
+	if bdata.Raw {
+		// raw output of commands, already chunked by lines
+		fmt.Fprint(run.f, *bdata.M)
+		return
+	}
+	ts := time.UnixMicro(bdata.Timestamp).UTC().Format("15:04:05.000000")
+	fmt.Fprintf(run.f, "%s %-8s %s\n", ts, logLevelName(*bdata.LogLevel), *bdata.M)
+}
+
+// appendIndex func. run.mu should be locked
+func (a *actionLogs) appendIndex(run *ActionLogRun) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	a.indexMu.Lock()
+	defer a.indexMu.Unlock()
+	if a.index == nil {
+		return nil
+	}
+	_, err = a.index.Write(append(data, '\n'))
+	return err
+}
+
+func logLevelName(level int) string {
+	switch level {
+	case CriticalLevel:
+		return "CRITICAL"
+	case ErrorLevel:
+		return "ERROR"
+	case WarningLevel:
+		return "WARNING"
+	case InfoLevel:
+		return "INFO"
+	case DebugLevel:
+		return "DEBUG"
+	}
+	return fmt.Sprintf("LEVEL%d", level)
+}
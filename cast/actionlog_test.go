@@ -0,0 +1,157 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cast
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newTestActionLogs(t *testing.T) (*actionLogs, string) {
+	a := &actionLogs{
+		running:  make(map[string]*ActionLogRun),
+		attempts: make(map[string]int),
+		runIDs:   make(map[string]int64),
+	}
+	dir := t.TempDir()
+	if err := a.SetDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.index.Close() })
+	return a, dir
+}
+
+func captureLog(a *actionLogs, eid string, threadID string, actionID string, msg string) {
+	level := InfoLevel
+	a.capture(&BusData{TypeID: BusDataTypeLog, LogLevel: &level, M: &msg, ExecutionUUID: &eid, ThreadID: &threadID, ActionID: &actionID})
+}
+
+func readIndex(t *testing.T, dir string) []*ActionLogRun {
+	f, err := os.Open(filepath.Join(dir, ActionLogIndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var runs []*ActionLogRun
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		run := &ActionLogRun{}
+		if err := json.Unmarshal(scanner.Bytes(), run); err != nil {
+			t.Fatal(err)
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+func TestActionLogsConcurrentRuns(t *testing.T) {
+	a, dir := newTestActionLogs(t)
+	// the same action running in two threads
+	run1, err := a.Begin("exec", "t1", "a", "log", "generic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	run2, err := a.Begin("exec", "t2", "a", "log", "generic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	captureLog(a, "exec", "t1", "a", "from t1")
+	captureLog(a, "exec", "t2", "a", "from t2")
+	a.Section(run1, "stdout", "out t1")
+	if err := a.End(run2, fmt.Errorf("failed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.End(run1, nil); err != nil {
+		t.Fatal(err)
+	}
+	// logs after the end are not captured
+	captureLog(a, "exec", "t1", "a", "late")
+
+	for _, tc := range []struct {
+		run      *ActionLogRun
+		expected string
+		other    string
+	}{
+		{run1, "from t1", "from t2"},
+		{run2, "from t2", "from t1"},
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, tc.run.File))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), tc.expected) || strings.Contains(string(data), tc.other) || strings.Contains(string(data), "late") {
+			t.Errorf("unexpected log of run %d:\n%s", tc.run.RunID, data)
+		}
+	}
+	if run1.File == run2.File || run1.Attempt != 1 || run2.Attempt != 2 {
+		t.Errorf("unexpected runs %s %d, %s %d", run1.File, run1.Attempt, run2.File, run2.Attempt)
+	}
+
+	// begin and end of each run
+	runs := readIndex(t, dir)
+	if len(runs) != 4 {
+		t.Fatalf("expected 4 index entries, got %d", len(runs))
+	}
+	if runs[2].RunID != run2.RunID || runs[2].Status != "ko" || runs[2].Error != "failed" || runs[3].Status != "ok" {
+		t.Errorf("unexpected index %+v %+v", runs[2], runs[3])
+	}
+}
+
+func TestActionLogsExecutions(t *testing.T) {
+	a, dir := newTestActionLogs(t)
+	var wg sync.WaitGroup
+	files := make([]string, 2)
+	for i, eid := range []string{"exec1", "exec2"} {
+		wg.Add(1)
+		go func(i int, eid string) {
+			defer wg.Done()
+			run, err := a.Begin(eid, "", "a", "log", "generic")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			captureLog(a, eid, "", "a", "log of "+eid)
+			files[i] = run.File
+			a.End(run, nil) // #nosec G104 -- Unhandle is OK here
+		}(i, eid)
+	}
+	wg.Wait()
+	if files[0] == files[1] || filepath.Dir(files[0]) != "exec1" {
+		t.Errorf("runs of different executions should have their own files: %v", files)
+	}
+	for i, eid := range []string{"exec1", "exec2"} {
+		data, err := os.ReadFile(filepath.Join(dir, files[i]))
+		if err != nil || !strings.Contains(string(data), "log of "+eid) {
+			t.Errorf("unexpected log %s (%v)", data, err)
+		}
+	}
+	if runs := readIndex(t, dir); len(runs) != 4 {
+		t.Errorf("expected 4 index entries, got %d", len(runs))
+	}
+}
@@ -0,0 +1,270 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cast
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os/user"
+	"sync"
+	"time"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/config"
+	"github.com/develatio/nebulant-cli/term"
+)
+
+// Approvals is the globally shared registry of pending approvals
+var Approvals = &ApprovalRegistry{
+	pending: make(map[string]*pendingApproval),
+}
+
+// ApprovalDecision struct
+type ApprovalDecision struct {
+	Approved bool      `json:"approved"`
+	By       string    `json:"by"`
+	At       time.Time `json:"at"`
+	Comment  string    `json:"comment,omitempty"`
+	// Filled internally, the approval expired
+	// before anyone approved or rejected it
+	TimedOut bool `json:"timed_out,omitempty"`
+	// Filled internally, By comes from a validated API
+	// token and not from the client. Required by the
+	// approvers.
+	Authenticated bool `json:"-"`
+}
+
+// Approval struct. An approval requested by an action
+type Approval struct {
+	ID            string     `json:"id"`
+	ExecutionUUID *string    `json:"execution_uuid,omitempty"`
+	ActionID      *string    `json:"action_id,omitempty"`
+	ThreadID      *string    `json:"thread_id,omitempty"`
+	Message       string     `json:"message"`
+	Approvers     []string   `json:"approvers,omitempty"`
+	RequestedAt   time.Time  `json:"requested_at"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+}
+
+type pendingApproval struct {
+	approval *Approval
+	decision chan *ApprovalDecision
+	// stops the console prompt, if any
+	cancelPrompt context.CancelFunc
+}
+
+// ApprovalRegistry struct
+type ApprovalRegistry struct {
+	mu            sync.Mutex
+	pending       map[string]*pendingApproval
+	consolePrompt bool
+}
+
+// SetConsolePrompt func. Enable or disable the prompt of pending
+// approvals in the console. The prompt is only shown on terminals.
+func (a *ApprovalRegistry) SetConsolePrompt(on bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.consolePrompt = on
+}
+
+// Request func. Register a new pending approval and announce it. The
+// returned chan receives the decision once someone resolves it.
+func (a *ApprovalRegistry) Request(approval *Approval) (<-chan *ApprovalDecision, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	approval.ID = hex.EncodeToString(b)
+	approval.RequestedAt = time.Now().UTC()
+	promptCtx, cancelPrompt := context.WithCancel(context.Background())
+	p := &pendingApproval{
+		approval:     approval,
+		decision:     make(chan *ApprovalDecision, 1),
+		cancelPrompt: cancelPrompt,
+	}
+
+	a.mu.Lock()
+	a.pending[approval.ID] = p
+	prompt := a.consolePrompt
+	a.mu.Unlock()
+
+	PushEventWithExtra(EventApprovalPending, approval.ExecutionUUID, approvalExtra(approval, nil))
+	if prompt && term.IsTerminal() {
+		if len(approval.Approvers) > 0 {
+			// the console can not identify the user
+			LogWarn("Approval "+approval.ID+" has approvers and can only be resolved with an API token", approval.ExecutionUUID)
+		} else {
+			go a.promptApproval(promptCtx, approval)
+		}
+	}
+	return p.decision, nil
+}
+
+// Get func. Returns a pending approval
+func (a *ApprovalRegistry) Get(id string) (*Approval, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p, exists := a.pending[id]
+	if !exists {
+		return nil, fmt.Errorf("approval %s not found or already resolved", id)
+	}
+	cp := *p.approval
+	return &cp, nil
+}
+
+// Pending func. Returns the pending approvals of an execution,
+// or all pending approvals if executionUUID is empty
+func (a *ApprovalRegistry) Pending(executionUUID string) []*Approval {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var approvals []*Approval
+	for _, p := range a.pending {
+		if executionUUID != "" && (p.approval.ExecutionUUID == nil || *p.approval.ExecutionUUID != executionUUID) {
+			continue
+		}
+		cp := *p.approval
+		approvals = append(approvals, &cp)
+	}
+	return approvals
+}
+
+// Resolve func. Approve or reject a pending approval
+func (a *ApprovalRegistry) Resolve(id string, decision *ApprovalDecision) error {
+	a.mu.Lock()
+	p, exists := a.pending[id]
+	if !exists {
+		a.mu.Unlock()
+		return fmt.Errorf("approval %s not found or already resolved", id)
+	}
+	if !decision.TimedOut && len(p.approval.Approvers) > 0 {
+		allowed := false
+		if !decision.Authenticated {
+			a.mu.Unlock()
+			return fmt.Errorf("the approval %s requires an API token", id)
+		}
+		for _, approver := range p.approval.Approvers {
+			if approver == decision.By {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			a.mu.Unlock()
+			return fmt.Errorf("%s is not allowed to resolve the approval %s", decision.By, id)
+		}
+	}
+	delete(a.pending, id)
+	a.mu.Unlock()
+	p.cancelPrompt()
+
+	if decision.At.IsZero() {
+		decision.At = time.Now().UTC()
+	}
+	p.decision <- decision
+	PushEventWithExtra(EventApprovalResolved, p.approval.ExecutionUUID, approvalExtra(p.approval, decision))
+	return nil
+}
+
+// Cancel func. Discard a pending approval without decision,
+// eg. when the execution is stopped
+func (a *ApprovalRegistry) Cancel(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if p, exists := a.pending[id]; exists {
+		p.cancelPrompt()
+		delete(a.pending, id)
+	}
+}
+
+// TokenIdentity func. Validates an API token created with
+// `nebulant serve token create` and returns his name, used
+// as the identity of the approver
+func TokenIdentity(raw string) (string, error) {
+	if raw == "" {
+		return "", fmt.Errorf("missing API token")
+	}
+	token, err := config.ValidateAPIToken(raw)
+	if err != nil {
+		return "", err
+	}
+	if token.Name == "" {
+		return token.ID, nil
+	}
+	return token.Name, nil
+}
+
+func (a *ApprovalRegistry) promptApproval(ctx context.Context, approval *Approval) {
+	optidx, err := term.SelectableContext(ctx, "Approval required: "+approval.Message, []string{"Approve", "Reject"})
+	if err != nil || optidx < 0 {
+		// resolved from another place, timed out
+		// or cancelled while prompting
+		return
+	}
+	// the user at the console is not authenticated, so
+	// this is only used for approvals without approvers
+	by := "console"
+	if u, err := user.Current(); err == nil {
+		by = "console:" + u.Username
+	}
+	err = a.Resolve(approval.ID, &ApprovalDecision{Approved: optidx == 0, By: by})
+	if err != nil {
+		// already resolved from another place
+		LogDebug(err.Error(), approval.ExecutionUUID)
+	}
+}
+
+func approvalExtra(approval *Approval, decision *ApprovalDecision) map[string]interface{} {
+	extra := map[string]interface{}{
+		"approval_id": approval.ID,
+		"message":     approval.Message,
+		"approvers":   approval.Approvers,
+	}
+	if approval.ActionID != nil {
+		extra["action_id"] = *approval.ActionID
+	}
+	if approval.ExpiresAt != nil {
+		extra["expires_at"] = approval.ExpiresAt.Format(time.RFC3339)
+	}
+	if decision != nil {
+		extra["approved"] = decision.Approved
+		extra["by"] = decision.By
+		extra["at"] = decision.At.Format(time.RFC3339)
+		extra["timed_out"] = decision.TimedOut
+	}
+	return extra
+}
+
+// LoggerIDs func. Returns the execution, action and thread ids
+// known by the logger
+func LoggerIDs(l base.ILogger) (executionUUID *string, actionID *string, threadID *string) {
+	switch ll := l.(type) {
+	case *Logger:
+		return ll.ExecutionUUID, ll.ActionID, ll.ThreadID
+	case *DummyLogger:
+		return ll.ExecutionUUID, ll.ActionID, ll.ThreadID
+	}
+	return nil, nil, nil
+}
@@ -0,0 +1,132 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cast
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Callbacks is the globally shared registry of callback tokens
+var Callbacks = &CallbackRegistry{
+	byToken: make(map[string]*Callback),
+}
+
+// CallbackEvent struct. The data received by a callback
+type CallbackEvent struct {
+	Token      string          `json:"token"`
+	Source     string          `json:"source"`
+	ReceivedAt time.Time       `json:"received_at"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// Callback struct. A token that external systems can use to
+// signal an action waiting for an event
+type Callback struct {
+	Token         string
+	ExecutionUUID *string
+	ActionID      string
+	events        chan *CallbackEvent
+}
+
+// Events func. Returns the chan of received events
+func (c *Callback) Events() <-chan *CallbackEvent {
+	return c.events
+}
+
+// CallbackRegistry struct
+type CallbackRegistry struct {
+	mu      sync.Mutex
+	byToken map[string]*Callback
+}
+
+// Register func. Creates a new callback for the action. The token is
+// prefixed with prefix (the IPC server id), so `nebulant signal` can
+// find the process that owns the token.
+func (c *CallbackRegistry) Register(prefix string, executionUUID *string, actionID string) (*Callback, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	cb := &Callback{
+		Token:         prefix + "." + hex.EncodeToString(b),
+		ExecutionUUID: executionUUID,
+		ActionID:      actionID,
+		// keep the event received before the
+		// action starts to wait
+		events: make(chan *CallbackEvent, 1),
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byToken[cb.Token] = cb
+	return cb, nil
+}
+
+// Signal func. Delivers body to the action waiting for token
+func (c *CallbackRegistry) Signal(token string, source string, body []byte) error {
+	c.mu.Lock()
+	cb, exists := c.byToken[token]
+	c.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("unknown callback token")
+	}
+	body = []byte(strings.TrimSpace(string(body)))
+	if len(body) <= 0 {
+		body = []byte("null")
+	}
+	if !json.Valid(body) {
+		return fmt.Errorf("the callback body is not valid JSON")
+	}
+	select {
+	case cb.events <- &CallbackEvent{
+		Token:      token,
+		Source:     source,
+		ReceivedAt: time.Now().UTC(),
+		Body:       body,
+	}:
+	default:
+		return fmt.Errorf("there is already a pending event for this callback")
+	}
+	PushEventWithExtra(EventCallbackSignaled, cb.ExecutionUUID, map[string]interface{}{
+		"action_id": cb.ActionID,
+		"source":    source,
+	})
+	return nil
+}
+
+// Unregister func. Removes the callbacks of an execution
+func (c *CallbackRegistry) Unregister(executionUUID *string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for token, cb := range c.byToken {
+		if (cb.ExecutionUUID == nil && executionUUID == nil) ||
+			(cb.ExecutionUUID != nil && executionUUID != nil && *cb.ExecutionUUID == *executionUUID) {
+			delete(c.byToken, token)
+		}
+	}
+}
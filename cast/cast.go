@@ -30,6 +30,7 @@ import (
 
 	"github.com/develatio/nebulant-cli/base"
 	"github.com/develatio/nebulant-cli/config"
+	"github.com/develatio/nebulant-cli/util"
 )
 
 // CriticalLevel const
@@ -137,6 +138,16 @@ const (
 	EventWaitingForState
 	// EventActionUnCaughtKO 16
 	EventActionUnCaughtKO
+	// EventApprovalPending 17
+	EventApprovalPending
+	// EventApprovalResolved 18
+	EventApprovalResolved
+	// EventCallbackSignaled 19
+	EventCallbackSignaled
+	// EventNetworkTrace 20
+	EventNetworkTrace
+	// EventActionProgress 21
+	EventActionProgress
 )
 
 // BusData struct
@@ -153,8 +164,14 @@ type BusData struct {
 	EOF      bool    `json:"EOF,omitempty"`
 	// Event id
 	EventID *int `json:"event_id,omitempty"`
-	// State id
+	// State id. Only set on status data, holding the last runtime
+	// event (state) of the execution. Their values repeat on every
+	// status, so they can not identify a position into the history.
 	LastKnownEventID *int `json:"last_known_event_id,omitempty"`
+	// Position into the history of the execution, unique and
+	// growing on every data of any type, see LogHistory. Clients
+	// resume from here with the last_seq field of the join cmd.
+	Seq int64 `json:"seq,omitempty"`
 	// Extra data
 	// Be carefully on putting pointers here or
 	// race condition may occur
@@ -248,10 +265,7 @@ func (s *SystemBus) Start() {
 		case busdata := <-s.busBuffer:
 			// Calculate bus buffer load
 			e := len(SBus.busBuffer)
-			if e > 0 {
-				load := (float64(e) / float64(SBusBufferSize)) * 100.0
-				BInfo.SetLoad(load)
-			}
+			BInfo.SetLoad((float64(e) / float64(SBusBufferSize)) * 100.0)
 
 			// Discard logs as needed
 			if busdata.TypeID == BusDataTypeEvent && busdata.ExecutionUUID != nil && busdata.EventID != nil {
@@ -271,6 +285,9 @@ func (s *SystemBus) Start() {
 					continue
 				}
 			}
+			if recordableBusData(busdata) {
+				LogHistory.record(busdata)
+			}
 
 			// Dispatch busdata to consumers
 			for busConsumerLink := range s.links {
@@ -317,6 +334,24 @@ func (s *SystemBus) Start() {
 	}
 }
 
+// recordableBusData returns true if busdata belongs to the
+// history of an execution
+func recordableBusData(busdata *BusData) bool {
+	if busdata.ExecutionUUID == nil || *busdata.ExecutionUUID == "" || busdata.ClientUUIDFilter != nil {
+		return false
+	}
+	switch busdata.TypeID {
+	case BusDataTypeLog:
+		return config.DEBUG || *busdata.LogLevel != DebugLevel
+	case BusDataTypeEvent:
+		// this event carries the manager, is for httpd only
+		return busdata.EventID == nil || *busdata.EventID != EventRegisteredManager
+	case BusDataTypeStatus:
+		return true
+	}
+	return false
+}
+
 // WIP: esto lo mismo podríamos moverlo a runtime
 // RegisterProviderInitFunc func
 func (s *SystemBus) RegisterProviderInitFunc(strname string, initfunc base.ProviderInitFunc) {
@@ -344,11 +379,10 @@ func (s *SystemBus) Close() *sync.WaitGroup {
 
 // Log func
 func Log(level int, m *string, ei *string, ai *string, ti *string, raw bool) {
-	// prevent debug messages on non-debug mode
-	if !config.DEBUG && level == DebugLevel {
+	if !config.PARANOICDEBUG && level == ParanoicDebugLevel {
 		return
 	}
-	if !config.PARANOICDEBUG && level == ParanoicDebugLevel {
+	if !config.DEBUG && level == DebugLevel && !ActionLogs.Enabled() {
 		return
 	}
 
@@ -371,6 +405,12 @@ func Log(level int, m *string, ei *string, ai *string, ti *string, raw bool) {
 		bdata.ThreadID = &tti
 	}
 	bdata.Timestamp = time.Now().UTC().UnixMicro()
+	ActionLogs.capture(bdata)
+
+	// prevent debug messages on non-debug mode
+	if !config.DEBUG && level == DebugLevel {
+		return
+	}
 	PushBusData(bdata)
 }
 
@@ -491,6 +531,42 @@ func PushFilteredBusData(clientUUIDFilter string, extra map[string]interface{})
 	PushBusData(bdata)
 }
 
+// NetworkTracer func. Returns the trace func of util.DebugTransport for
+// the actions logging through l. Traces are logged as debug messages
+// and pushed as EventNetworkTrace events.
+func NetworkTracer(l base.ILogger) func(t *util.HTTPTrace) {
+	return func(t *util.HTTPTrace) {
+		l.LogDebug(t.String())
+		var ei *string
+		extra := map[string]interface{}{"trace": t}
+		if cl, ok := l.(*Logger); ok {
+			ei = cl.ExecutionUUID
+			if cl.ActionID != nil {
+				extra["action_id"] = *cl.ActionID
+			}
+		}
+		PushEventWithExtra(EventNetworkTrace, ei, extra)
+	}
+}
+
+// PushActionProgress func. Pushes an EventActionProgress event with the
+// progress percentage (0-100) of the long running work of the action
+// logging through l. Nothing is pushed for non bus loggers.
+func PushActionProgress(l base.ILogger, msg string, progress int) {
+	cl, ok := l.(*Logger)
+	if !ok || SBus == nil {
+		return
+	}
+	extra := map[string]interface{}{
+		"message":  msg,
+		"progress": progress,
+	}
+	if cl.ActionID != nil {
+		extra["action_id"] = *cl.ActionID
+	}
+	PushEventWithExtra(EventActionProgress, cl.ExecutionUUID, extra)
+}
+
 // Logger struct
 type Logger struct {
 	ExecutionUUID *string
@@ -610,13 +686,36 @@ func (l *DummyLogger) LogDebug(s string)    {}
 // BusInfo struct
 type BusInfo struct {
 	mu   sync.Mutex
+	cond *sync.Cond
 	Load float64
 }
 
+func (b *BusInfo) init() {
+	if b.cond == nil {
+		b.cond = sync.NewCond(&b.mu)
+	}
+}
+
 func (b *BusInfo) SetLoad(l float64) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.init()
 	b.Load = l
+	b.cond.Broadcast()
+}
+
+// WaitLoadBelow blocks until the bus load drops under max. It returns true
+// if the caller had to wait.
+func (b *BusInfo) WaitLoadBelow(max float64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.init()
+	waited := false
+	for b.Load > max {
+		waited = true
+		b.cond.Wait()
+	}
+	return waited
 }
 
 func (b *BusInfo) GetLoad() float64 {
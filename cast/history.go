@@ -0,0 +1,314 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cast
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// LogHistorySize const. Max bus data kept in memory per execution
+const LogHistorySize = 10000
+
+// LogHistoryExecutions const. Max executions with history kept
+const LogHistoryExecutions = 100
+
+// LogHistory keeps the last bus data (logs, events and status) of
+// the executions, so late clients can replay them
+var LogHistory = &logHistory{execs: make(map[string]*executionHistory)}
+
+// executionHistory is a ring buffer growing up to LogHistorySize.
+// Evicted data is appended to the spill file if there is a spill dir.
+type executionHistory struct {
+	buf []*BusData
+	// index of the oldest data once the buffer is full
+	start int
+	// seq of the last recorded data, seqs start at 1
+	lastSeq   int64
+	spillPath string
+	// only used by the spill writer
+	spill *os.File
+	// seq of the last evicted data handled by the spill writer
+	spilledSeq int64
+	// data that could not be spilled, lost
+	spillErrors int64
+}
+
+// spillJob is a data evicted from memory to be written to the
+// spill file, or the removal of the file if bdata is nil
+type spillJob struct {
+	eid   string
+	ehist *executionHistory
+	bdata *BusData
+}
+
+type logHistory struct {
+	mu    sync.Mutex
+	execs map[string]*executionHistory
+	// executions by arrival, to forget the older ones
+	order    []string
+	spillDir string
+	// the spill files are written by the spill writer goroutine,
+	// out of the bus goroutine and without holding mu
+	spillQueue []*spillJob
+	spillWake  chan struct{}
+	// broadcasted when the spill writer makes progress
+	spilled *sync.Cond
+}
+
+// SetSpillDir func. Evicted data will be written to dir
+func (h *logHistory) SetSpillDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.spillDir = dir
+	return nil
+}
+
+// record assigns the next seq of his execution to bdata
+// and stores it. Only called from the bus goroutine.
+func (h *logHistory) record(bdata *BusData) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	eid := *bdata.ExecutionUUID
+	ehist, exists := h.execs[eid]
+	if !exists {
+		ehist = &executionHistory{}
+		h.execs[eid] = ehist
+		h.order = append(h.order, eid)
+		if len(h.order) > LogHistoryExecutions {
+			h.forget(h.order[0])
+			h.order = h.order[1:]
+		}
+	}
+	ehist.lastSeq++
+	bdata.Seq = ehist.lastSeq
+	if len(ehist.buf) < LogHistorySize {
+		ehist.buf = append(ehist.buf, bdata)
+		return
+	}
+	// full, evict the oldest
+	h.spillData(eid, ehist, ehist.buf[ehist.start])
+	ehist.buf[ehist.start] = bdata
+	ehist.start = (ehist.start + 1) % len(ehist.buf)
+}
+
+// forget func. h.mu should be locked
+func (h *logHistory) forget(eid string) {
+	ehist, exists := h.execs[eid]
+	if !exists {
+		return
+	}
+	delete(h.execs, eid)
+	if ehist.spillPath != "" {
+		h.queueSpill(&spillJob{eid: eid, ehist: ehist})
+	}
+	if h.spilled != nil {
+		// wake up the replays waiting for it
+		h.spilled.Broadcast()
+	}
+}
+
+// spillData func. h.mu should be locked
+func (h *logHistory) spillData(eid string, ehist *executionHistory, bdata *BusData) {
+	if h.spillDir == "" {
+		ehist.spilledSeq = bdata.Seq
+		return
+	}
+	if ehist.spillPath == "" {
+		ehist.spillPath = filepath.Join(h.spillDir, filepath.Base(eid)+".jsonl")
+	}
+	h.queueSpill(&spillJob{eid: eid, ehist: ehist, bdata: bdata})
+}
+
+// queueSpill func. h.mu should be locked
+func (h *logHistory) queueSpill(job *spillJob) {
+	if h.spillWake == nil {
+		h.spillWake = make(chan struct{}, 1)
+		h.spilled = sync.NewCond(&h.mu)
+		go h.spillWriter()
+	}
+	h.spillQueue = append(h.spillQueue, job)
+	select {
+	case h.spillWake <- struct{}{}:
+	default:
+		// already awake
+	}
+}
+
+// spillWriter writes the queued jobs to the spill files
+func (h *logHistory) spillWriter() {
+	for range h.spillWake {
+		h.mu.Lock()
+		jobs := h.spillQueue
+		h.spillQueue = nil
+		h.mu.Unlock()
+		for _, job := range jobs {
+			if job.bdata == nil {
+				if job.ehist.spill != nil {
+					job.ehist.spill.Close() // #nosec G104 -- Unhandle is OK here
+				}
+				os.Remove(job.ehist.spillPath) // #nosec G104 -- Unhandle is OK here
+				continue
+			}
+			err := writeSpill(job.ehist, job.bdata)
+			h.mu.Lock()
+			if err != nil {
+				job.ehist.spillErrors++
+			}
+			first := job.ehist.spillErrors == 1
+			job.ehist.spilledSeq = job.bdata.Seq
+			h.spilled.Broadcast()
+			h.mu.Unlock()
+			if err != nil && first {
+				// the error can not be logged through the
+				// bus, it could be full. Report only the first one.
+				log.Printf("Cannot spill history of execution %s: %v", job.eid, err)
+			}
+		}
+	}
+}
+
+// writeSpill func. Only called from the spill writer
+func writeSpill(ehist *executionHistory, bdata *BusData) error {
+	if ehist.spill == nil {
+		f, err := os.OpenFile(ehist.spillPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600) // #nosec G304 -- path from execution uuid
+		if err != nil {
+			return err
+		}
+		ehist.spill = f
+	}
+	data, err := json.Marshal(bdata)
+	if err != nil {
+		return err
+	}
+	_, err = ehist.spill.Write(append(data, '\n'))
+	return err
+}
+
+// SpillErrors func. Returns the count of data of the execution
+// lost because it could not be written to the spill file
+func (h *logHistory) SpillErrors(eid string) int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ehist, exists := h.execs[eid]
+	if !exists {
+		return 0
+	}
+	return ehist.spillErrors
+}
+
+// readSpilled returns the spilled data with from <= seq < until.
+// Runs without lock, the data with seq < until is already written
+// and later writes are skipped, even if they are read partially.
+func readSpilled(spillPath string, from int64, until int64) ([]*BusData, error) {
+	f, err := os.Open(spillPath) // #nosec G304 -- path from execution uuid
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []*BusData
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 65536), 16*1024*1024)
+	for scanner.Scan() {
+		bdata := &BusData{}
+		if err := json.Unmarshal(scanner.Bytes(), bdata); err != nil {
+			continue
+		}
+		if bdata.Seq >= until {
+			break
+		}
+		if bdata.Seq >= from {
+			out = append(out, bdata)
+		}
+	}
+	return out, scanner.Err()
+}
+
+// Replay func. Returns the data of the execution with seq >= from
+// and the seq of the last recorded data. Data evicted from memory
+// is only available with a spill dir.
+func (h *logHistory) Replay(eid string, from int64) ([]*BusData, int64, error) {
+	// snapshot the memory data, the spill file
+	// is read once unlocked
+	h.mu.Lock()
+	ehist, exists := h.execs[eid]
+	if !exists {
+		h.mu.Unlock()
+		return nil, 0, fmt.Errorf("no history found for execution %s", eid)
+	}
+	var lastSeq, oldest int64
+	for {
+		lastSeq = ehist.lastSeq
+		oldest = lastSeq - int64(len(ehist.buf)) + 1
+		if from >= oldest || ehist.spillPath == "" || ehist.spilledSeq >= oldest-1 || h.execs[eid] != ehist {
+			break
+		}
+		// wait until the spill writer handles
+		// the data evicted from memory
+		h.spilled.Wait()
+	}
+	spillPath := ehist.spillPath
+	var mem []*BusData
+	for i := 0; i < len(ehist.buf); i++ {
+		bdata := ehist.buf[(ehist.start+i)%len(ehist.buf)]
+		if bdata.Seq >= from {
+			mem = append(mem, bdata)
+		}
+	}
+	h.mu.Unlock()
+
+	var out []*BusData
+	if from < oldest && spillPath != "" {
+		spilled, err := readSpilled(spillPath, from, oldest)
+		if err != nil {
+			// forgotten meanwhile or unreadable,
+			// replay what is in memory
+			log.Printf("Cannot read spilled history of execution %s: %v", eid, err)
+		}
+		out = spilled
+	}
+	return append(out, mem...), lastSeq, nil
+}
+
+// Logs func. Like Replay, but only log data
+func (h *logHistory) Logs(eid string, from int64) ([]*BusData, int64, error) {
+	all, lastSeq, err := h.Replay(eid, from)
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make([]*BusData, 0, len(all))
+	for _, bdata := range all {
+		if bdata.TypeID == BusDataTypeLog {
+			out = append(out, bdata)
+		}
+	}
+	return out, lastSeq, nil
+}
@@ -0,0 +1,200 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cast
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func newTestBusData(eid string) *BusData {
+	level := InfoLevel
+	msg := "msg"
+	return &BusData{TypeID: BusDataTypeLog, LogLevel: &level, M: &msg, ExecutionUUID: &eid}
+}
+
+func checkSeqs(t *testing.T, data []*BusData, first int64, last int64) {
+	t.Helper()
+	if int64(len(data)) != last-first+1 {
+		t.Fatalf("expected %d data, got %d", last-first+1, len(data))
+	}
+	for i, bdata := range data {
+		if bdata.Seq != first+int64(i) {
+			t.Fatalf("expected seq %d at %d, got %d", first+int64(i), i, bdata.Seq)
+		}
+	}
+}
+
+func TestHistoryRingOverflow(t *testing.T) {
+	h := &logHistory{execs: make(map[string]*executionHistory)}
+	total := int64(LogHistorySize + 5)
+	for i := int64(0); i < total; i++ {
+		h.record(newTestBusData("exec"))
+	}
+	// without spill dir the evicted data is lost
+	data, lastSeq, err := h.Replay("exec", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastSeq != total {
+		t.Errorf("expected last seq %d, got %d", total, lastSeq)
+	}
+	checkSeqs(t, data, 6, total)
+
+	data, _, _ = h.Replay("exec", total-1)
+	checkSeqs(t, data, total-1, total)
+
+	if _, _, err := h.Replay("unknown", 1); err == nil {
+		t.Errorf("unknown executions should fail")
+	}
+}
+
+func TestHistorySpillReplay(t *testing.T) {
+	h := &logHistory{execs: make(map[string]*executionHistory)}
+	if err := h.SetSpillDir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	total := int64(2*LogHistorySize + 3)
+	for i := int64(0); i < total; i++ {
+		h.record(newTestBusData("exec"))
+	}
+	// spilled data comes first, in order
+	data, _, err := h.Replay("exec", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSeqs(t, data, 1, total)
+	data, _, _ = h.Replay("exec", LogHistorySize)
+	checkSeqs(t, data, LogHistorySize, total)
+	if n := h.SpillErrors("exec"); n != 0 {
+		t.Errorf("unexpected spill errors %d", n)
+	}
+
+	// forgotten executions remove the spill file
+	h.mu.Lock()
+	spillPath := h.execs["exec"].spillPath
+	h.forget("exec")
+	h.mu.Unlock()
+	// removed by the spill writer
+	deadline := time.Now().Add(5 * time.Second)
+	for _, err := os.Stat(spillPath); !os.IsNotExist(err); _, err = os.Stat(spillPath) {
+		if time.Now().After(deadline) {
+			t.Fatalf("spill file should be removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHistorySpillErrors(t *testing.T) {
+	// a file can not be used as dir
+	notDir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(notDir, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	h := &logHistory{execs: make(map[string]*executionHistory), spillDir: notDir}
+	for i := 0; i < LogHistorySize+3; i++ {
+		h.record(newTestBusData("exec"))
+	}
+	// the replay waits for the spill writer
+	data, _, err := h.Replay("exec", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSeqs(t, data, 4, LogHistorySize+3)
+	if n := h.SpillErrors("exec"); n != 3 {
+		t.Errorf("expected 3 spill errors, got %d", n)
+	}
+}
+
+func TestWebSocketJoinReplay(t *testing.T) {
+	if SBus == nil {
+		InitSystemBus()
+	}
+	eid := "exec-ws-replay"
+	for i := 0; i < 5; i++ {
+		LogHistory.record(newTestBusData(eid))
+	}
+	defer func() {
+		LogHistory.mu.Lock()
+		LogHistory.forget(eid)
+		LogHistory.mu.Unlock()
+	}()
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		NewWebSocketLogger(conn, "client-ws-replay")
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		join  string
+		first int64
+	}{
+		{`{"cmd": "join", "param": "` + eid + `", "last_seq": 2}`, 3},
+		{`{"cmd": "join", "param": "` + eid + `", "last_seq": 0}`, 1},
+	} {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(tc.join)); err != nil {
+			t.Fatal(err)
+		}
+		acked := false
+		var seqs []int64
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second)) // #nosec G104 -- Unhandle is OK here
+		for !acked || len(seqs) < int(5-tc.first+1) {
+			msg := make(map[string]interface{})
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("%s: %v", tc.join, err)
+			}
+			if msg["cmd"] == "join" {
+				acked = msg["ok"] == true
+				continue
+			}
+			if msg["execution_uuid"] != eid {
+				continue
+			}
+			seqs = append(seqs, int64(msg["seq"].(float64)))
+		}
+		for i, seq := range seqs {
+			if seq != tc.first+int64(i) {
+				t.Errorf("%s: unexpected replayed seqs %v", tc.join, seqs)
+				break
+			}
+		}
+		conn.Close()
+	}
+}
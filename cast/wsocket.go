@@ -60,12 +60,32 @@ type WSocketLogger struct {
 	conn  *websocket.Conn
 	fLink *BusConsumerLink
 	mu    sync.Mutex
+	// join requests with replay, handled by readCastBus
+	// so the replayed and the live data are not mixed
+	replay chan *replayRequest
+	// execution uuid -> seq of the last replayed data.
+	// Only used from readCastBus.
+	replayed map[string]int64
+}
+
+type replayRequest struct {
+	executionUUID string
+	from          int64
 }
 
 type clientMsg struct {
 	Cmd   string `json:"cmd" validate:"required"`
 	Param string `json:"param" validate:"required"`
 	Ok    bool   `json:"ok"`
+	// approve/reject cmds, token is an API token
+	// identifying the approver
+	Comment string `json:"comment,omitempty"`
+	Token   string `json:"token,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// join cmd: replay the history of the execution after the
+	// last BusData.Seq known by the client, 0 replays it all.
+	// BusData.LastKnownEventID can not be used here, see BusData
+	LastSeq *int64 `json:"last_seq,omitempty"`
 }
 
 func (c *WSocketLogger) readWebSocket() {
@@ -97,8 +117,41 @@ func (c *WSocketLogger) readWebSocket() {
 		clmsg.Ok = false
 		// Handle client msg
 		if clmsg.Cmd == "join" {
-			c.joinExecution(clmsg.Param)
-			clmsg.Ok = true
+			var req *replayRequest
+			if clmsg.LastSeq != nil {
+				req = &replayRequest{executionUUID: clmsg.Param, from: *clmsg.LastSeq + 1}
+			}
+			if req == nil {
+				c.joinExecution(clmsg.Param)
+				clmsg.Ok = true
+			} else {
+				select {
+				case c.replay <- req:
+					clmsg.Ok = true
+				default:
+					clmsg.Error = "too many pending replays"
+				}
+			}
+		}
+		if clmsg.Cmd == "approve" || clmsg.Cmd == "reject" {
+			// the identity comes from the token, never
+			// from the client msg
+			by, err := TokenIdentity(clmsg.Token)
+			if err == nil {
+				err = Approvals.Resolve(clmsg.Param, &ApprovalDecision{
+					Approved:      clmsg.Cmd == "approve",
+					By:            by,
+					Comment:       clmsg.Comment,
+					Authenticated: true,
+				})
+			}
+			// never echo the token back
+			clmsg.Token = ""
+			if err != nil {
+				clmsg.Error = err.Error()
+			} else {
+				clmsg.Ok = true
+			}
 		}
 
 		// Write back to client
@@ -156,6 +209,32 @@ func (c *WSocketLogger) canReadExecution(remoteExecutionUUID string) bool {
 	return true
 }
 
+// replayExecution joins the execution and writes his history
+// from req.from. Later data with an already sent seq is skipped.
+func (c *WSocketLogger) replayExecution(req *replayRequest) error {
+	c.joinExecution(req.executionUUID)
+	history, lastSeq, err := LogHistory.Replay(req.executionUUID, req.from)
+	if err != nil {
+		// nothing recorded yet, the live data is enough
+		return nil
+	}
+	for _, bdata := range history {
+		if err := c.lockedWriteToWS(bdata); err != nil {
+			return err
+		}
+	}
+	c.replayed[req.executionUUID] = lastSeq
+	return nil
+}
+
+func (c *WSocketLogger) alreadyReplayed(fback *BusData) bool {
+	if fback.Seq <= 0 || fback.ExecutionUUID == nil {
+		return false
+	}
+	lastSeq, exists := c.replayed[*fback.ExecutionUUID]
+	return exists && fback.Seq <= lastSeq
+}
+
 // readCastBus read log pipe and write back to websocket
 func (c *WSocketLogger) readCastBus() {
 	ticker := time.NewTicker(((60 * time.Second) * 9) / 10)
@@ -172,6 +251,15 @@ func (c *WSocketLogger) readCastBus() {
 			return
 		}
 		select {
+		case req := <-c.replay:
+			if err := c.lockedSetWriteDeadline(time.Time{}); err != nil {
+				log.Printf("WSocket 6c err: %v", err)
+				return
+			}
+			if err := c.replayExecution(req); err != nil {
+				log.Printf("WSocket 8c err: %v", err)
+				return
+			}
 		case fback, ok := <-c.fLink.CommonChan:
 			// No timeout for msg
 			if err := c.lockedSetWriteDeadline(time.Time{}); err != nil {
@@ -204,7 +292,7 @@ func (c *WSocketLogger) readCastBus() {
 				// no remote uuid, skip data
 				continue
 			}
-			if !c.canReadExecution(*fback.ExecutionUUID) {
+			if !c.canReadExecution(*fback.ExecutionUUID) || c.alreadyReplayed(fback) {
 				continue
 			}
 			err := c.lockedWriteToWS(fback)
@@ -239,7 +327,7 @@ func (c *WSocketLogger) readCastBus() {
 				// no remote uuid, skip log
 				continue
 			}
-			if !c.canReadExecution(*fback.ExecutionUUID) {
+			if !c.canReadExecution(*fback.ExecutionUUID) || c.alreadyReplayed(fback) {
 				continue
 			}
 			err := c.lockedWriteToWS(fback)
@@ -281,7 +369,12 @@ func NewWebSocketLogger(conn *websocket.Conn, clientUUID string) {
 		AllowEventData:  true,
 		AllowStatusData: true,
 	}
-	logger := &WSocketLogger{conn: conn, fLink: fLink}
+	logger := &WSocketLogger{
+		conn:     conn,
+		fLink:    fLink,
+		replay:   make(chan *replayRequest, 10),
+		replayed: make(map[string]int64),
+	}
 	select {
 	case SBus.connect <- fLink:
 	default:
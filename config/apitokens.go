@@ -0,0 +1,158 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package config
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APITokenPrefix const. Prefix of the tokens of the server API
+const APITokenPrefix = "nbt_"
+
+var apiTokensMu sync.Mutex
+
+// APIToken struct. Only the hash of the secret is stored
+type APIToken struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func apiTokensPath() string {
+	return filepath.Join(AppHomePath(), "api_tokens.json")
+}
+
+func readAPITokens() ([]*APIToken, error) {
+	data, err := os.ReadFile(apiTokensPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tokens []*APIToken
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func saveAPITokens(tokens []*APIToken) error {
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(apiTokensPath(), data, 0600)
+}
+
+func hashAPITokenSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIToken func. Returns the token, it can't be recovered later
+func CreateAPIToken(name string) (string, *APIToken, error) {
+	apiTokensMu.Lock()
+	defer apiTokensMu.Unlock()
+	tokens, err := readAPITokens()
+	if err != nil {
+		return "", nil, err
+	}
+	rnd := make([]byte, 36)
+	if _, err := rand.Read(rnd); err != nil {
+		return "", nil, err
+	}
+	id := hex.EncodeToString(rnd[:4])
+	secret := hex.EncodeToString(rnd[4:])
+	token := &APIToken{
+		ID:        id,
+		Name:      name,
+		Hash:      hashAPITokenSecret(secret),
+		CreatedAt: time.Now().UTC(),
+	}
+	tokens = append(tokens, token)
+	if err := saveAPITokens(tokens); err != nil {
+		return "", nil, err
+	}
+	return APITokenPrefix + id + "_" + secret, token, nil
+}
+
+// ListAPITokens func
+func ListAPITokens() ([]*APIToken, error) {
+	apiTokensMu.Lock()
+	defer apiTokensMu.Unlock()
+	return readAPITokens()
+}
+
+// RevokeAPIToken func
+func RevokeAPIToken(id string) error {
+	apiTokensMu.Lock()
+	defer apiTokensMu.Unlock()
+	tokens, err := readAPITokens()
+	if err != nil {
+		return err
+	}
+	for i, token := range tokens {
+		if token.ID == id {
+			return saveAPITokens(append(tokens[:i], tokens[i+1:]...))
+		}
+	}
+	return fmt.Errorf("token %s not found", id)
+}
+
+// ValidateAPIToken func. The tokens file is read on each call,
+// so revoked tokens stop working without restarting the server
+func ValidateAPIToken(raw string) (*APIToken, error) {
+	rest, found := strings.CutPrefix(raw, APITokenPrefix)
+	if !found {
+		return nil, fmt.Errorf("invalid token")
+	}
+	id, secret, found := strings.Cut(rest, "_")
+	if !found {
+		return nil, fmt.Errorf("invalid token")
+	}
+	apiTokensMu.Lock()
+	defer apiTokensMu.Unlock()
+	tokens, err := readAPITokens()
+	if err != nil {
+		return nil, err
+	}
+	hash := hashAPITokenSecret(secret)
+	for _, token := range tokens {
+		if token.ID == id && subtle.ConstantTimeCompare([]byte(token.Hash), []byte(hash)) == 1 {
+			return token, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid token")
+}
@@ -163,6 +163,14 @@ var ForceNoTerm = false
 
 var ForceFile *bool
 
+var ScheduleStateFlag *string
+
+var TriggersFileFlag *string
+
+var LogHistoryDirFlag *string
+
+var LogDirFlag *string
+
 var LOAD_CONF_FILES = "true"
 
 func AppHomePath() string {
@@ -0,0 +1,353 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package executive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/cast"
+	"github.com/develatio/nebulant-cli/config"
+	"github.com/develatio/nebulant-cli/nhttpd"
+	"github.com/develatio/nebulant-cli/runtime"
+	"github.com/go-playground/validator/v10"
+)
+
+// Execution states
+const (
+	ExecutionStateRunning  = "running"
+	ExecutionStatePaused   = "paused"
+	ExecutionStateStopping = "stopping"
+	ExecutionStateEnded    = "ended"
+)
+
+// ExecutionStatus struct
+type ExecutionStatus struct {
+	ExecutionUUID string                 `json:"execution_uuid"`
+	State         string                 `json:"state"`
+	ExitCode      int                    `json:"exit_code"`
+	Errors        []string               `json:"errors,omitempty"`
+	StartedAt     time.Time              `json:"started_at"`
+	FinishedAt    *time.Time             `json:"finished_at,omitempty"`
+	Actions       []*runtime.ActionState `json:"actions,omitempty"`
+}
+
+// APIStartRequest struct
+type APIStartRequest struct {
+	// same url accepted by the run command
+	Blueprint string `json:"blueprint" validate:"required"`
+	// blueprint is a local file of the server
+	File bool     `json:"file"`
+	Args []string `json:"args"`
+}
+
+// APIStartResponse struct
+type APIStartResponse struct {
+	ExecutionUUID string `json:"execution_uuid"`
+}
+
+// APILogsResponse struct
+type APILogsResponse struct {
+	Logs []*cast.BusData `json:"logs"`
+	// seq of the next log, pass as ?since= to get the following logs
+	Next int64 `json:"next"`
+}
+
+// executionStatus func. d.mu should be locked
+func (d *Director) executionStatus(manager *Manager, ended bool) *ExecutionStatus {
+	status := &ExecutionStatus{
+		StartedAt: d.managersStartedAt[manager],
+		State:     ExecutionStateRunning,
+	}
+	if irb := d.managers[manager]; irb != nil && irb.ExecutionUUID != nil {
+		status.ExecutionUUID = *irb.ExecutionUUID
+	}
+	rt := manager.Runtime
+	if rt == nil {
+		return status
+	}
+	status.ExitCode = rt.ExitCode()
+	for _, err := range rt.Errors() {
+		status.Errors = append(status.Errors, err.Error())
+	}
+	status.Actions = rt.ActionStates()
+	switch {
+	case ended:
+		now := time.Now()
+		status.FinishedAt = &now
+		status.State = ExecutionStateEnded
+	case rt.State() == base.RuntimeStateStill:
+		status.State = ExecutionStatePaused
+	case rt.State() == base.RuntimeStateEnding || rt.State() == base.RuntimeStateEnd:
+		status.State = ExecutionStateStopping
+	}
+	return status
+}
+
+// Executions func. Running and last finished executions,
+// without the state of the actions
+func (d *Director) Executions() []*ExecutionStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var out []*ExecutionStatus
+	for _, status := range d.finished {
+		st := *status
+		st.Actions = nil
+		out = append(out, &st)
+	}
+	for manager := range d.managers {
+		status := d.executionStatus(manager, false)
+		status.Actions = nil
+		out = append(out, status)
+	}
+	return out
+}
+
+// Execution func
+func (d *Director) Execution(executionUUID string) (*ExecutionStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if manager, exists := d.managersByExecutionID[executionUUID]; exists {
+		return d.executionStatus(manager, false), nil
+	}
+	for _, status := range d.finished {
+		if status.ExecutionUUID == executionUUID {
+			return status, nil
+		}
+	}
+	return nil, fmt.Errorf("execution %s not found", executionUUID)
+}
+
+// IsRunning func
+func (d *Director) IsRunning(executionUUID string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, exists := d.managersByExecutionID[executionUUID]
+	return exists
+}
+
+func stopExecution(executionUUID string) error {
+	select {
+	case MDirector.ExecInstruction <- &ExecCtrlInstruction{
+		Instruction:   ExecStop,
+		ExecutionUUID: &executionUUID,
+	}:
+		cast.SBus.SetExecutionStatus(executionUUID, false)
+	default:
+		return fmt.Errorf(http.StatusText(http.StatusExpectationFailed))
+	}
+	// echo
+	cast.PushEvent(cast.EventRuntimeStopping, &executionUUID)
+	return nil
+}
+
+func pauseExecution(executionUUID string) error {
+	select {
+	case MDirector.ExecInstruction <- &ExecCtrlInstruction{
+		Instruction:   ExecPause,
+		ExecutionUUID: &executionUUID,
+	}:
+		cast.SBus.SetExecutionStatus(executionUUID, false)
+	default:
+		return fmt.Errorf(http.StatusText(http.StatusExpectationFailed))
+	}
+	// echo
+	cast.PushEvent(cast.EventRuntimePausing, &executionUUID)
+	return nil
+}
+
+func resumeExecution(executionUUID string) error {
+	MDirector.ExecInstruction <- &ExecCtrlInstruction{
+		Instruction:   ExecResume,
+		ExecutionUUID: &executionUUID,
+	}
+	// echo
+	cast.PushEvent(cast.EventRuntimeResuming, &executionUUID)
+	return nil
+}
+
+func addAPIViews(srv *nhttpd.Httpd) {
+	srv.AddView(`^/api/v1/executions/?$`, apiAuth(apiExecutionsView))
+	srv.AddView(`^/api/v1/executions/([-a-zA-Z0-9_]+)$`, apiAuth(apiExecutionView))
+	srv.AddView(`^/api/v1/executions/([-a-zA-Z0-9_]+)/logs$`, apiAuth(apiExecutionLogsView))
+	srv.AddView(`^/api/v1/executions/([-a-zA-Z0-9_]+)/(stop|pause|resume)$`, apiAuth(apiExecutionControlView))
+}
+
+func apiError(w http.ResponseWriter, status int, err error) {
+	w.WriteHeader(status)
+	resp := &GenericResponse{
+		Code:   "E13",
+		Fail:   true,
+		Errors: []string{err.Error()},
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		cast.LogErr("E13 "+err.Error(), nil)
+	}
+}
+
+func apiJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		cast.LogErr("E13 "+err.Error(), nil)
+	}
+}
+
+// apiAuth requires a token created with `nebulant serve token create`
+func apiAuth(view nhttpd.ViewFunc) nhttpd.ViewFunc {
+	return func(w http.ResponseWriter, r *http.Request, matches [][]string) {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Origin, Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		raw, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found {
+			apiError(w, http.StatusUnauthorized, fmt.Errorf("missing bearer token"))
+			return
+		}
+		token, err := config.ValidateAPIToken(raw)
+		if err != nil {
+			cast.LogWarn("API request rejected: "+err.Error(), nil)
+			apiError(w, http.StatusUnauthorized, err)
+			return
+		}
+		cast.LogDebug("API request "+r.Method+" "+r.URL.Path+" with token "+token.ID, nil)
+		view(w, r, matches)
+	}
+}
+
+func apiExecutionsView(w http.ResponseWriter, r *http.Request, matches [][]string) {
+	switch r.Method {
+	case "GET":
+		apiJSON(w, http.StatusOK, MDirector.Executions())
+		return
+	case "POST":
+	default:
+		apiError(w, http.StatusMethodNotAllowed, fmt.Errorf(http.StatusText(http.StatusMethodNotAllowed)))
+		return
+	}
+
+	defer r.Body.Close()
+	body := http.MaxBytesReader(w, r.Body, 65536)
+	data, err := io.ReadAll(body)
+	if err != nil {
+		apiError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	sReq := &APIStartRequest{}
+	if err := json.Unmarshal(data, sReq); err != nil {
+		apiError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := validator.New().Struct(sReq); err != nil {
+		apiError(w, http.StatusBadRequest, err)
+		return
+	}
+	var bpURL *blueprint.BlueprintURL
+	if sReq.File {
+		bpURL, err = blueprint.ParsePath(sReq.Blueprint)
+	} else {
+		bpURL, err = blueprint.ParseURL(sReq.Blueprint)
+	}
+	if err != nil {
+		apiError(w, http.StatusBadRequest, err)
+		return
+	}
+	irb, err := blueprint.NewIRBFromAny(bpURL, &blueprint.IRBGenConfig{Args: sReq.Args})
+	if err != nil {
+		apiError(w, http.StatusBadRequest, err)
+		return
+	}
+	MDirector.HandleIRB <- &HandleIRBConfig{IRB: irb}
+	apiJSON(w, http.StatusAccepted, &APIStartResponse{ExecutionUUID: *irb.ExecutionUUID})
+}
+
+func apiExecutionView(w http.ResponseWriter, r *http.Request, matches [][]string) {
+	if r.Method != "GET" {
+		apiError(w, http.StatusMethodNotAllowed, fmt.Errorf(http.StatusText(http.StatusMethodNotAllowed)))
+		return
+	}
+	status, err := MDirector.Execution(matches[0][1])
+	if err != nil {
+		apiError(w, http.StatusNotFound, err)
+		return
+	}
+	apiJSON(w, http.StatusOK, status)
+}
+
+func apiExecutionLogsView(w http.ResponseWriter, r *http.Request, matches [][]string) {
+	if r.Method != "GET" {
+		apiError(w, http.StatusMethodNotAllowed, fmt.Errorf(http.StatusText(http.StatusMethodNotAllowed)))
+		return
+	}
+	var since int64
+	if s := r.URL.Query().Get("since"); s != "" {
+		var err error
+		since, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			apiError(w, http.StatusBadRequest, fmt.Errorf("bad since value %s", s))
+			return
+		}
+	}
+	logs, lastSeq, err := cast.LogHistory.Logs(matches[0][1], since)
+	if err != nil {
+		apiError(w, http.StatusNotFound, err)
+		return
+	}
+	apiJSON(w, http.StatusOK, &APILogsResponse{Logs: logs, Next: lastSeq + 1})
+}
+
+func apiExecutionControlView(w http.ResponseWriter, r *http.Request, matches [][]string) {
+	if r.Method != "POST" {
+		apiError(w, http.StatusMethodNotAllowed, fmt.Errorf(http.StatusText(http.StatusMethodNotAllowed)))
+		return
+	}
+	executionUUID := matches[0][1]
+	if !MDirector.IsRunning(executionUUID) {
+		apiError(w, http.StatusNotFound, fmt.Errorf("execution %s is not running", executionUUID))
+		return
+	}
+	var err error
+	switch matches[0][2] {
+	case "stop":
+		err = stopExecution(executionUUID)
+	case "pause":
+		err = pauseExecution(executionUUID)
+	case "resume":
+		err = resumeExecution(executionUUID)
+	}
+	if err != nil {
+		apiError(w, http.StatusExpectationFailed, err)
+		return
+	}
+	apiJSON(w, http.StatusAccepted, &APIStartResponse{ExecutionUUID: executionUUID})
+}
@@ -0,0 +1,107 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package executive
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/cast"
+	"github.com/develatio/nebulant-cli/ipc"
+	"github.com/develatio/nebulant-cli/nhttpd"
+)
+
+// CallbackInfo struct. Exposed through the output var of the
+// wait_for_event actions before they run
+type CallbackInfo struct {
+	Token string `json:"token"`
+	// only in server mode, empty otherwise
+	URL    string `json:"url"`
+	Signal string `json:"signal"`
+}
+
+func addCallbackView(srv *nhttpd.Httpd) {
+	srv.AddView(`^/callback/([0-9]+\.[0-9a-f]+)$`, callbackView)
+}
+
+// registerCallbacks registers a callback token for each action waiting
+// for events, so earlier actions can use it through {{ output.token }}.
+// The http callbacks are served by the server mode only, `run`
+// invocations are signaled through `nebulant signal`.
+func (m *Manager) registerCallbacks(st base.IStore, ipcs *ipc.IPC) error {
+	for _, action := range m.IRB.Actions {
+		if !action.CallbackPoint {
+			continue
+		}
+		cb, err := cast.Callbacks.Register(ipcs.GetUUID(), m.ExecutionUUID, action.ActionID)
+		if err != nil {
+			return err
+		}
+		st.SetPrivateVar(blueprint.CallbackPrivateVarPrefix+action.ActionID, cb)
+		if action.Output == nil {
+			continue
+		}
+		info := &CallbackInfo{
+			Token:  cb.Token,
+			Signal: "nebulant signal " + cb.Token,
+		}
+		if m.serverMode {
+			info.URL = "http://" + nhttpd.GetServer().GetAddr() + "/callback/" + cb.Token
+		}
+		aout := base.NewActionOutput(action, info, &cb.Token)
+		aout.Records[0].Literal = true
+		if err := st.Insert(aout.Records[0], action.Provider); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func callbackView(w http.ResponseWriter, r *http.Request, matches [][]string) {
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Origin")
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	defer r.Body.Close()
+	body := http.MaxBytesReader(w, r.Body, 4000000)
+	data, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, "E10 "+err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	err = cast.Callbacks.Signal(matches[0][1], "http", data)
+	if err != nil {
+		http.Error(w, "E10 "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
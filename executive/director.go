@@ -37,6 +37,9 @@ import (
 // MDirector var
 var MDirector *Director
 
+// last finished executions kept by the director
+const directorFinishedSize = 100
+
 // InitDirector func
 func InitDirector(serverMode bool, interactiveMode bool) error {
 	if MDirector != nil {
@@ -47,6 +50,8 @@ func InitDirector(serverMode bool, interactiveMode bool) error {
 	MDirector = &Director{
 		managers:              make(map[*Manager]*blueprint.IRBlueprint),
 		managersByExecutionID: make(map[string]*Manager),
+		managersDone:          make(map[*Manager]chan int),
+		managersStartedAt:     make(map[*Manager]time.Time),
 		HandleIRB:             make(chan *HandleIRBConfig, 10),
 		ExecInstruction:       make(chan *ExecCtrlInstruction, 10),
 		UnregisterManager:     make(chan *Manager, 10),
@@ -54,6 +59,9 @@ func InitDirector(serverMode bool, interactiveMode bool) error {
 		serverMode:            serverMode,
 		interactiveMode:       interactiveMode,
 	}
+	// approvals are resolved from the console only
+	// out of server mode
+	cast.Approvals.SetConsolePrompt(!serverMode)
 	go MDirector.startDirector()
 	return nil
 }
@@ -65,6 +73,9 @@ func RemoveDirector() {
 type HandleIRBConfig struct {
 	Manager *Manager
 	IRB     *blueprint.IRBlueprint
+	// optional buffered chan, receives the exit code of the execution and
+	// is closed when the manager is unregistered
+	Done chan int
 }
 
 // Director struct
@@ -78,7 +89,13 @@ type Director struct {
 	directorWaiter        *sync.WaitGroup
 	managers              map[*Manager]*blueprint.IRBlueprint
 	managersByExecutionID map[string]*Manager
-	ExitCode              int
+	managersDone          map[*Manager]chan int
+	managersStartedAt     map[*Manager]time.Time
+	// guards the managers maps and finished
+	mu sync.Mutex
+	// last finished executions, newest last
+	finished []*ExecutionStatus
+	ExitCode int
 }
 
 // Wait func
@@ -136,6 +153,9 @@ L:
 			if manager == nil {
 				if _, exists := d.managersByExecutionID[*irb.BP.ExecutionUUID]; exists {
 					cast.LogErr("[Director] bp already running...", irb.BP.ExecutionUUID)
+					if hirbcfg.Done != nil {
+						close(hirbcfg.Done)
+					}
 					continue
 				}
 				manager = NewManager(d.serverMode)
@@ -147,6 +167,9 @@ L:
 				irb = manager.IRB
 				if _, exists := d.managersByExecutionID[*irb.BP.ExecutionUUID]; exists {
 					cast.LogErr("[Director] bp already running...", irb.BP.ExecutionUUID)
+					if hirbcfg.Done != nil {
+						close(hirbcfg.Done)
+					}
 					continue
 				}
 			}
@@ -154,8 +177,14 @@ L:
 			if irb.BP.BuilderWarnings > 0 {
 				cast.LogWarn("This blueprint has "+fmt.Sprintf("%v", irb.BP.BuilderWarnings)+" warnings from the builder", irb.BP.ExecutionUUID)
 			}
+			d.mu.Lock()
 			d.managersByExecutionID[*irb.BP.ExecutionUUID] = manager
 			d.managers[manager] = irb
+			d.managersStartedAt[manager] = time.Now()
+			if hirbcfg.Done != nil {
+				d.managersDone[manager] = hirbcfg.Done
+			}
+			d.mu.Unlock()
 			extra := make(map[string]interface{})
 			extra["manager"] = manager
 			cast.PushEventWithExtra(cast.EventRegisteredManager, irb.BP.ExecutionUUID, extra)
@@ -206,16 +235,26 @@ L:
 			// exitCode := manager.ExternalRegistry.ExitCode
 			exitCode := manager.Runtime.ExitCode()
 
+			d.mu.Lock()
+			d.finished = append(d.finished, d.executionStatus(manager, true))
+			if len(d.finished) > directorFinishedSize {
+				d.finished = d.finished[1:]
+			}
 			manager.reset()
 			irb := d.managers[manager]
 			delete(d.managers, manager)
 			delete(d.managersByExecutionID, *irb.ExecutionUUID)
+			delete(d.managersStartedAt, manager)
+			d.mu.Unlock()
+			if done, exists := d.managersDone[manager]; exists {
+				delete(d.managersDone, manager)
+				done <- exitCode
+				close(done)
+			}
 			if len(d.managers) <= 0 && !d.serverMode {
 				d.ExitCode = exitCode
 				break L
 			}
-		default:
-			time.Sleep(2 * time.Second)
 		}
 	}
 
@@ -144,6 +144,21 @@ func (m *Manager) Run() error {
 	// set ipcs into store
 	st.SetPrivateVar("IPCS", ipcs)
 
+	// set execution metadata into store
+	if m.ExecutionUUID != nil {
+		st.SetPrivateVar(blueprint.ExecutionUUIDPrivateVar, *m.ExecutionUUID)
+	}
+	if m.IRB.BP != nil {
+		st.SetPrivateVar(blueprint.BlueprintNamePrivateVar, m.IRB.BP.Name)
+	}
+
+	// register the callbacks of the actions waiting for events
+	defer cast.Callbacks.Unregister(m.ExecutionUUID)
+	err = m.registerCallbacks(st, ipcs)
+	if err != nil {
+		return err
+	}
+
 	// set vars from cli args
 	for _, irbarg := range m.IRB.Args {
 		if st.ExistsRefName(irbarg.Name) {
@@ -180,6 +195,9 @@ func (m *Manager) Run() error {
 	m.Logger.ParanoicLogDebug("after set store")
 
 	eventlistener := m.Runtime.NewEventListener()
+	// keep the end event even if the runtime
+	// ends before the wait starts
+	eventlistener.Arm()
 	// start to run
 	m.Runtime.NewThread(startActionContext)
 
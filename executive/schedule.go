@@ -0,0 +1,465 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package executive
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/cast"
+	"github.com/develatio/nebulant-cli/nhttpd"
+	"github.com/develatio/nebulant-cli/util"
+)
+
+const (
+	// ScheduleOverlapSkip const. Do not run while the previous run is alive
+	ScheduleOverlapSkip = "skip"
+	// ScheduleOverlapQueue const. Run after the previous run ends
+	ScheduleOverlapQueue = "queue"
+	// ScheduleOverlapAllow const. Run concurrently
+	ScheduleOverlapAllow = "allow"
+
+	scheduleHistorySize = 50
+	scheduleMaxQueued   = 10
+)
+
+// ScheduleRun status values
+const (
+	ScheduleRunQueued      = "queued"
+	ScheduleRunRunning     = "running"
+	ScheduleRunSuccess     = "success"
+	ScheduleRunFailed      = "failed"
+	ScheduleRunSkipped     = "skipped"
+	ScheduleRunError       = "error"
+	ScheduleRunInterrupted = "interrupted"
+)
+
+// MScheduler var
+var MScheduler *Scheduler
+
+// ScheduleEntry struct. A blueprint to run on a cron schedule
+type ScheduleEntry struct {
+	Name string `json:"name" validate:"required"`
+	// minute hour day-of-month month day-of-week, or a macro like @daily
+	Cron string `json:"cron" validate:"required"`
+	// same url or path accepted by the run command
+	Blueprint string `json:"blueprint" validate:"required"`
+	// blueprint is a local file, like run -f
+	File bool `json:"file"`
+	// blueprint args: ["--varname=value"]
+	Args     []string `json:"args"`
+	Overlap  string   `json:"overlap" validate:"omitempty,oneof=skip queue allow"`
+	Timezone string   `json:"timezone"`
+	Disabled bool     `json:"disabled"`
+}
+
+// ScheduleFile struct
+type ScheduleFile struct {
+	Schedules []*ScheduleEntry `json:"schedules" validate:"required,dive"`
+}
+
+// ScheduleRun struct. A run launched (or not) by the scheduler
+type ScheduleRun struct {
+	ExecutionUUID *string    `json:"execution_uuid"`
+	ScheduledAt   time.Time  `json:"scheduled_at"`
+	StartedAt     *time.Time `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+	Status        string     `json:"status"`
+	ExitCode      int        `json:"exit_code"`
+	Error         string     `json:"error,omitempty"`
+}
+
+// ScheduleStatus struct. Exposed by the server
+type ScheduleStatus struct {
+	*ScheduleEntry
+	NextRun *time.Time   `json:"next_run"`
+	Running int          `json:"running"`
+	Queued  int          `json:"queued"`
+	LastRun *ScheduleRun `json:"last_run"`
+}
+
+type scheduleState struct {
+	History []*ScheduleRun `json:"history"`
+}
+
+type scheduledJob struct {
+	entry   *ScheduleEntry
+	bpURL   *blueprint.BlueprintURL
+	cron    *util.CronSchedule
+	loc     *time.Location
+	next    time.Time
+	running int
+	queue   []*ScheduleRun
+	history []*ScheduleRun
+}
+
+// Scheduler struct. Launches the blueprints of a schedule
+// file into the director
+type Scheduler struct {
+	mu        sync.Mutex
+	jobs      []*scheduledJob
+	statePath string
+	stop      chan struct{}
+}
+
+// NewScheduler func. Loads the schedule file and the last
+// known state from statePath
+func NewScheduler(path string, statePath string) (*Scheduler, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path from cli
+	if err != nil {
+		return nil, err
+	}
+	sfile := &ScheduleFile{}
+	if err := util.UnmarshalValidJSON(data, sfile); err != nil {
+		return nil, fmt.Errorf("bad schedule file %s: %v", path, err)
+	}
+
+	s := &Scheduler{
+		statePath: statePath,
+		stop:      make(chan struct{}),
+	}
+	names := make(map[string]bool)
+	for _, entry := range sfile.Schedules {
+		if names[entry.Name] {
+			return nil, fmt.Errorf("duplicated schedule name %s", entry.Name)
+		}
+		names[entry.Name] = true
+		if entry.Overlap == "" {
+			entry.Overlap = ScheduleOverlapSkip
+		}
+		job := &scheduledJob{entry: entry, loc: time.Local}
+		job.cron, err = util.ParseCron(entry.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %v", entry.Name, err)
+		}
+		if entry.Timezone != "" {
+			job.loc, err = time.LoadLocation(entry.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("schedule %s: %v", entry.Name, err)
+			}
+		}
+		job.bpURL, err = blueprint.ParseRef(entry.Blueprint, entry.File, filepath.Dir(path))
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %v", entry.Name, err)
+		}
+		if _, err := blueprint.ParseBPArgs(entry.Args); err != nil {
+			return nil, fmt.Errorf("schedule %s: %v", entry.Name, err)
+		}
+		s.jobs = append(s.jobs, job)
+	}
+
+	if err := s.loadState(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// InitScheduler func. Starts the scheduler and adds
+// the schedule views to the server
+func InitScheduler(path string, statePath string) error {
+	if MScheduler != nil {
+		return fmt.Errorf("scheduler already running")
+	}
+	if MDirector == nil {
+		return fmt.Errorf("the scheduler needs a running director")
+	}
+	s, err := NewScheduler(path, statePath)
+	if err != nil {
+		return err
+	}
+	MScheduler = s
+	srv := nhttpd.GetServer()
+	srv.AddView(`^/schedule/$`, apiAuth(scheduleView))
+	srv.AddView(`^/schedule/([^/]+)/history$`, apiAuth(scheduleHistoryView))
+	go s.Run()
+	return nil
+}
+
+func (s *Scheduler) loadState() error {
+	data, err := os.ReadFile(s.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	state := make(map[string]*scheduleState)
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("bad schedule state file %s: %v", s.statePath, err)
+	}
+	for _, job := range s.jobs {
+		jstate, exists := state[job.entry.Name]
+		if !exists {
+			continue
+		}
+		for _, run := range jstate.History {
+			if run.Status == ScheduleRunRunning || run.Status == ScheduleRunQueued {
+				// the previous scheduler died before the end
+				run.Status = ScheduleRunInterrupted
+			}
+		}
+		job.history = jstate.History
+	}
+	return nil
+}
+
+// saveState func. s.mu should be locked
+func (s *Scheduler) saveState() {
+	state := make(map[string]*scheduleState)
+	for _, job := range s.jobs {
+		state[job.entry.Name] = &scheduleState{History: job.history}
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(s.statePath), os.ModePerm)
+	}
+	if err == nil {
+		tmp := s.statePath + ".tmp"
+		err = os.WriteFile(tmp, data, 0600)
+		if err == nil {
+			err = os.Rename(tmp, s.statePath)
+		}
+	}
+	if err != nil {
+		cast.LogErr("[Scheduler] cannot save state: "+err.Error(), nil)
+	}
+}
+
+// Run func. Blocks until Stop
+func (s *Scheduler) Run() {
+	s.mu.Lock()
+	now := time.Now()
+	for _, job := range s.jobs {
+		if job.entry.Disabled {
+			continue
+		}
+		job.next = job.cron.Next(now.In(job.loc))
+		cast.LogInfo(fmt.Sprintf("[Scheduler] %s next run at %v", job.entry.Name, job.next), nil)
+	}
+	s.mu.Unlock()
+
+	for {
+		s.mu.Lock()
+		var next time.Time
+		for _, job := range s.jobs {
+			if !job.next.IsZero() && (next.IsZero() || job.next.Before(next)) {
+				next = job.next
+			}
+		}
+		s.mu.Unlock()
+
+		// a nil chan blocks forever if there is nothing to run
+		var timerC <-chan time.Time
+		var timer *time.Timer
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			timerC = timer.C
+		}
+		select {
+		case now := <-timerC:
+			s.mu.Lock()
+			for _, job := range s.jobs {
+				if job.next.IsZero() || job.next.After(now) {
+					continue
+				}
+				scheduledAt := job.next
+				job.next = job.cron.Next(now.In(job.loc))
+				s.dispatch(job, scheduledAt)
+			}
+			s.saveState()
+			s.mu.Unlock()
+		case <-s.stop:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+	}
+}
+
+// Stop func
+func (s *Scheduler) Stop() {
+	close(s.stop)
+}
+
+// dispatch applies the overlap policy. s.mu should be locked
+func (s *Scheduler) dispatch(job *scheduledJob, scheduledAt time.Time) {
+	run := &ScheduleRun{ScheduledAt: scheduledAt}
+	job.history = append(job.history, run)
+	if len(job.history) > scheduleHistorySize {
+		job.history = job.history[len(job.history)-scheduleHistorySize:]
+	}
+	if job.running > 0 {
+		switch job.entry.Overlap {
+		case ScheduleOverlapSkip:
+			cast.LogWarn("[Scheduler] "+job.entry.Name+" is still running, skipping run", nil)
+			run.Status = ScheduleRunSkipped
+			return
+		case ScheduleOverlapQueue:
+			if len(job.queue) >= scheduleMaxQueued {
+				cast.LogWarn("[Scheduler] "+job.entry.Name+" has too many queued runs, skipping run", nil)
+				run.Status = ScheduleRunSkipped
+				return
+			}
+			cast.LogInfo("[Scheduler] "+job.entry.Name+" is still running, queueing run", nil)
+			run.Status = ScheduleRunQueued
+			job.queue = append(job.queue, run)
+			return
+		}
+	}
+	s.start(job, run)
+}
+
+// start runs the blueprint. s.mu should be locked
+func (s *Scheduler) start(job *scheduledJob, run *ScheduleRun) {
+	job.running++
+	startedAt := time.Now()
+	run.StartedAt = &startedAt
+	run.Status = ScheduleRunRunning
+	go func() {
+		exitCode, err := s.execute(job, run)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		finishedAt := time.Now()
+		run.FinishedAt = &finishedAt
+		run.ExitCode = exitCode
+		switch {
+		case err != nil:
+			cast.LogErr("[Scheduler] "+job.entry.Name+": "+err.Error(), nil)
+			run.Status = ScheduleRunError
+			run.Error = err.Error()
+		case exitCode != 0:
+			run.Status = ScheduleRunFailed
+		default:
+			run.Status = ScheduleRunSuccess
+		}
+		cast.LogInfo(fmt.Sprintf("[Scheduler] %s ended with status %s", job.entry.Name, run.Status), nil)
+		job.running--
+		if job.running <= 0 && len(job.queue) > 0 {
+			queued := job.queue[0]
+			job.queue = job.queue[1:]
+			s.start(job, queued)
+		}
+		s.saveState()
+	}()
+}
+
+// execute loads the blueprint and waits for the end of the execution
+func (s *Scheduler) execute(job *scheduledJob, run *ScheduleRun) (int, error) {
+	cast.LogInfo("[Scheduler] Running "+job.entry.Name, nil)
+	irb, err := blueprint.NewIRBFromAny(job.bpURL, &blueprint.IRBGenConfig{Args: job.entry.Args})
+	if err != nil {
+		return 1, err
+	}
+	s.mu.Lock()
+	run.ExecutionUUID = irb.ExecutionUUID
+	s.saveState()
+	s.mu.Unlock()
+
+	done := make(chan int, 1)
+	MDirector.HandleIRB <- &HandleIRBConfig{IRB: irb, Done: done}
+	exitCode, ok := <-done
+	if !ok {
+		return 1, fmt.Errorf("execution %s rejected by the director", *irb.ExecutionUUID)
+	}
+	return exitCode, nil
+}
+
+// Status func
+func (s *Scheduler) Status() []*ScheduleStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*ScheduleStatus
+	for _, job := range s.jobs {
+		status := &ScheduleStatus{
+			ScheduleEntry: job.entry,
+			Running:       job.running,
+			Queued:        len(job.queue),
+		}
+		if !job.next.IsZero() {
+			next := job.next
+			status.NextRun = &next
+		}
+		// the last run that is not waiting in the queue
+		for i := len(job.history) - 1; i >= 0; i-- {
+			if job.history[i].Status != ScheduleRunQueued {
+				run := *job.history[i]
+				status.LastRun = &run
+				break
+			}
+		}
+		out = append(out, status)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// History func. Last runs of the schedule, newest first
+func (s *Scheduler) History(name string) ([]*ScheduleRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if job.entry.Name != name {
+			continue
+		}
+		out := make([]*ScheduleRun, 0, len(job.history))
+		for i := len(job.history) - 1; i >= 0; i-- {
+			run := *job.history[i]
+			out = append(out, &run)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("schedule %s not found", name)
+}
+
+func scheduleView(w http.ResponseWriter, r *http.Request, matches [][]string) {
+	if r.Method != "GET" {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(MScheduler.Status()); err != nil {
+		http.Error(w, "E11 "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func scheduleHistoryView(w http.ResponseWriter, r *http.Request, matches [][]string) {
+	if r.Method != "GET" {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	history, err := MScheduler.History(matches[0][1])
+	if err != nil {
+		http.Error(w, "E11 "+err.Error(), http.StatusNotFound)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		http.Error(w, "E11 "+err.Error(), http.StatusInternalServerError)
+	}
+}
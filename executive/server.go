@@ -65,15 +65,18 @@ func InitServerMode() chan error {
 	srv.AddOrigin(config.FrontOriginPre)
 	srv.AddOrigin(config.FrontOrigin)
 
-	srv.AddView(`/ws/.+$`, wsView)
-	srv.AddView(`/handshake`, handshakeView)
-	srv.AddView(`/stop/.+$`, stopBlueprintView)
-	srv.AddView(`/pause/.+$`, pauseBlueprintView)
-	srv.AddView(`/resume/.+$`, resumeBlueprintView)
-	srv.AddView(`/blueprint/.+$`, blueprintView)
-	srv.AddView(`/autocomplete/$`, autocompleteView)
-	srv.AddView(`/assets/(.+)$`, assetsView)
-	srv.AddView(`/proxy.html$`, proxyView)
+	srv.AddView(`^/ws/.+$`, wsView)
+	srv.AddView(`^/handshake/?$`, handshakeView)
+	srv.AddView(`^/stop/.+$`, stopBlueprintView)
+	srv.AddView(`^/pause/.+$`, pauseBlueprintView)
+	srv.AddView(`^/resume/.+$`, resumeBlueprintView)
+	srv.AddView(`^/blueprint/.+$`, blueprintView)
+	srv.AddView(`^/autocomplete/$`, autocompleteView)
+	srv.AddView(`^/assets/(.+)$`, assetsView)
+	srv.AddView(`^/proxy\.html$`, proxyView)
+	srv.AddView(`^/approval/([0-9a-f]+)$`, approvalView)
+	addCallbackView(srv)
+	addAPIViews(srv)
 
 	cast.LogInfo("The server mode is designed to be used with the Builder: "+config.FrontUrl, nil)
 	return srv.ServeIfNot()
@@ -359,19 +362,10 @@ func stopBlueprintView(w http.ResponseWriter, r *http.Request, matches [][]strin
 	}
 	defer r.Body.Close()
 	remoteExecutionUUID := path.Base(r.URL.Path)
-
-	select {
-	case MDirector.ExecInstruction <- &ExecCtrlInstruction{
-		Instruction:   ExecStop,
-		ExecutionUUID: &remoteExecutionUUID,
-	}:
-		cast.SBus.SetExecutionStatus(remoteExecutionUUID, false)
-	default:
-		http.Error(w, http.StatusText(http.StatusExpectationFailed), http.StatusExpectationFailed)
+	if err := stopExecution(remoteExecutionUUID); err != nil {
+		http.Error(w, err.Error(), http.StatusExpectationFailed)
 		return
 	}
-	// echo
-	cast.PushEvent(cast.EventRuntimeStopping, &remoteExecutionUUID)
 	w.WriteHeader(http.StatusAccepted)
 }
 
@@ -388,19 +382,10 @@ func pauseBlueprintView(w http.ResponseWriter, r *http.Request, matches [][]stri
 	}
 	defer r.Body.Close()
 	remoteExecutionUUID := path.Base(r.URL.Path)
-	select {
-	case MDirector.ExecInstruction <- &ExecCtrlInstruction{
-		Instruction:   ExecPause,
-		ExecutionUUID: &remoteExecutionUUID,
-	}:
-		cast.SBus.SetExecutionStatus(remoteExecutionUUID, false)
-	default:
-		http.Error(w, http.StatusText(http.StatusExpectationFailed), http.StatusExpectationFailed)
+	if err := pauseExecution(remoteExecutionUUID); err != nil {
+		http.Error(w, err.Error(), http.StatusExpectationFailed)
 		return
 	}
-
-	// echo
-	cast.PushEvent(cast.EventRuntimePausing, &remoteExecutionUUID)
 	w.WriteHeader(http.StatusAccepted)
 }
 
@@ -417,12 +402,10 @@ func resumeBlueprintView(w http.ResponseWriter, r *http.Request, matches [][]str
 	}
 	defer r.Body.Close()
 	remoteExecutionUUID := path.Base(r.URL.Path)
-	MDirector.ExecInstruction <- &ExecCtrlInstruction{
-		Instruction:   ExecResume,
-		ExecutionUUID: &remoteExecutionUUID,
+	if err := resumeExecution(remoteExecutionUUID); err != nil {
+		http.Error(w, err.Error(), http.StatusExpectationFailed)
+		return
 	}
-	// echo
-	cast.PushEvent(cast.EventRuntimeResuming, &remoteExecutionUUID)
 	w.WriteHeader(http.StatusAccepted)
 }
 
@@ -709,3 +692,74 @@ func proxyView(w http.ResponseWriter, r *http.Request, matches [][]string) {
 
 	w.Write([]byte(assets.PROXYHTTP))
 }
+
+// ApprovalRequest struct
+type ApprovalRequest struct {
+	Approved *bool  `json:"approved" validate:"required"`
+	Comment  string `json:"comment"`
+}
+
+func approvalView(w http.ResponseWriter, r *http.Request, matches [][]string) {
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Origin, Authorization")
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	approvalID := matches[0][1]
+
+	// the identity comes from the API token, never
+	// from the request body
+	raw, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	by, err := cast.TokenIdentity(raw)
+	if err != nil {
+		cast.LogWarn("Approval request rejected: "+err.Error(), nil)
+		http.Error(w, "E09 "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method == "GET" {
+		approval, err := cast.Approvals.Get(approvalID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(approval); err != nil {
+			http.Error(w, "E09 "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	defer r.Body.Close()
+	body := http.MaxBytesReader(w, r.Body, 65536)
+	data, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, "E09 "+err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	aReq := &ApprovalRequest{}
+	if err := json.Unmarshal(data, aReq); err != nil {
+		http.Error(w, "E09 "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validator.New().Struct(aReq); err != nil {
+		http.Error(w, "E09 "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = cast.Approvals.Resolve(approvalID, &cast.ApprovalDecision{
+		Approved:      *aReq.Approved,
+		By:            by,
+		Comment:       aReq.Comment,
+		Authenticated: true,
+	})
+	if err != nil {
+		http.Error(w, "E09 "+err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
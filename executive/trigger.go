@@ -0,0 +1,303 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package executive
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" // #nosec G505 -- some webhooks still sign with sha1
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bhmj/jsonslice"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/cast"
+	"github.com/develatio/nebulant-cli/nhttpd"
+	"github.com/develatio/nebulant-cli/util"
+)
+
+const (
+	// TriggerAuthHMAC const. Body signed with the secret, GitHub style:
+	// X-Hub-Signature-256: sha256=<hex digest>
+	TriggerAuthHMAC = "hmac"
+	// TriggerAuthToken const. Secret sent as "Authorization: Bearer <secret>"
+	// or in the token header, GitLab style: X-Gitlab-Token: <secret>
+	TriggerAuthToken = "token"
+	// TriggerAuthNone const
+	TriggerAuthNone = "none"
+
+	triggerDefaultSignatureHeader = "X-Hub-Signature-256"
+)
+
+var triggers = &triggerRegistry{}
+
+// TriggerEntry struct. A named webhook that runs a blueprint
+type TriggerEntry struct {
+	Name string `json:"name" validate:"required"`
+	// same url or path accepted by the run command
+	Blueprint string `json:"blueprint" validate:"required"`
+	// blueprint is a local file, like run -f
+	File bool `json:"file"`
+	// static blueprint args: ["--varname=value"]
+	Args []string `json:"args"`
+	// blueprint var name -> json path into the request body.
+	// Overrides the static args.
+	ArgsMap map[string]string `json:"args_map"`
+	Auth    string            `json:"auth" validate:"required,oneof=hmac token none"`
+	Secret  string            `json:"secret"`
+	// read the secret from this env var instead
+	SecretEnv string `json:"secret_env"`
+	// hmac: header with the signature (X-Hub-Signature-256 by default)
+	// token: header with the token, Authorization: Bearer is always accepted
+	Header string `json:"header"`
+}
+
+// TriggerFile struct
+type TriggerFile struct {
+	Triggers []*TriggerEntry `json:"triggers" validate:"required,dive"`
+}
+
+// TriggerResponse struct
+type TriggerResponse struct {
+	ExecutionUUID string `json:"execution_uuid"`
+}
+
+type trigger struct {
+	entry  *TriggerEntry
+	bpURL  *blueprint.BlueprintURL
+	args   []*blueprint.IRBArg
+	secret []byte
+}
+
+type triggerRegistry struct {
+	mu       sync.Mutex
+	triggers map[string]*trigger
+}
+
+// LoadTriggers func. Loads the triggers file and adds
+// the trigger view to the server
+func LoadTriggers(path string) error {
+	data, err := os.ReadFile(path) // #nosec G304 -- path from cli
+	if err != nil {
+		return err
+	}
+	tfile := &TriggerFile{}
+	if err := util.UnmarshalValidJSON(data, tfile); err != nil {
+		return fmt.Errorf("bad triggers file %s: %v", path, err)
+	}
+	loaded := make(map[string]*trigger)
+	for _, entry := range tfile.Triggers {
+		if _, exists := loaded[entry.Name]; exists {
+			return fmt.Errorf("duplicated trigger name %s", entry.Name)
+		}
+		t := &trigger{entry: entry}
+		t.bpURL, err = blueprint.ParseRef(entry.Blueprint, entry.File, filepath.Dir(path))
+		if err != nil {
+			return fmt.Errorf("trigger %s: %v", entry.Name, err)
+		}
+		t.args, err = blueprint.ParseBPArgs(entry.Args)
+		if err != nil {
+			return fmt.Errorf("trigger %s: %v", entry.Name, err)
+		}
+		for varname, jpath := range entry.ArgsMap {
+			if _, err := jsonslice.Get([]byte("{}"), jpath); err != nil {
+				return fmt.Errorf("trigger %s: bad json path %s for %s: %v", entry.Name, jpath, varname, err)
+			}
+		}
+		secret := entry.Secret
+		if entry.SecretEnv != "" {
+			secret = os.Getenv(entry.SecretEnv)
+		}
+		if entry.Auth != TriggerAuthNone && secret == "" {
+			return fmt.Errorf("trigger %s: empty secret for %s auth", entry.Name, entry.Auth)
+		}
+		if entry.Auth == TriggerAuthNone {
+			cast.LogWarn("Trigger "+entry.Name+" accepts unauthenticated requests", nil)
+		}
+		t.secret = []byte(secret)
+		loaded[entry.Name] = t
+	}
+
+	triggers.mu.Lock()
+	triggers.triggers = loaded
+	triggers.mu.Unlock()
+	nhttpd.GetServer().AddView(`^/trigger/([-a-zA-Z0-9_.]+)$`, triggerView)
+	return nil
+}
+
+func (r *triggerRegistry) get(name string) (*trigger, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, exists := r.triggers[name]
+	if !exists {
+		return nil, fmt.Errorf("trigger %s not found", name)
+	}
+	return t, nil
+}
+
+// authenticate checks the signature or the token of the request
+func (t *trigger) authenticate(r *http.Request, body []byte) error {
+	switch t.entry.Auth {
+	case TriggerAuthNone:
+		return nil
+	case TriggerAuthToken:
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found && t.entry.Header != "" {
+			token = r.Header.Get(t.entry.Header)
+		}
+		if token != "" && subtle.ConstantTimeCompare([]byte(token), t.secret) == 1 {
+			return nil
+		}
+		return fmt.Errorf("invalid token")
+	case TriggerAuthHMAC:
+		header := t.entry.Header
+		if header == "" {
+			header = triggerDefaultSignatureHeader
+		}
+		algo, signature, found := strings.Cut(r.Header.Get(header), "=")
+		if !found {
+			// plain hex digest
+			algo, signature = "sha256", algo
+		}
+		var fn func() hash.Hash
+		switch algo {
+		case "sha256":
+			fn = sha256.New
+		case "sha1":
+			fn = sha1.New
+		default:
+			return fmt.Errorf("unsupported signature algorithm %s", algo)
+		}
+		expected, err := hex.DecodeString(signature)
+		if err != nil {
+			return fmt.Errorf("invalid signature")
+		}
+		mac := hmac.New(fn, t.secret)
+		mac.Write(body) // #nosec G104 -- hash writes never fail
+		if !hmac.Equal(mac.Sum(nil), expected) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown auth %s", t.entry.Auth)
+}
+
+// buildArgs merges the static args and the values mapped
+// from the request body
+func (t *trigger) buildArgs(body []byte) ([]string, error) {
+	values := make(map[string]string)
+	var names []string
+	for _, arg := range t.args {
+		if _, exists := values[arg.Name]; !exists {
+			names = append(names, arg.Name)
+		}
+		values[arg.Name] = arg.Value
+	}
+	if len(t.entry.ArgsMap) > 0 {
+		if !json.Valid(body) {
+			return nil, fmt.Errorf("the request body is not valid JSON")
+		}
+		for varname, jpath := range t.entry.ArgsMap {
+			raw, err := jsonslice.Get(body, jpath)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read %s from the request: %v", jpath, err)
+			}
+			if len(raw) <= 0 || string(raw) == "[]" {
+				// field not present in this request
+				continue
+			}
+			value := string(raw)
+			var str string
+			if err := json.Unmarshal(raw, &str); err == nil {
+				value = str
+			}
+			if _, exists := values[varname]; !exists {
+				names = append(names, varname)
+			}
+			values[varname] = value
+		}
+	}
+	var args []string
+	for _, name := range names {
+		args = append(args, "--"+name+"="+values[name])
+	}
+	return args, nil
+}
+
+func triggerView(w http.ResponseWriter, r *http.Request, matches [][]string) {
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Origin, Authorization")
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	t, err := triggers.get(matches[0][1])
+	if err != nil {
+		http.Error(w, "E12 "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	defer r.Body.Close()
+	body := http.MaxBytesReader(w, r.Body, 4000000)
+	data, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, "E12 "+err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err := t.authenticate(r, data); err != nil {
+		cast.LogWarn("Trigger "+t.entry.Name+" rejected: "+err.Error(), nil)
+		http.Error(w, "E12 "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+	args, err := t.buildArgs(data)
+	if err != nil {
+		http.Error(w, "E12 "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cast.LogInfo("Trigger "+t.entry.Name+" fired", nil)
+	irb, err := blueprint.NewIRBFromAny(t.bpURL, &blueprint.IRBGenConfig{Args: args})
+	if err != nil {
+		http.Error(w, "E12 "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	MDirector.HandleIRB <- &HandleIRBConfig{IRB: irb}
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(&TriggerResponse{ExecutionUUID: *irb.ExecutionUUID}); err != nil {
+		cast.LogErr("E12 "+err.Error(), nil)
+	}
+}
@@ -23,12 +23,14 @@
 package ipc
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
 	"math/rand"
 	"net"
-	"time"
+	"strings"
+	"sync"
 
 	"github.com/develatio/nebulant-cli/base"
 	"github.com/develatio/nebulant-cli/cast"
@@ -92,8 +94,6 @@ func (c *IPCConsumer) ExposeStoreVars(store base.IStore) chan bool {
 				}
 			case <-out:
 				break L
-			default:
-				time.Sleep(200000 * time.Microsecond)
 			}
 		}
 	}()
@@ -106,12 +106,29 @@ type IPC struct {
 	l         net.Listener
 	Errors    chan error
 	closed    bool
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 func (p *IPC) IsClosed() bool {
 	return p.closed
 }
 
+// Done returns a channel that is closed when the server is closed, so
+// readers of Errors can stop waiting without polling IsClosed.
+func (p *IPC) Done() <-chan struct{} {
+	return p.done
+}
+
+// pushErr sends err to the Errors chan unless the server has been closed,
+// in which case nobody is listening anymore and the error is dropped.
+func (p *IPC) pushErr(err error) {
+	select {
+	case p.Errors <- err:
+	case <-p.done:
+	}
+}
+
 func (p *IPC) SetListener(l net.Listener) {
 	p.l = l
 }
@@ -135,6 +152,7 @@ func (p *IPC) Close() error {
 	err := p.l.Close()
 	p.l = nil
 	p.closed = true
+	p.closeOnce.Do(func() { close(p.done) })
 	return err
 }
 
@@ -143,7 +161,7 @@ func (p *IPC) Accept() error {
 	defer func() {
 		err := p.Close()
 		if err != nil {
-			p.Errors <- err
+			p.pushErr(err)
 		}
 	}()
 	for {
@@ -152,7 +170,7 @@ func (p *IPC) Accept() error {
 		}
 		con, err := p.l.Accept()
 		if err != nil {
-			p.Errors <- err
+			p.pushErr(err)
 			continue
 		}
 		go p.serve(con)
@@ -160,6 +178,13 @@ func (p *IPC) Accept() error {
 	return nil
 }
 
+// maxSignalBody is the max size of the body of a signal cmd
+const maxSignalBody = 16 * 1024 * 1024
+
+// serve handles one request per connection. The request is a header
+// line "IPCSID IPCCID COMMAND VARNAME" ended by \n. The signal cmd adds
+// the length of the body to the header, followed by the body itself:
+// "IPCSID IPCCID signal TOKEN LENGTH\n<BODY>"
 func (p *IPC) serve(con net.Conn) {
 	defer func() {
 		if con != nil {
@@ -169,31 +194,48 @@ func (p *IPC) serve(con net.Conn) {
 			}
 		}
 	}()
-	buf := make([]byte, 512)
-	// var buff bytes.Buffer
-	for {
-		n, err := con.Read(buf)
-		if err != nil {
-			if err != io.EOF {
-				p.Errors <- err
-			}
-			break
-		}
-		str := string(buf[:n])
-		ppd := &PipeData{c: con}
-		_, err = fmt.Sscanf(str, "%s %s %s %s", &ppd.IPCSID, &ppd.IPCCID, &ppd.COMMAND, &ppd.VARNAME)
-		if err != nil {
-			p.Errors <- err
-			continue
+	reader := bufio.NewReader(con)
+	header, err := reader.ReadString('\n')
+	if err != nil {
+		if err != io.EOF {
+			p.pushErr(err)
 		}
-		if _, exists := p.consumers[ppd.IPCCID]; exists {
-			p.consumers[ppd.IPCCID].Stream <- ppd
+		return
+	}
+	ppd := &PipeData{c: con}
+	var length int
+	n, err := fmt.Sscanf(header, "%s %s %s %s %d", &ppd.IPCSID, &ppd.IPCCID, &ppd.COMMAND, &ppd.VARNAME, &length)
+	if n < 4 {
+		p.pushErr(err)
+		return
+	}
+	if ppd.COMMAND == "signal" {
+		resp := "OK"
+		if length < 0 || length > maxSignalBody {
+			resp = fmt.Sprintf("ERR invalid body length %d", length)
 		} else {
-			err := ppd.Resp("")
-			if err != nil {
-				p.Errors <- err
+			body := make([]byte, length)
+			if _, err := io.ReadFull(reader, body); err != nil {
+				p.pushErr(err)
+				return
 			}
+			if err := cast.Callbacks.Signal(ppd.VARNAME, "ipc", body); err != nil {
+				resp = "ERR " + err.Error()
+			}
+		}
+		if err := ppd.Resp(resp); err != nil {
+			p.pushErr(err)
 		}
+		return
+	}
+	if consumer, exists := p.consumers[ppd.IPCCID]; exists && ppd.COMMAND == "readvar" {
+		// the consumer responds and closes the connection
+		consumer.Stream <- ppd
+		con = nil
+		return
+	}
+	if err := ppd.Resp(""); err != nil {
+		p.pushErr(err)
 	}
 }
 
@@ -203,6 +245,7 @@ func NewListenerIPCServer(l net.Listener, id string) (*IPC, error) {
 		uuid:      id,
 		consumers: make(map[string]*IPCConsumer),
 		Errors:    make(chan error),
+		done:      make(chan struct{}),
 	}
 	if l == nil {
 		l, err = ipc.listen()
@@ -217,3 +260,20 @@ func NewListenerIPCServer(l net.Listener, id string) (*IPC, error) {
 func NewIPCServer() (*IPC, error) {
 	return NewListenerIPCServer(nil, fmt.Sprintf("%d", rand.Int())) // #nosec G404 -- Weak random is OK here
 }
+
+// Signal func. Sends body to the action waiting for the callback
+// token. The token starts with the id of the IPC server.
+func Signal(token string, body string) error {
+	ipcsid, _, found := strings.Cut(token, ".")
+	if !found || ipcsid == "" {
+		return fmt.Errorf("invalid callback token")
+	}
+	resp, err := Read(ipcsid, "-", fmt.Sprintf("signal %s %d\n%s", token, len(body), body))
+	if err != nil {
+		return err
+	}
+	if resp != "OK" {
+		return errors.New(strings.TrimPrefix(resp, "ERR "))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// MIT License
+//
+// Copyright (C) 2023  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build !windows
+
+package ipc
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/develatio/nebulant-cli/cast"
+)
+
+func TestSignalBody(t *testing.T) {
+	if cast.SBus == nil {
+		cast.InitSystemBus()
+	}
+	ipcs, err := NewIPCServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go ipcs.Accept()
+	defer ipcs.Close()
+
+	cb, err := cast.Callbacks.Register(ipcs.GetUUID(), nil, "action")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cast.Callbacks.Unregister(nil)
+
+	// bigger than a single read and with new lines
+	body := `{"data": "` + strings.Repeat("x", 200000) + `",` + "\n" + `"ok": true}`
+	if err := Signal(cb.Token, body); err != nil {
+		t.Fatal(err)
+	}
+	ev := <-cb.Events()
+	var v map[string]interface{}
+	if err := json.Unmarshal(ev.Body, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v["ok"] != true || len(v["data"].(string)) != 200000 {
+		t.Errorf("body truncated")
+	}
+
+	if err := Signal(ipcs.GetUUID()+".unknown", "{}"); err == nil {
+		t.Errorf("unknown token should fail")
+	}
+}
+
+func TestConsumerUnknownCommand(t *testing.T) {
+	ipcs, err := NewIPCServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go ipcs.Accept()
+	defer ipcs.Close()
+
+	// nobody reads the stream, only readvar is handed to the consumer
+	consumer := &IPCConsumer{ID: "c1", Stream: make(chan *PipeData, 1)}
+	ipcs.AppendConsumer(consumer)
+	defer ipcs.OutConsumer(consumer)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		resp, err := Read(ipcs.GetUUID(), "c1", "unknown X")
+		if err != nil || resp != "" {
+			t.Errorf("unexpected response %q (%v)", resp, err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the connection of an unknown command was not closed")
+	}
+	if len(consumer.Stream) != 0 {
+		t.Errorf("unknown command handed to the consumer")
+	}
+}
@@ -25,7 +25,9 @@
 package ipc
 
 import (
+	"io"
 	"net"
+	"strings"
 
 	"github.com/Microsoft/go-winio"
 )
@@ -48,16 +50,20 @@ func Read(ipsid string, ipcid string, msg string) (string, error) {
 	}
 	defer c.Close()
 
+	// the header line ends with the first \n, msg
+	// without \n is a header without body
+	if !strings.Contains(msg, "\n") {
+		msg += "\n"
+	}
 	_, err = c.Write([]byte(ipsid + " " + ipcid + " " + msg))
 	if err != nil {
 		return "", err
 	}
 
-	buf := make([]byte, 1024)
-
-	n, err := c.Read(buf[:])
+	// the server closes the connection after the response
+	resp, err := io.ReadAll(c)
 	if err != nil {
 		return "", err
 	}
-	return string(buf[0:n]), nil
+	return string(resp), nil
 }
@@ -307,24 +307,17 @@ func (s *SSHClient) StartIPC() (*ipc.IPCConsumer, error) {
 	go func() {
 		err := ipcs.Accept()
 		if err != nil {
-			ipcs.Errors <- err
+			s.Events <- &SSHClientEvent{Type: SSHClientEventError, SSHClient: s, Error: err}
 		}
 	}()
 
 	go func() {
-	L:
 		for {
 			select {
 			case err := <-ipcs.Errors:
 				s.Events <- &SSHClientEvent{Type: SSHClientEventError, SSHClient: s, Error: err}
-			default:
-				if len(ipcs.Errors) > 0 {
-					continue
-				}
-				if ipcs.IsClosed() {
-					break L
-				}
-				time.Sleep(200000 * time.Microsecond)
+			case <-ipcs.Done():
+				return
 			}
 		}
 	}()
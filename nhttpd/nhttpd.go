@@ -30,13 +30,14 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/develatio/nebulant-cli/cast"
 	"github.com/develatio/nebulant-cli/config"
 )
 
-var server = &Httpd{urls: make(map[*regexp.Regexp]ViewFunc), validOrigins: make(map[string]bool)}
+var server = &Httpd{validOrigins: make(map[string]bool)}
 
 func GetServer() *Httpd {
 	addr := net.JoinHostPort(config.SERVER_ADDR, config.SERVER_PORT)
@@ -46,6 +47,11 @@ func GetServer() *Httpd {
 
 type ViewFunc func(w http.ResponseWriter, r *http.Request, matches [][]string)
 
+type route struct {
+	rgx  *regexp.Regexp
+	view ViewFunc
+}
+
 // Httpd struct
 type Httpd struct {
 	validOrigins map[string]bool
@@ -54,10 +60,12 @@ type Httpd struct {
 	scheme       string
 	errors       []error
 	consumers    []chan error
-	urls         map[*regexp.Regexp]ViewFunc
-	addr         string
-	certPath     *string
-	keyPath      *string
+	// matched in the order they are added
+	urls     []*route
+	urlsMu   sync.RWMutex
+	addr     string
+	certPath *string
+	keyPath  *string
 }
 
 func (h *Httpd) SetSecure(cert string, key string) {
@@ -69,8 +77,12 @@ func (h *Httpd) SetAddr(addr string) {
 	h.addr = addr
 }
 
+// AddView func. Routes are matched in the order they are
+// added, so path should be anchored (^...$)
 func (h *Httpd) AddView(path string, view ViewFunc) {
-	h.urls[regexp.MustCompile(path)] = view
+	h.urlsMu.Lock()
+	defer h.urlsMu.Unlock()
+	h.urls = append(h.urls, &route{rgx: regexp.MustCompile(path), view: view})
 }
 
 func (h *Httpd) AddOrigin(origin string) {
@@ -142,13 +154,15 @@ func (h *Httpd) route(w http.ResponseWriter, r *http.Request) {
 
 	var vfn ViewFunc
 	var vrgx *regexp.Regexp
-	for rgx, fn := range h.urls {
-		if rgx.MatchString(r.URL.Path) {
-			vfn = fn
-			vrgx = rgx
+	h.urlsMu.RLock()
+	for _, rt := range h.urls {
+		if rt.rgx.MatchString(r.URL.Path) {
+			vfn = rt.view
+			vrgx = rt.rgx
 			break
 		}
 	}
+	h.urlsMu.RUnlock()
 	if vfn != nil {
 		matches := vrgx.FindAllStringSubmatch(r.URL.Path, -1)
 		vfn(w, r, matches)
@@ -23,14 +23,35 @@
 package actors
 
 import (
+	"context"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 	"github.com/develatio/nebulant-cli/base"
 	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/cast"
+	"github.com/develatio/nebulant-cli/providers/objectstore"
+	"github.com/develatio/nebulant-cli/util"
 )
 
 type ec2Client func() ec2iface.EC2API
+type s3Client func() *s3.Client
+type route53Client func() route53iface.Route53API
+type ssmClient func() ssmiface.SSMAPI
+type elbv2Client func() elbv2iface.ELBV2API
+type taggingClient func() resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
 
 // ActionContext struct
 type ActionContext struct {
@@ -40,11 +61,22 @@ type ActionContext struct {
 	Store        base.IStore
 	Logger       base.ILogger
 	NewEC2Client ec2Client
+	NewS3Client  s3Client
+
+	NewRoute53Client route53Client
+	NewSSMClient     ssmClient
+	NewTaggingClient taggingClient
+	NewELBV2Client   elbv2Client
 }
 
 var NewActionContext = func(awsSess *session.Session, action *blueprint.Action, store base.IStore, logger base.ILogger) *ActionContext {
 	l := logger.Duplicate()
 	l.SetActionID(action.ActionID)
+	if action.DebugNetwork {
+		awsSess = awsSess.Copy(&aws.Config{
+			HTTPClient: util.NewDebugHTTPClient(awsSess.Config.HTTPClient, cast.NetworkTracer(l)),
+		})
+	}
 	return &ActionContext{
 		AwsSess: awsSess,
 		Action:  action,
@@ -53,7 +85,55 @@ var NewActionContext = func(awsSess *session.Session, action *blueprint.Action,
 		NewEC2Client: func() ec2iface.EC2API {
 			return ec2.New(awsSess)
 		},
+		NewS3Client: func() *s3.Client {
+			return newS3Client(awsSess)
+		},
+		NewRoute53Client: func() route53iface.Route53API {
+			return route53.New(awsSess)
+		},
+		NewSSMClient: func() ssmiface.SSMAPI {
+			return ssm.New(awsSess)
+		},
+		NewTaggingClient: func() resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI {
+			return resourcegroupstaggingapi.New(awsSess)
+		},
+		NewELBV2Client: func() elbv2iface.ELBV2API {
+			return elbv2.New(awsSess)
+		},
+	}
+}
+
+// newS3Client returns an sdk v2 S3 client sharing region, credentials,
+// endpoint and http client with the v1 session used by the other actors
+func newS3Client(sess *session.Session) *s3.Client {
+	opts := s3.Options{
+		Region:       aws.StringValue(sess.Config.Region),
+		UsePathStyle: aws.BoolValue(sess.Config.S3ForcePathStyle),
+		Credentials: awsv2.CredentialsProviderFunc(func(ctx context.Context) (awsv2.Credentials, error) {
+			v, err := sess.Config.Credentials.GetWithContext(ctx)
+			if err != nil {
+				return awsv2.Credentials{}, err
+			}
+			creds := awsv2.Credentials{
+				AccessKeyID:     v.AccessKeyID,
+				SecretAccessKey: v.SecretAccessKey,
+				SessionToken:    v.SessionToken,
+				Source:          v.ProviderName,
+			}
+			if exp, err := sess.Config.Credentials.ExpiresAt(); err == nil {
+				creds.CanExpire = true
+				creds.Expires = exp
+			}
+			return creds, nil
+		}),
+	}
+	if sess.Config.Endpoint != nil && *sess.Config.Endpoint != "" {
+		opts.BaseEndpoint = sess.Config.Endpoint
 	}
+	if sess.Config.HTTPClient != nil {
+		opts.HTTPClient = sess.Config.HTTPClient
+	}
+	return s3.New(opts)
 }
 
 // ActionFunc func
@@ -91,8 +171,16 @@ var ActionFuncMap map[string]*ActionLayout = map[string]*ActionLayout{
 	"stop_instance":    {F: StopInstance, N: NextOKKO},
 	"start_instance":   {F: StartInstance, N: NextOKKO},
 
-	"find_images":   {F: FindImages, N: NextOKKO},
-	"findone_image": {F: FindOneImage, N: NextOKKO},
+	"find_images":      {F: FindImages, N: NextOKKO},
+	"findone_image":    {F: FindOneImage, N: NextOKKO},
+	"create_image":     {F: CreateImage, N: NextOKKO},
+	"copy_image":       {F: CopyImage, N: NextOKKO},
+	"deregister_image": {F: DeregisterImage, N: NextOKKO},
+
+	"find_snapshots":   {F: FindSnapshots, N: NextOKKO},
+	"findone_snapshot": {F: FindOneSnapshot, N: NextOKKO},
+	"create_snapshot":  {F: CreateSnapshot, N: NextOKKO},
+	"delete_snapshot":  {F: DeleteSnapshot, N: NextOKKO},
 
 	"find_ifaces":   {F: FindNetworkInterfaces, N: NextOKKO},
 	"findone_iface": {F: FindNetworkInterface, N: NextOKKO},
@@ -113,21 +201,92 @@ var ActionFuncMap map[string]*ActionLayout = map[string]*ActionLayout{
 	"release_address": {F: ReleaseAddress, N: NextOKKO},
 	"detach_address":  {F: DetachAddress, N: NextOKKO},
 
-	"set_region": {F: SetRegion, N: NextOKKO},
+	"set_region":       {F: SetRegion, N: NextOKKO},
+	"set_default_tags": {F: SetDefaultTags, N: NextOKKO},
+	"tag_resources":    {F: TagResources, N: NextOKKO},
+	"untag_resources":  {F: UntagResources, N: NextOKKO},
 
 	"find_vpcs":   {F: FindVpcs, N: NextOKKO},
 	"findone_vpc": {F: FindOneVpc, N: NextOKKO},
+	"create_vpc":  {F: CreateVpc, N: NextOKKO},
 	"delete_vpc":  {F: DeleteVpc, N: NextOKKO},
 
 	"find_subnets":   {F: FindSubnets, N: NextOKKO},
 	"findone_subnet": {F: FindOneSubnet, N: NextOKKO},
+	"create_subnet":  {F: CreateSubnet, N: NextOKKO},
 	"delete_subnet":  {F: DeleteSubnet, N: NextOKKO},
 
+	"create_internetgateway": {F: CreateInternetGateway, N: NextOKKO},
+	"attach_internetgateway": {F: AttachInternetGateway, N: NextOKKO},
+	"detach_internetgateway": {F: DetachInternetGateway, N: NextOKKO},
+	"delete_internetgateway": {F: DeleteInternetGateway, N: NextOKKO},
+	"create_natgateway":      {F: CreateNatGateway, N: NextOKKO},
+	"delete_natgateway":      {F: DeleteNatGateway, N: NextOKKO},
+
+	"create_routetable":       {F: CreateRouteTable, N: NextOKKO},
+	"delete_routetable":       {F: DeleteRouteTable, N: NextOKKO},
+	"associate_routetable":    {F: AssociateRouteTable, N: NextOKKO},
+	"disassociate_routetable": {F: DisassociateRouteTable, N: NextOKKO},
+	"create_route":            {F: CreateRoute, N: NextOKKO},
+	"delete_route":            {F: DeleteRoute, N: NextOKKO},
+
 	"find_securitygroups":   {F: FindSecurityGroups, N: NextOKKO},
 	"findone_securitygroup": {F: FindOneSecurityGroup, N: NextOKKO},
+	"create_securitygroup":  {F: CreateSecurityGroup, N: NextOKKO},
 	"delete_securitygroup":  {F: DeleteSecurityGroup, N: NextOKKO},
 
+	"authorize_securitygroup_ingress": {F: AuthorizeSecurityGroupIngress, N: NextOKKO},
+	"authorize_securitygroup_egress":  {F: AuthorizeSecurityGroupEgress, N: NextOKKO},
+	"revoke_securitygroup_ingress":    {F: RevokeSecurityGroupIngress, N: NextOKKO},
+	"revoke_securitygroup_egress":     {F: RevokeSecurityGroupEgress, N: NextOKKO},
+
 	"find_keypairs":   {F: FindKeyPairs, N: NextOKKO},
 	"findone_keypair": {F: FindOneKeyPair, N: NextOKKO},
+	"create_keypair":  {F: CreateKeyPair, N: NextOKKO},
+	"import_keypair":  {F: ImportKeyPair, N: NextOKKO},
 	"delete_keypair":  {F: DeleteKeyPair, N: NextOKKO},
+
+	"find_hostedzones":   {F: FindHostedZones, N: NextOKKO},
+	"findone_hostedzone": {F: FindOneHostedZone, N: NextOKKO},
+	"find_records":       {F: FindRecords, N: NextOKKO},
+	"findone_record":     {F: FindOneRecord, N: NextOKKO},
+	"create_record":      {F: CreateRecord, N: NextOKKO},
+	"upsert_record":      {F: UpsertRecord, N: NextOKKO},
+	"delete_record":      {F: DeleteRecord, N: NextOKKO},
+
+	"run_command": {F: RunCommand, N: NextOKKO},
+
+	"find_loadbalancers":   {F: FindLoadBalancers, N: NextOKKO},
+	"findone_loadbalancer": {F: FindOneLoadBalancer, N: NextOKKO},
+	"create_loadbalancer":  {F: CreateLoadBalancer, N: NextOKKO},
+	"delete_loadbalancer":  {F: DeleteLoadBalancer, N: NextOKKO},
+
+	"find_targetgroups":   {F: FindTargetGroups, N: NextOKKO},
+	"findone_targetgroup": {F: FindOneTargetGroup, N: NextOKKO},
+	"create_targetgroup":  {F: CreateTargetGroup, N: NextOKKO},
+	"delete_targetgroup":  {F: DeleteTargetGroup, N: NextOKKO},
+	"register_targets":    {F: RegisterTargets, N: NextOKKO},
+	"deregister_targets":  {F: DeregisterTargets, N: NextOKKO},
+	"find_targets":        {F: FindTargets, N: NextOKKO},
+
+	"find_listeners":   {F: FindListeners, N: NextOKKO},
+	"findone_listener": {F: FindOneListener, N: NextOKKO},
+	"create_listener":  {F: CreateListener, N: NextOKKO},
+	"modify_listener":  {F: ModifyListener, N: NextOKKO},
+	"delete_listener":  {F: DeleteListener, N: NextOKKO},
+
+	"find_rules":   {F: FindRules, N: NextOKKO},
+	"findone_rule": {F: FindOneRule, N: NextOKKO},
+	"create_rule":  {F: CreateRule, N: NextOKKO},
+	"modify_rule":  {F: ModifyRule, N: NextOKKO},
+	"delete_rule":  {F: DeleteRule, N: NextOKKO},
+
+	"create_bucket":  {F: s3Action(objectstore.CreateBucket), N: NextOKKO},
+	"delete_bucket":  {F: s3Action(objectstore.DeleteBucket), N: NextOKKO},
+	"upload_files":   {F: s3Action(objectstore.UploadFiles), N: NextOKKO},
+	"download_files": {F: s3Action(objectstore.DownloadFiles), N: NextOKKO},
+	"list_objects":   {F: s3Action(objectstore.ListObjects), N: NextOKKO},
+	"delete_objects": {F: s3Action(objectstore.DeleteObjects), N: NextOKKO},
+	"presign_url":    {F: s3Action(objectstore.PresignURL), N: NextOKKO},
+	"sync_directory": {F: s3Action(objectstore.SyncDirectory), N: NextOKKO},
 }
@@ -35,7 +35,7 @@ type setRegionParameters struct {
 // SetRegion func
 func SetRegion(ctx *ActionContext) (*base.ActionOutput, error) {
 	awsinput := new(setRegionParameters)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -37,7 +37,11 @@ import (
 func FindVolumes(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.DescribeVolumesInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -52,6 +56,11 @@ func FindVolumes(ctx *ActionContext) (*base.ActionOutput, error) {
 	region := ctx.AwsSess.Config.Region
 	ctx.Logger.LogInfo("Looking for volumes in region " + *region)
 
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+	awsinput.Filters = append(awsinput.Filters, tags.ec2Filters()...)
+
 	svc := ctx.NewEC2Client()
 	result, err := svc.DescribeVolumes(awsinput)
 	if err != nil {
@@ -87,7 +96,7 @@ func FindOneVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 // CreateVolume func
 func CreateVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 	awsinput := new(ec2.CreateVolumeInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	internalparams := new(blueprint.InternalParameters)
@@ -105,6 +114,8 @@ func CreateVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 		awsinput.AvailabilityZone = &defaultAvZone
 	}
 
+	awsinput.TagSpecifications = withDefaultTags(ctx, ec2.ResourceTypeVolume, awsinput.TagSpecifications)
+
 	svc := ctx.NewEC2Client()
 	vol, err := svc.CreateVolume(awsinput) // ec2.Volume
 	if err != nil {
@@ -147,7 +158,7 @@ func AttachVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 	svc := ctx.NewEC2Client()
 
 	awsinput := new(ec2.AttachVolumeInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	internalparams := new(blueprint.InternalParameters)
@@ -196,7 +207,7 @@ func AttachVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 func DetachVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.DetachVolumeInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -222,7 +233,7 @@ func DetachVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 func DeleteVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.DeleteVolumeInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	internalparams := new(blueprint.InternalParameters)
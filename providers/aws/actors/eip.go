@@ -33,13 +33,15 @@ import (
 // AllocateAddress func
 func AllocateAddress(ctx *ActionContext) (*base.ActionOutput, error) {
 	awsinput := new(ec2.AllocateAddressInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
 		return nil, nil
 	}
 
+	awsinput.TagSpecifications = withDefaultTags(ctx, ec2.ResourceTypeElasticIp, awsinput.TagSpecifications)
+
 	svc := ctx.NewEC2Client()
 	result, err := svc.AllocateAddress(awsinput)
 	if err != nil {
@@ -63,7 +65,11 @@ func AllocateAddress(ctx *ActionContext) (*base.ActionOutput, error) {
 func FindAddresses(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.DescribeAddressesInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -75,6 +81,11 @@ func FindAddresses(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+	awsinput.Filters = append(awsinput.Filters, tags.ec2Filters()...)
+
 	svc := ctx.NewEC2Client()
 	result, err := svc.DescribeAddresses(awsinput)
 	if err != nil {
@@ -112,7 +123,7 @@ func FindOneAddress(ctx *ActionContext) (*base.ActionOutput, error) {
 func AttachAddress(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.AssociateAddressInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -143,7 +154,7 @@ func AttachAddress(ctx *ActionContext) (*base.ActionOutput, error) {
 func DetachAddress(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.DisassociateAddressInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -169,7 +180,7 @@ func DetachAddress(ctx *ActionContext) (*base.ActionOutput, error) {
 func ReleaseAddress(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.ReleaseAddressInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -0,0 +1,861 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/util"
+)
+
+// max ARNs per DescribeTags request
+const elbDescribeTagsBatchSize = 20
+
+// isAWSErrorCode returns true if err is an aws error with code
+func isAWSErrorCode(err error, code string) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == code
+}
+
+// elbTaggedArns returns the ARNs of the elb resources that match
+// the tags. Elbv2 has no tag filters, the tags of each resource
+// are retrieved and compared here.
+func elbTaggedArns(svc elbv2iface.ELBV2API, arns []*string, tags *findTagsParameters) (map[string]bool, error) {
+	matched := make(map[string]bool)
+	for start := 0; start < len(arns); start += elbDescribeTagsBatchSize {
+		end := start + elbDescribeTagsBatchSize
+		if end > len(arns) {
+			end = len(arns)
+		}
+		result, err := svc.DescribeTags(&elbv2.DescribeTagsInput{ResourceArns: arns[start:end]})
+		if err != nil {
+			return nil, err
+		}
+		for _, desc := range result.TagDescriptions {
+			resourceTags := make(map[string]string, len(desc.Tags))
+			for _, tag := range desc.Tags {
+				resourceTags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+			if tags.match(resourceTags) {
+				matched[aws.StringValue(desc.ResourceArn)] = true
+			}
+		}
+	}
+	return matched, nil
+}
+
+// FindLoadBalancers func
+func FindLoadBalancers(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.DescribeLoadBalancersInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+
+	ctx.Logger.LogInfo("Looking for load balancers in region " + aws.StringValue(ctx.AwsSess.Config.Region))
+	svc := ctx.NewELBV2Client()
+	result := new(elbv2.DescribeLoadBalancersOutput)
+	err = svc.DescribeLoadBalancersPages(awsinput, func(page *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
+		result.LoadBalancers = append(result.LoadBalancers, page.LoadBalancers...)
+		return true
+	})
+	if err != nil && !isAWSErrorCode(err, elbv2.ErrCodeLoadBalancerNotFoundException) {
+		return nil, err
+	}
+
+	if len(tags.Tags) > 0 && len(result.LoadBalancers) > 0 {
+		arns := make([]*string, 0, len(result.LoadBalancers))
+		for _, lb := range result.LoadBalancers {
+			arns = append(arns, lb.LoadBalancerArn)
+		}
+		matched, err := elbTaggedArns(svc, arns, tags)
+		if err != nil {
+			return nil, err
+		}
+		lbs := make([]*elbv2.LoadBalancer, 0, len(matched))
+		for _, lb := range result.LoadBalancers {
+			if matched[aws.StringValue(lb.LoadBalancerArn)] {
+				lbs = append(lbs, lb)
+			}
+		}
+		result.LoadBalancers = lbs
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// FindOneLoadBalancer func
+func FindOneLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
+	aout, err := FindLoadBalancers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+	if len(aout.Records) <= 0 {
+		return nil, fmt.Errorf("no load balancer found")
+	}
+	raw := aout.Records[0].Value.(*elbv2.DescribeLoadBalancersOutput)
+	found := len(raw.LoadBalancers)
+	if found > 1 {
+		return nil, fmt.Errorf("too many results")
+	}
+	if found <= 0 {
+		return nil, fmt.Errorf("no load balancer found")
+	}
+	aout = base.NewActionOutput(ctx.Action, raw.LoadBalancers[0], raw.LoadBalancers[0].LoadBalancerArn)
+	return aout, nil
+}
+
+// CreateLoadBalancer func
+func CreateLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.CreateLoadBalancerInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	awsinput.Tags = withDefaultELBv2Tags(ctx, awsinput.Tags)
+
+	ctx.Logger.LogInfo("Creating load balancer " + *awsinput.Name)
+	svc := ctx.NewELBV2Client()
+	result, err := svc.CreateLoadBalancer(awsinput)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.LoadBalancers) <= 0 {
+		return nil, fmt.Errorf("no load balancer created")
+	}
+	lb := result.LoadBalancers[0]
+
+	if internalparams.Waiters != nil {
+		waitinput := &elbv2.DescribeLoadBalancersInput{
+			LoadBalancerArns: []*string{lb.LoadBalancerArn},
+		}
+		for _, waitername := range internalparams.Waiters {
+			switch waitername {
+			case "WaitUntilLoadBalancerExists":
+				ctx.Logger.LogInfo("Waiting for load balancer to exist...")
+				err = svc.WaitUntilLoadBalancerExists(waitinput)
+				if err != nil {
+					return nil, err
+				}
+			case "WaitUntilLoadBalancerAvailable":
+				ctx.Logger.LogInfo("Waiting for load balancer to be active...")
+				err = svc.WaitUntilLoadBalancerAvailable(waitinput)
+				if err != nil {
+					return nil, err
+				}
+				lb.State = &elbv2.LoadBalancerState{Code: aws.String(elbv2.LoadBalancerStateEnumActive)}
+			default:
+				return nil, fmt.Errorf("unkown waiter")
+			}
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, lb, lb.LoadBalancerArn)
+	return aout, nil
+}
+
+// DeleteLoadBalancer func
+func DeleteLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.DeleteLoadBalancerInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Logger.LogInfo("Deleting load balancer " + *awsinput.LoadBalancerArn)
+	svc := ctx.NewELBV2Client()
+	result, err := svc.DeleteLoadBalancer(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	if internalparams.Waiters != nil {
+		waitinput := &elbv2.DescribeLoadBalancersInput{
+			LoadBalancerArns: []*string{awsinput.LoadBalancerArn},
+		}
+		for _, waitername := range internalparams.Waiters {
+			switch waitername {
+			case "WaitUntilLoadBalancersDeleted":
+				ctx.Logger.LogInfo("Waiting for load balancer to be deleted...")
+				err = svc.WaitUntilLoadBalancersDeleted(waitinput)
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("unkown waiter")
+			}
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// FindTargetGroups func
+func FindTargetGroups(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.DescribeTargetGroupsInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewELBV2Client()
+	result := new(elbv2.DescribeTargetGroupsOutput)
+	err = svc.DescribeTargetGroupsPages(awsinput, func(page *elbv2.DescribeTargetGroupsOutput, lastPage bool) bool {
+		result.TargetGroups = append(result.TargetGroups, page.TargetGroups...)
+		return true
+	})
+	if err != nil && !isAWSErrorCode(err, elbv2.ErrCodeTargetGroupNotFoundException) {
+		return nil, err
+	}
+
+	if len(tags.Tags) > 0 && len(result.TargetGroups) > 0 {
+		arns := make([]*string, 0, len(result.TargetGroups))
+		for _, tg := range result.TargetGroups {
+			arns = append(arns, tg.TargetGroupArn)
+		}
+		matched, err := elbTaggedArns(svc, arns, tags)
+		if err != nil {
+			return nil, err
+		}
+		tgs := make([]*elbv2.TargetGroup, 0, len(matched))
+		for _, tg := range result.TargetGroups {
+			if matched[aws.StringValue(tg.TargetGroupArn)] {
+				tgs = append(tgs, tg)
+			}
+		}
+		result.TargetGroups = tgs
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// FindOneTargetGroup func
+func FindOneTargetGroup(ctx *ActionContext) (*base.ActionOutput, error) {
+	aout, err := FindTargetGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+	if len(aout.Records) <= 0 {
+		return nil, fmt.Errorf("no target group found")
+	}
+	raw := aout.Records[0].Value.(*elbv2.DescribeTargetGroupsOutput)
+	found := len(raw.TargetGroups)
+	if found > 1 {
+		return nil, fmt.Errorf("too many results")
+	}
+	if found <= 0 {
+		return nil, fmt.Errorf("no target group found")
+	}
+	aout = base.NewActionOutput(ctx.Action, raw.TargetGroups[0], raw.TargetGroups[0].TargetGroupArn)
+	return aout, nil
+}
+
+// CreateTargetGroup func
+func CreateTargetGroup(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.CreateTargetGroupInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	awsinput.Tags = withDefaultELBv2Tags(ctx, awsinput.Tags)
+
+	ctx.Logger.LogInfo("Creating target group " + *awsinput.Name)
+	svc := ctx.NewELBV2Client()
+	result, err := svc.CreateTargetGroup(awsinput)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.TargetGroups) <= 0 {
+		return nil, fmt.Errorf("no target group created")
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result.TargetGroups[0], result.TargetGroups[0].TargetGroupArn)
+	return aout, nil
+}
+
+// DeleteTargetGroup func
+func DeleteTargetGroup(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.DeleteTargetGroupInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Logger.LogInfo("Deleting target group " + *awsinput.TargetGroupArn)
+	svc := ctx.NewELBV2Client()
+	result, err := svc.DeleteTargetGroup(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// RegisterTargets func
+func RegisterTargets(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.RegisterTargetsInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Logger.LogInfo(fmt.Sprintf("Registering %d targets in target group %s", len(awsinput.Targets), *awsinput.TargetGroupArn))
+	svc := ctx.NewELBV2Client()
+	result, err := svc.RegisterTargets(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	if internalparams.Waiters != nil {
+		waitinput := &elbv2.DescribeTargetHealthInput{
+			TargetGroupArn: awsinput.TargetGroupArn,
+			Targets:        awsinput.Targets,
+		}
+		for _, waitername := range internalparams.Waiters {
+			switch waitername {
+			case "WaitUntilTargetInService":
+				ctx.Logger.LogInfo("Waiting for targets to be healthy...")
+				err = svc.WaitUntilTargetInService(waitinput)
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("unkown waiter")
+			}
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// DeregisterTargets func
+func DeregisterTargets(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.DeregisterTargetsInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Logger.LogInfo(fmt.Sprintf("Deregistering %d targets from target group %s", len(awsinput.Targets), *awsinput.TargetGroupArn))
+	svc := ctx.NewELBV2Client()
+	result, err := svc.DeregisterTargets(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	if internalparams.Waiters != nil {
+		waitinput := &elbv2.DescribeTargetHealthInput{
+			TargetGroupArn: awsinput.TargetGroupArn,
+			Targets:        awsinput.Targets,
+		}
+		for _, waitername := range internalparams.Waiters {
+			switch waitername {
+			case "WaitUntilTargetDeregistered":
+				ctx.Logger.LogInfo("Waiting for targets to be deregistered...")
+				err = svc.WaitUntilTargetDeregistered(waitinput)
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("unkown waiter")
+			}
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// FindTargets func. Returns the targets of a target group with their health
+// Targets are instance ids, ips or lambda arns of the group, not
+// tagged elb resources, so the tags shorthand is not accepted here.
+func FindTargets(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.DescribeTargetHealthInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewELBV2Client()
+	result, err := svc.DescribeTargetHealth(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// FindListeners func
+func FindListeners(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.DescribeListenersInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewELBV2Client()
+	result := new(elbv2.DescribeListenersOutput)
+	err = svc.DescribeListenersPages(awsinput, func(page *elbv2.DescribeListenersOutput, lastPage bool) bool {
+		result.Listeners = append(result.Listeners, page.Listeners...)
+		return true
+	})
+	if err != nil && !isAWSErrorCode(err, elbv2.ErrCodeListenerNotFoundException) {
+		return nil, err
+	}
+
+	if len(tags.Tags) > 0 && len(result.Listeners) > 0 {
+		arns := make([]*string, 0, len(result.Listeners))
+		for _, listener := range result.Listeners {
+			arns = append(arns, listener.ListenerArn)
+		}
+		matched, err := elbTaggedArns(svc, arns, tags)
+		if err != nil {
+			return nil, err
+		}
+		listeners := make([]*elbv2.Listener, 0, len(matched))
+		for _, listener := range result.Listeners {
+			if matched[aws.StringValue(listener.ListenerArn)] {
+				listeners = append(listeners, listener)
+			}
+		}
+		result.Listeners = listeners
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// FindOneListener func
+func FindOneListener(ctx *ActionContext) (*base.ActionOutput, error) {
+	aout, err := FindListeners(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+	if len(aout.Records) <= 0 {
+		return nil, fmt.Errorf("no listener found")
+	}
+	raw := aout.Records[0].Value.(*elbv2.DescribeListenersOutput)
+	found := len(raw.Listeners)
+	if found > 1 {
+		return nil, fmt.Errorf("too many results")
+	}
+	if found <= 0 {
+		return nil, fmt.Errorf("no listener found")
+	}
+	aout = base.NewActionOutput(ctx.Action, raw.Listeners[0], raw.Listeners[0].ListenerArn)
+	return aout, nil
+}
+
+// CreateListener func
+func CreateListener(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.CreateListenerInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	awsinput.Tags = withDefaultELBv2Tags(ctx, awsinput.Tags)
+
+	ctx.Logger.LogInfo("Creating listener for load balancer " + *awsinput.LoadBalancerArn)
+	svc := ctx.NewELBV2Client()
+	result, err := svc.CreateListener(awsinput)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Listeners) <= 0 {
+		return nil, fmt.Errorf("no listener created")
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result.Listeners[0], result.Listeners[0].ListenerArn)
+	return aout, nil
+}
+
+// ModifyListener func. Commonly used to switch the default
+// target group of a listener
+func ModifyListener(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.ModifyListenerInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Logger.LogInfo("Modifying listener " + *awsinput.ListenerArn)
+	svc := ctx.NewELBV2Client()
+	result, err := svc.ModifyListener(awsinput)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Listeners) <= 0 {
+		return nil, fmt.Errorf("no listener modified")
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result.Listeners[0], result.Listeners[0].ListenerArn)
+	return aout, nil
+}
+
+// DeleteListener func
+func DeleteListener(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.DeleteListenerInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Logger.LogInfo("Deleting listener " + *awsinput.ListenerArn)
+	svc := ctx.NewELBV2Client()
+	result, err := svc.DeleteListener(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// FindRules func
+func FindRules(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.DescribeRulesInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewELBV2Client()
+	result := new(elbv2.DescribeRulesOutput)
+	// DescribeRules has no pages func
+	for {
+		page, err := svc.DescribeRules(awsinput)
+		if err != nil {
+			if isAWSErrorCode(err, elbv2.ErrCodeRuleNotFoundException) {
+				break
+			}
+			return nil, err
+		}
+		result.Rules = append(result.Rules, page.Rules...)
+		if aws.StringValue(page.NextMarker) == "" {
+			break
+		}
+		awsinput.Marker = page.NextMarker
+	}
+
+	if len(tags.Tags) > 0 && len(result.Rules) > 0 {
+		arns := make([]*string, 0, len(result.Rules))
+		for _, rule := range result.Rules {
+			arns = append(arns, rule.RuleArn)
+		}
+		matched, err := elbTaggedArns(svc, arns, tags)
+		if err != nil {
+			return nil, err
+		}
+		rules := make([]*elbv2.Rule, 0, len(matched))
+		for _, rule := range result.Rules {
+			if matched[aws.StringValue(rule.RuleArn)] {
+				rules = append(rules, rule)
+			}
+		}
+		result.Rules = rules
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// FindOneRule func
+func FindOneRule(ctx *ActionContext) (*base.ActionOutput, error) {
+	aout, err := FindRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+	if len(aout.Records) <= 0 {
+		return nil, fmt.Errorf("no rule found")
+	}
+	raw := aout.Records[0].Value.(*elbv2.DescribeRulesOutput)
+	found := len(raw.Rules)
+	if found > 1 {
+		return nil, fmt.Errorf("too many results")
+	}
+	if found <= 0 {
+		return nil, fmt.Errorf("no rule found")
+	}
+	aout = base.NewActionOutput(ctx.Action, raw.Rules[0], raw.Rules[0].RuleArn)
+	return aout, nil
+}
+
+// CreateRule func
+func CreateRule(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.CreateRuleInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	awsinput.Tags = withDefaultELBv2Tags(ctx, awsinput.Tags)
+
+	ctx.Logger.LogInfo("Creating rule for listener " + *awsinput.ListenerArn)
+	svc := ctx.NewELBV2Client()
+	result, err := svc.CreateRule(awsinput)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Rules) <= 0 {
+		return nil, fmt.Errorf("no rule created")
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result.Rules[0], result.Rules[0].RuleArn)
+	return aout, nil
+}
+
+// ModifyRule func
+func ModifyRule(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.ModifyRuleInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Logger.LogInfo("Modifying rule " + *awsinput.RuleArn)
+	svc := ctx.NewELBV2Client()
+	result, err := svc.ModifyRule(awsinput)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Rules) <= 0 {
+		return nil, fmt.Errorf("no rule modified")
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result.Rules[0], result.Rules[0].RuleArn)
+	return aout, nil
+}
+
+// DeleteRule func
+func DeleteRule(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(elbv2.DeleteRuleInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Logger.LogInfo("Deleting rule " + *awsinput.RuleArn)
+	svc := ctx.NewELBV2Client()
+	result, err := svc.DeleteRule(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
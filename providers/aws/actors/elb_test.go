@@ -0,0 +1,246 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/develatio/nebulant-cli/providers/aws/actors"
+)
+
+type fakeELBV2Client struct {
+	elbv2iface.ELBV2API
+}
+
+// fakeLoadBalancers are returned in pages of one
+// load balancer by the fake elbv2 client
+var fakeLoadBalancers = []*elbv2.LoadBalancer{
+	{LoadBalancerArn: aws.String("arn:lb/blue"), LoadBalancerName: aws.String("blue")},
+	{LoadBalancerArn: aws.String("arn:lb/green"), LoadBalancerName: aws.String("green")},
+}
+
+var fakeELBTags = map[string][]*elbv2.Tag{
+	"arn:lb/blue":  {{Key: aws.String("color"), Value: aws.String("blue")}},
+	"arn:lb/green": {{Key: aws.String("color"), Value: aws.String("green")}, {Key: aws.String("live"), Value: aws.String("yes")}},
+}
+
+func (f *fakeELBV2Client) CreateLoadBalancer(input *elbv2.CreateLoadBalancerInput) (*elbv2.CreateLoadBalancerOutput, error) {
+	tags := make([]string, 0, len(input.Tags))
+	for _, tag := range input.Tags {
+		tags = append(tags, *tag.Key+"="+*tag.Value)
+	}
+	fakeEC2Calls = append(fakeEC2Calls, "CreateLoadBalancer:"+*input.Name+":"+strings.Join(tags, ","))
+	return &elbv2.CreateLoadBalancerOutput{
+		LoadBalancers: []*elbv2.LoadBalancer{{
+			LoadBalancerArn:  aws.String("arn:lb/" + *input.Name),
+			LoadBalancerName: input.Name,
+			State:            &elbv2.LoadBalancerState{Code: aws.String(elbv2.LoadBalancerStateEnumProvisioning)},
+		}},
+	}, nil
+}
+
+func (f *fakeELBV2Client) WaitUntilLoadBalancerAvailable(input *elbv2.DescribeLoadBalancersInput) error {
+	fakeEC2Calls = append(fakeEC2Calls, "WaitUntilLoadBalancerAvailable:"+*input.LoadBalancerArns[0])
+	return nil
+}
+
+func (f *fakeELBV2Client) DeleteLoadBalancer(input *elbv2.DeleteLoadBalancerInput) (*elbv2.DeleteLoadBalancerOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "DeleteLoadBalancer:"+*input.LoadBalancerArn)
+	return &elbv2.DeleteLoadBalancerOutput{}, nil
+}
+
+func (f *fakeELBV2Client) WaitUntilLoadBalancersDeleted(input *elbv2.DescribeLoadBalancersInput) error {
+	fakeEC2Calls = append(fakeEC2Calls, "WaitUntilLoadBalancersDeleted:"+*input.LoadBalancerArns[0])
+	return nil
+}
+
+func (f *fakeELBV2Client) DescribeLoadBalancersPages(input *elbv2.DescribeLoadBalancersInput, fn func(*elbv2.DescribeLoadBalancersOutput, bool) bool) error {
+	fakeEC2Calls = append(fakeEC2Calls, "DescribeLoadBalancers:"+strings.Join(aws.StringValueSlice(input.Names), ","))
+	if len(input.Names) > 0 {
+		return awserr.New(elbv2.ErrCodeLoadBalancerNotFoundException, "not found", nil)
+	}
+	for i, lb := range fakeLoadBalancers {
+		if !fn(&elbv2.DescribeLoadBalancersOutput{LoadBalancers: []*elbv2.LoadBalancer{lb}}, i == len(fakeLoadBalancers)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeELBV2Client) DescribeTags(input *elbv2.DescribeTagsInput) (*elbv2.DescribeTagsOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "DescribeTags:"+strings.Join(aws.StringValueSlice(input.ResourceArns), ","))
+	out := &elbv2.DescribeTagsOutput{}
+	for _, arn := range input.ResourceArns {
+		out.TagDescriptions = append(out.TagDescriptions, &elbv2.TagDescription{ResourceArn: arn, Tags: fakeELBTags[*arn]})
+	}
+	return out, nil
+}
+
+func (f *fakeELBV2Client) RegisterTargets(input *elbv2.RegisterTargetsInput) (*elbv2.RegisterTargetsOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "RegisterTargets:"+*input.TargetGroupArn+":"+*input.Targets[0].Id)
+	return &elbv2.RegisterTargetsOutput{}, nil
+}
+
+func (f *fakeELBV2Client) WaitUntilTargetInService(input *elbv2.DescribeTargetHealthInput) error {
+	fakeEC2Calls = append(fakeEC2Calls, "WaitUntilTargetInService:"+*input.TargetGroupArn+":"+*input.Targets[0].Id)
+	return nil
+}
+
+func (f *fakeELBV2Client) DeregisterTargets(input *elbv2.DeregisterTargetsInput) (*elbv2.DeregisterTargetsOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "DeregisterTargets:"+*input.TargetGroupArn+":"+*input.Targets[0].Id)
+	return &elbv2.DeregisterTargetsOutput{}, nil
+}
+
+func (f *fakeELBV2Client) WaitUntilTargetDeregistered(input *elbv2.DescribeTargetHealthInput) error {
+	fakeEC2Calls = append(fakeEC2Calls, "WaitUntilTargetDeregistered:"+*input.TargetGroupArn+":"+*input.Targets[0].Id)
+	return nil
+}
+
+func (f *fakeELBV2Client) ModifyListener(input *elbv2.ModifyListenerInput) (*elbv2.ModifyListenerOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "ModifyListener:"+*input.ListenerArn+":"+*input.DefaultActions[0].TargetGroupArn)
+	return &elbv2.ModifyListenerOutput{
+		Listeners: []*elbv2.Listener{{ListenerArn: input.ListenerArn, DefaultActions: input.DefaultActions}},
+	}, nil
+}
+
+func (f *fakeELBV2Client) DescribeRules(input *elbv2.DescribeRulesInput) (*elbv2.DescribeRulesOutput, error) {
+	marker := aws.StringValue(input.Marker)
+	fakeEC2Calls = append(fakeEC2Calls, "DescribeRules:"+*input.ListenerArn+":"+marker)
+	if marker == "" {
+		return &elbv2.DescribeRulesOutput{
+			Rules:      []*elbv2.Rule{{RuleArn: aws.String("arn:rule/1")}},
+			NextMarker: aws.String("2"),
+		}, nil
+	}
+	return &elbv2.DescribeRulesOutput{
+		Rules: []*elbv2.Rule{{RuleArn: aws.String("arn:rule/2")}},
+	}, nil
+}
+
+func TestCreateDeleteLoadBalancer(t *testing.T) {
+	aout, calls, err := runEC2Action(t, actors.CreateLoadBalancer, `{"Name": "web", "Subnets": ["subnet-1", "subnet-2"], "_waiters": ["WaitUntilLoadBalancerAvailable"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "CreateLoadBalancer:web:", "WaitUntilLoadBalancerAvailable:arn:lb/web")
+	if aout.Records[0].ValueID != "arn:lb/web" {
+		t.Errorf("unexpected value id %s", aout.Records[0].ValueID)
+	}
+	lb := aout.Records[0].Value.(*elbv2.LoadBalancer)
+	if *lb.State.Code != elbv2.LoadBalancerStateEnumActive {
+		t.Errorf("expected active load balancer, got %s", *lb.State.Code)
+	}
+
+	_, calls, err = runEC2Action(t, actors.CreateLoadBalancer, `{"Name": "web", "_waiters": ["WaitUntilNothing"]}`)
+	if err == nil {
+		t.Errorf("unknown waiters should fail")
+	}
+	expectCalls(t, calls, "CreateLoadBalancer:web:")
+
+	_, calls, err = runEC2Action(t, actors.CreateLoadBalancer, `{"Subnets": ["subnet-1"]}`)
+	if err == nil {
+		t.Errorf("load balancer without name should fail")
+	}
+	expectCalls(t, calls)
+
+	_, calls, err = runEC2Action(t, actors.DeleteLoadBalancer, `{"LoadBalancerArn": "arn:lb/web", "_waiters": ["WaitUntilLoadBalancersDeleted"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "DeleteLoadBalancer:arn:lb/web", "WaitUntilLoadBalancersDeleted:arn:lb/web")
+}
+
+func TestFindLoadBalancers(t *testing.T) {
+	aout, calls, err := runEC2Action(t, actors.FindLoadBalancers, `{}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "DescribeLoadBalancers:")
+	if found := len(aout.Records[0].Value.(*elbv2.DescribeLoadBalancersOutput).LoadBalancers); found != 2 {
+		t.Errorf("expected the load balancers of all the pages, got %d", found)
+	}
+
+	aout, calls, err = runEC2Action(t, actors.FindOneLoadBalancer, `{"tags": {"live": "*"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "DescribeLoadBalancers:", "DescribeTags:arn:lb/blue,arn:lb/green")
+	if aout.Records[0].ValueID != "arn:lb/green" {
+		t.Errorf("unexpected value id %s", aout.Records[0].ValueID)
+	}
+
+	_, _, err = runEC2Action(t, actors.FindOneLoadBalancer, `{"tags": {"color": "red"}}`)
+	if err == nil || err.Error() != "no load balancer found" {
+		t.Errorf("expected no load balancer found, got %v", err)
+	}
+
+	_, _, err = runEC2Action(t, actors.FindOneLoadBalancer, `{"Names": ["missing"]}`)
+	if err == nil || err.Error() != "no load balancer found" {
+		t.Errorf("expected no load balancer found, got %v", err)
+	}
+}
+
+func TestRegisterDeregisterTargets(t *testing.T) {
+	_, calls, err := runEC2Action(t, actors.RegisterTargets, `{"TargetGroupArn": "arn:tg/blue", "Targets": [{"Id": "i-1"}], "_waiters": ["WaitUntilTargetInService"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "RegisterTargets:arn:tg/blue:i-1", "WaitUntilTargetInService:arn:tg/blue:i-1")
+
+	_, calls, err = runEC2Action(t, actors.DeregisterTargets, `{"TargetGroupArn": "arn:tg/blue", "Targets": [{"Id": "i-1"}], "_waiters": ["WaitUntilTargetDeregistered"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "DeregisterTargets:arn:tg/blue:i-1", "WaitUntilTargetDeregistered:arn:tg/blue:i-1")
+
+	_, calls, err = runEC2Action(t, actors.RegisterTargets, `{"TargetGroupArn": "arn:tg/blue"}`)
+	if err == nil {
+		t.Errorf("register without targets should fail")
+	}
+	expectCalls(t, calls)
+}
+
+func TestListenersAndRules(t *testing.T) {
+	aout, calls, err := runEC2Action(t, actors.ModifyListener, `{"ListenerArn": "arn:listener/1", "DefaultActions": [{"Type": "forward", "TargetGroupArn": "arn:tg/green"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "ModifyListener:arn:listener/1:arn:tg/green")
+	if aout.Records[0].ValueID != "arn:listener/1" {
+		t.Errorf("unexpected value id %s", aout.Records[0].ValueID)
+	}
+
+	aout, calls, err = runEC2Action(t, actors.FindRules, `{"ListenerArn": "arn:listener/1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "DescribeRules:arn:listener/1:", "DescribeRules:arn:listener/1:2")
+	if found := len(aout.Records[0].Value.(*elbv2.DescribeRulesOutput).Rules); found != 2 {
+		t.Errorf("expected the rules of all the pages, got %d", found)
+	}
+}
@@ -0,0 +1,259 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/util"
+)
+
+// CreateInternetGateway func
+func CreateInternetGateway(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(ec2.CreateInternetGatewayInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	awsinput.TagSpecifications = withDefaultTags(ctx, ec2.ResourceTypeInternetGateway, awsinput.TagSpecifications)
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.CreateInternetGateway(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	if internalparams.Waiters != nil {
+		waitinput := &ec2.DescribeInternetGatewaysInput{
+			InternetGatewayIds: []*string{result.InternetGateway.InternetGatewayId},
+		}
+		for _, waitername := range internalparams.Waiters {
+			switch waitername {
+			case "WaitUntilInternetGatewayExists":
+				ctx.Logger.LogInfo("Waiting for internet gateway to exist...")
+				err = svc.WaitUntilInternetGatewayExists(waitinput)
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("unkown waiter")
+			}
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result.InternetGateway, result.InternetGateway.InternetGatewayId)
+	return aout, nil
+}
+
+// AttachInternetGateway func
+func AttachInternetGateway(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.AttachInternetGatewayInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.AttachInternetGateway(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// DetachInternetGateway func
+func DetachInternetGateway(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.DetachInternetGatewayInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.DetachInternetGateway(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// DeleteInternetGateway func
+func DeleteInternetGateway(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.DeleteInternetGatewayInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.DeleteInternetGateway(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// CreateNatGateway func
+func CreateNatGateway(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(ec2.CreateNatGatewayInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	awsinput.TagSpecifications = withDefaultTags(ctx, ec2.ResourceTypeNatgateway, awsinput.TagSpecifications)
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.CreateNatGateway(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	if internalparams.Waiters != nil {
+		waitinput := &ec2.DescribeNatGatewaysInput{
+			NatGatewayIds: []*string{result.NatGateway.NatGatewayId},
+		}
+		for _, waitername := range internalparams.Waiters {
+			switch waitername {
+			case "WaitUntilNatGatewayAvailable":
+				ctx.Logger.LogInfo("Waiting for nat gateway to be available...")
+				err = svc.WaitUntilNatGatewayAvailable(waitinput)
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("unkown waiter")
+			}
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result.NatGateway, result.NatGateway.NatGatewayId)
+	return aout, nil
+}
+
+// DeleteNatGateway func
+func DeleteNatGateway(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(ec2.DeleteNatGatewayInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.DeleteNatGateway(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	if internalparams.Waiters != nil {
+		waitinput := &ec2.DescribeNatGatewaysInput{
+			NatGatewayIds: []*string{awsinput.NatGatewayId},
+		}
+		for _, waitername := range internalparams.Waiters {
+			switch waitername {
+			case "WaitUntilNatGatewayDeleted":
+				ctx.Logger.LogInfo("Waiting for nat gateway to be deleted...")
+				err = svc.WaitUntilNatGatewayDeleted(waitinput)
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("unkown waiter")
+			}
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, result.NatGatewayId)
+	return aout, nil
+}
@@ -34,7 +34,11 @@ import (
 func FindNetworkInterfaces(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.DescribeNetworkInterfacesInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -46,6 +50,11 @@ func FindNetworkInterfaces(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+	awsinput.Filters = append(awsinput.Filters, tags.ec2Filters()...)
+
 	svc := ctx.NewEC2Client()
 	outresult, err := svc.DescribeNetworkInterfaces(awsinput)
 	if err != nil {
@@ -84,7 +93,7 @@ func FindNetworkInterface(ctx *ActionContext) (*base.ActionOutput, error) {
 func DeleteNetworkInterface(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.DeleteNetworkInterfaceInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
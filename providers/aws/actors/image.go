@@ -23,10 +23,14 @@
 package actors
 
 import (
+	"encoding/json"
 	"fmt"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
 	"github.com/develatio/nebulant-cli/util"
 )
 
@@ -34,7 +38,11 @@ import (
 func FindImages(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.DescribeImagesInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -49,6 +57,11 @@ func FindImages(ctx *ActionContext) (*base.ActionOutput, error) {
 	region := ctx.AwsSess.Config.Region
 	ctx.Logger.LogInfo("Looking for image in region " + *region)
 
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+	awsinput.Filters = append(awsinput.Filters, tags.ec2Filters()...)
+
 	svc := ctx.NewEC2Client()
 	result, err := svc.DescribeImages(awsinput)
 	if err != nil {
@@ -82,3 +95,178 @@ func FindOneImage(ctx *ActionContext) (*base.ActionOutput, error) {
 	aout = base.NewActionOutput(ctx.Action, raw.Images[0], raw.Images[0].ImageId)
 	return aout, nil
 }
+
+type deregisterImageParameters struct {
+	ec2.DeregisterImageInput
+	// also delete the snapshots of the EBS volumes of the image
+	DeleteSnapshots bool
+}
+
+func waitImage(ctx *ActionContext, svc ec2iface.EC2API, imageID *string, waiters []string) error {
+	waitinput := &ec2.DescribeImagesInput{
+		ImageIds: []*string{imageID},
+	}
+	for _, waitername := range waiters {
+		switch waitername {
+		case "WaitUntilImageExists":
+			ctx.Logger.LogInfo("Waiting for image to exist...")
+			err := svc.WaitUntilImageExists(waitinput)
+			if err != nil {
+				return err
+			}
+		case "WaitUntilImageAvailable":
+			ctx.Logger.LogInfo("Waiting for image to be available...")
+			err := svc.WaitUntilImageAvailable(waitinput)
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unkown waiter")
+		}
+	}
+	return nil
+}
+
+// CreateImage func. Creates an AMI from an instance
+func CreateImage(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(ec2.CreateImageInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	awsinput.TagSpecifications = withDefaultTags(ctx, ec2.ResourceTypeImage, awsinput.TagSpecifications)
+	awsinput.TagSpecifications = withDefaultTags(ctx, ec2.ResourceTypeSnapshot, awsinput.TagSpecifications)
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.CreateImage(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	if internalparams.Waiters != nil {
+		err = waitImage(ctx, svc, result.ImageId, internalparams.Waiters)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, result.ImageId)
+	return aout, nil
+}
+
+// CopyImage func. The copy is created in the current region, use
+// set_region before to choose the destination
+func CopyImage(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(ec2.CopyImageInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Logger.LogInfo("Copying image " + *awsinput.SourceImageId + " from " + *awsinput.SourceRegion + " to " + aws.StringValue(ctx.AwsSess.Config.Region))
+	svc := ctx.NewEC2Client()
+	result, err := svc.CopyImage(awsinput)
+	if err != nil {
+		return nil, err
+	}
+	// CopyImage has no tag specifications, the default
+	// tags are added to the new image once it exists
+	if tags := defaultTags(ctx); len(tags) > 0 {
+		ec2tags := make([]*ec2.Tag, 0, len(tags))
+		for _, k := range sortedTagKeys(tags) {
+			ec2tags = append(ec2tags, &ec2.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+		}
+		_, err = svc.CreateTags(&ec2.CreateTagsInput{Resources: []*string{result.ImageId}, Tags: ec2tags})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if internalparams.Waiters != nil {
+		err = waitImage(ctx, svc, result.ImageId, internalparams.Waiters)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, result.ImageId)
+	return aout, nil
+}
+
+// DeregisterImage func
+func DeregisterImage(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	params := new(deregisterImageParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewEC2Client()
+	var snapshotIDs []*string
+	if params.DeleteSnapshots {
+		images, err := svc.DescribeImages(&ec2.DescribeImagesInput{
+			ImageIds: []*string{params.ImageId},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range images.Images {
+			for _, bdm := range image.BlockDeviceMappings {
+				if bdm.Ebs != nil && bdm.Ebs.SnapshotId != nil {
+					snapshotIDs = append(snapshotIDs, bdm.Ebs.SnapshotId)
+				}
+			}
+		}
+	}
+
+	result, err := svc.DeregisterImage(&params.DeregisterImageInput)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, snapshotID := range snapshotIDs {
+		ctx.Logger.LogInfo("Deleting snapshot " + *snapshotID + "...")
+		_, err = svc.DeleteSnapshot(&ec2.DeleteSnapshotInput{
+			SnapshotId: snapshotID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
@@ -30,6 +30,10 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 	"github.com/develatio/nebulant-cli/base"
 	"github.com/develatio/nebulant-cli/blueprint"
 	"github.com/develatio/nebulant-cli/providers/aws/actors"
@@ -63,6 +67,18 @@ func mockapi() (*session.Session, error) {
 			NewEC2Client: func() ec2iface.EC2API {
 				return &fakeEC2Client{}
 			},
+			NewRoute53Client: func() route53iface.Route53API {
+				return &fakeRoute53Client{}
+			},
+			NewSSMClient: func() ssmiface.SSMAPI {
+				return &fakeSSMClient{}
+			},
+			NewTaggingClient: func() resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI {
+				return &fakeTaggingClient{}
+			},
+			NewELBV2Client: func() elbv2iface.ELBV2API {
+				return &fakeELBV2Client{}
+			},
 		}
 	}
 	sess, serr := session.NewSessionWithOptions(session.Options{
@@ -75,6 +91,21 @@ func mockapi() (*session.Session, error) {
 }
 
 func (f *fakeEC2Client) DescribeImages(input *ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error) {
+	if len(input.ImageIds) > 0 {
+		fakeEC2Calls = append(fakeEC2Calls, "DescribeImages:"+*input.ImageIds[0])
+		return &ec2.DescribeImagesOutput{
+			Images: []*ec2.Image{
+				{
+					ImageId: input.ImageIds[0],
+					BlockDeviceMappings: []*ec2.BlockDeviceMapping{
+						{DeviceName: aws.String("/dev/xvda"), Ebs: &ec2.EbsBlockDevice{SnapshotId: aws.String("snap-1")}},
+						{DeviceName: aws.String("/dev/xvdb"), Ebs: &ec2.EbsBlockDevice{SnapshotId: aws.String("snap-2")}},
+						{DeviceName: aws.String("/dev/sdc"), VirtualName: aws.String("ephemeral0")},
+					},
+				},
+			},
+		}, nil
+	}
 	if *input.Filters[0].Name == "none" {
 		return &ec2.DescribeImagesOutput{
 			Images: []*ec2.Image{},
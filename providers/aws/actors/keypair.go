@@ -24,6 +24,9 @@ package actors
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -35,7 +38,11 @@ import (
 func FindKeyPairs(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.DescribeKeyPairsInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -50,6 +57,11 @@ func FindKeyPairs(ctx *ActionContext) (*base.ActionOutput, error) {
 	region := ctx.AwsSess.Config.Region
 	ctx.Logger.LogInfo("Looking for key pairs in region " + *region)
 
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+	awsinput.Filters = append(awsinput.Filters, tags.ec2Filters()...)
+
 	svc := ctx.NewEC2Client()
 	result, err := svc.DescribeKeyPairs(awsinput)
 	if err != nil {
@@ -96,11 +108,115 @@ func FindOneKeyPair(ctx *ActionContext) (*base.ActionOutput, error) {
 	return aout, nil
 }
 
+type importKeyPairParameters struct {
+	KeyName *string
+	// path to a local public key, like ~/.ssh/id_ed25519.pub
+	PublicKeyFile *string
+	// or the public key itself
+	PublicKeyMaterial *string
+	TagSpecifications []*ec2.TagSpecification
+	DryRun            *bool
+}
+
+func (p *importKeyPairParameters) Validate() error {
+	if p.KeyName == nil || *p.KeyName == "" {
+		return fmt.Errorf("KeyName is required")
+	}
+	if p.PublicKeyFile == nil && p.PublicKeyMaterial == nil {
+		return fmt.Errorf("PublicKeyFile or PublicKeyMaterial is required")
+	}
+	if p.PublicKeyFile != nil && p.PublicKeyMaterial != nil {
+		return fmt.Errorf("use only one of PublicKeyFile or PublicKeyMaterial")
+	}
+	return nil
+}
+
+// CreateKeyPair func. The private key is returned into KeyMaterial of
+// a secret record, use it like {{ keyvar.KeyMaterial }}
+func CreateKeyPair(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.CreateKeyPairInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	awsinput.TagSpecifications = withDefaultTags(ctx, ec2.ResourceTypeKeyPair, awsinput.TagSpecifications)
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.CreateKeyPair(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, result.KeyPairId)
+	aout.Records[0].Secret = true
+	return aout, nil
+}
+
+// ImportKeyPair func
+func ImportKeyPair(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	params := new(importKeyPairParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var material []byte
+	if params.PublicKeyFile != nil {
+		path := *params.PublicKeyFile
+		if strings.HasPrefix(path, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			path = filepath.Join(home, path[2:])
+		}
+		material, err = os.ReadFile(path) // #nosec G304 -- The user chooses the key to import
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		material = []byte(*params.PublicKeyMaterial)
+	}
+
+	params.TagSpecifications = withDefaultTags(ctx, ec2.ResourceTypeKeyPair, params.TagSpecifications)
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.ImportKeyPair(&ec2.ImportKeyPairInput{
+		KeyName:           params.KeyName,
+		PublicKeyMaterial: material,
+		TagSpecifications: params.TagSpecifications,
+		DryRun:            params.DryRun,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, result.KeyPairId)
+	return aout, nil
+}
+
 // DeleteKeyPair func
 func DeleteKeyPair(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.DeleteKeyPairInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -37,7 +37,7 @@ import (
 func RunInstance(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.RunInstancesInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	internalparams := new(blueprint.InternalParameters)
@@ -54,6 +54,9 @@ func RunInstance(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
+	awsinput.TagSpecifications = withDefaultTags(ctx, ec2.ResourceTypeInstance, awsinput.TagSpecifications)
+	awsinput.TagSpecifications = withDefaultTags(ctx, ec2.ResourceTypeVolume, awsinput.TagSpecifications)
+
 	svc := ctx.NewEC2Client()
 	// force only one
 	awsinput.MaxCount = aws.Int64(1)
@@ -113,7 +116,7 @@ func RunInstance(ctx *ActionContext) (*base.ActionOutput, error) {
 func DeleteInstance(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.TerminateInstancesInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	internalparams := new(blueprint.InternalParameters)
@@ -162,7 +165,7 @@ func DeleteInstance(ctx *ActionContext) (*base.ActionOutput, error) {
 func StopInstance(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.StopInstancesInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	internalparams := new(blueprint.InternalParameters)
@@ -211,7 +214,7 @@ func StopInstance(ctx *ActionContext) (*base.ActionOutput, error) {
 func StartInstance(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.StartInstancesInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	internalparams := new(blueprint.InternalParameters)
@@ -269,7 +272,11 @@ func StartInstance(ctx *ActionContext) (*base.ActionOutput, error) {
 func FindInstances(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.DescribeInstancesInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -281,6 +288,11 @@ func FindInstances(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+	awsinput.Filters = append(awsinput.Filters, tags.ec2Filters()...)
+
 	svc := ctx.NewEC2Client()
 	result, err := svc.DescribeInstances(awsinput)
 
@@ -0,0 +1,290 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/providers/aws/actors"
+	"github.com/develatio/nebulant-cli/storage"
+)
+
+// fakeEC2Calls records the calls received by the fake aws clients
+var fakeEC2Calls []string
+
+func (f *fakeEC2Client) CreateVpc(input *ec2.CreateVpcInput) (*ec2.CreateVpcOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "CreateVpc")
+	return &ec2.CreateVpcOutput{
+		Vpc: &ec2.Vpc{VpcId: aws.String("vpc-1"), CidrBlock: input.CidrBlock},
+	}, nil
+}
+
+func (f *fakeEC2Client) WaitUntilVpcAvailable(input *ec2.DescribeVpcsInput) error {
+	fakeEC2Calls = append(fakeEC2Calls, "WaitUntilVpcAvailable:"+*input.VpcIds[0])
+	return nil
+}
+
+func (f *fakeEC2Client) CreateSubnet(input *ec2.CreateSubnetInput) (*ec2.CreateSubnetOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "CreateSubnet:"+*input.VpcId)
+	return &ec2.CreateSubnetOutput{
+		Subnet: &ec2.Subnet{SubnetId: aws.String("subnet-1"), VpcId: input.VpcId},
+	}, nil
+}
+
+func (f *fakeEC2Client) CreateSecurityGroup(input *ec2.CreateSecurityGroupInput) (*ec2.CreateSecurityGroupOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "CreateSecurityGroup:"+*input.GroupName)
+	return &ec2.CreateSecurityGroupOutput{GroupId: aws.String("sg-1")}, nil
+}
+
+func (f *fakeEC2Client) WaitUntilSecurityGroupExists(input *ec2.DescribeSecurityGroupsInput) error {
+	fakeEC2Calls = append(fakeEC2Calls, "WaitUntilSecurityGroupExists:"+*input.GroupIds[0])
+	return nil
+}
+
+func (f *fakeEC2Client) AuthorizeSecurityGroupIngress(input *ec2.AuthorizeSecurityGroupIngressInput) (*ec2.AuthorizeSecurityGroupIngressOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "AuthorizeSecurityGroupIngress:"+*input.GroupId+":"+*input.IpPermissions[0].IpRanges[0].CidrIp)
+	return &ec2.AuthorizeSecurityGroupIngressOutput{Return: aws.Bool(true)}, nil
+}
+
+func (f *fakeEC2Client) RevokeSecurityGroupEgress(input *ec2.RevokeSecurityGroupEgressInput) (*ec2.RevokeSecurityGroupEgressOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "RevokeSecurityGroupEgress:"+*input.GroupId)
+	return &ec2.RevokeSecurityGroupEgressOutput{Return: aws.Bool(true)}, nil
+}
+
+func (f *fakeEC2Client) CreateInternetGateway(input *ec2.CreateInternetGatewayInput) (*ec2.CreateInternetGatewayOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "CreateInternetGateway")
+	return &ec2.CreateInternetGatewayOutput{
+		InternetGateway: &ec2.InternetGateway{InternetGatewayId: aws.String("igw-1")},
+	}, nil
+}
+
+func (f *fakeEC2Client) AttachInternetGateway(input *ec2.AttachInternetGatewayInput) (*ec2.AttachInternetGatewayOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "AttachInternetGateway:"+*input.InternetGatewayId+":"+*input.VpcId)
+	return &ec2.AttachInternetGatewayOutput{}, nil
+}
+
+func (f *fakeEC2Client) CreateNatGateway(input *ec2.CreateNatGatewayInput) (*ec2.CreateNatGatewayOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "CreateNatGateway:"+*input.SubnetId)
+	return &ec2.CreateNatGatewayOutput{
+		NatGateway: &ec2.NatGateway{NatGatewayId: aws.String("nat-1"), SubnetId: input.SubnetId},
+	}, nil
+}
+
+func (f *fakeEC2Client) WaitUntilNatGatewayAvailable(input *ec2.DescribeNatGatewaysInput) error {
+	fakeEC2Calls = append(fakeEC2Calls, "WaitUntilNatGatewayAvailable:"+*input.NatGatewayIds[0])
+	return nil
+}
+
+func (f *fakeEC2Client) DeleteNatGateway(input *ec2.DeleteNatGatewayInput) (*ec2.DeleteNatGatewayOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "DeleteNatGateway:"+*input.NatGatewayId)
+	return &ec2.DeleteNatGatewayOutput{NatGatewayId: input.NatGatewayId}, nil
+}
+
+func (f *fakeEC2Client) WaitUntilNatGatewayDeleted(input *ec2.DescribeNatGatewaysInput) error {
+	fakeEC2Calls = append(fakeEC2Calls, "WaitUntilNatGatewayDeleted:"+*input.NatGatewayIds[0])
+	return nil
+}
+
+func (f *fakeEC2Client) CreateRouteTable(input *ec2.CreateRouteTableInput) (*ec2.CreateRouteTableOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "CreateRouteTable:"+*input.VpcId)
+	return &ec2.CreateRouteTableOutput{
+		RouteTable: &ec2.RouteTable{RouteTableId: aws.String("rtb-1"), VpcId: input.VpcId},
+	}, nil
+}
+
+func (f *fakeEC2Client) CreateRoute(input *ec2.CreateRouteInput) (*ec2.CreateRouteOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "CreateRoute:"+*input.RouteTableId+":"+*input.DestinationCidrBlock+":"+*input.GatewayId)
+	return &ec2.CreateRouteOutput{Return: aws.Bool(true)}, nil
+}
+
+func (f *fakeEC2Client) AssociateRouteTable(input *ec2.AssociateRouteTableInput) (*ec2.AssociateRouteTableOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "AssociateRouteTable:"+*input.RouteTableId+":"+*input.SubnetId)
+	return &ec2.AssociateRouteTableOutput{AssociationId: aws.String("rtbassoc-1")}, nil
+}
+
+// runEC2Action runs the action with params against the fake
+// client and returns his output and the recorded calls
+func runEC2Action(t *testing.T, f actors.ActionFunc, params string) (*base.ActionOutput, []string, error) {
+	t.Helper()
+	return runEC2ActionWithStore(t, f, storage.NewStore(), params)
+}
+
+// runEC2ActionWithStore is runEC2Action with a custom store
+func runEC2ActionWithStore(t *testing.T, f actors.ActionFunc, store base.IStore, params string) (*base.ActionOutput, []string, error) {
+	t.Helper()
+	sess, err := mockapi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	action := &blueprint.Action{
+		Provider:   "aws",
+		Parameters: json.RawMessage(params),
+	}
+	fakeEC2Calls = nil
+	ctx := actors.NewActionContext(sess, action, store, &fakeLogger{})
+	aout, err := f(ctx)
+	return aout, fakeEC2Calls, err
+}
+
+func expectCalls(t *testing.T, got []string, expected ...string) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("expected calls %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("expected calls %v, got %v", expected, got)
+			return
+		}
+	}
+}
+
+func TestCreateVpc(t *testing.T) {
+	aout, calls, err := runEC2Action(t, actors.CreateVpc, `{"CidrBlock": "10.0.0.0/16", "_waiters": ["WaitUntilVpcAvailable"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "CreateVpc", "WaitUntilVpcAvailable:vpc-1")
+	if aout.Records[0].ValueID != "vpc-1" {
+		t.Errorf("unexpected value id %s", aout.Records[0].ValueID)
+	}
+
+	_, _, err = runEC2Action(t, actors.CreateVpc, `{"CidrBlock": "10.0.0.0/16", "_waiters": ["WaitUntilNothing"]}`)
+	if err == nil {
+		t.Errorf("unknown waiters should fail")
+	}
+}
+
+func TestCreateSubnet(t *testing.T) {
+	aout, calls, err := runEC2Action(t, actors.CreateSubnet, `{"VpcId": "vpc-1", "CidrBlock": "10.0.1.0/24"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "CreateSubnet:vpc-1")
+	if aout.Records[0].ValueID != "subnet-1" {
+		t.Errorf("unexpected value id %s", aout.Records[0].ValueID)
+	}
+
+	_, calls, err = runEC2Action(t, actors.CreateSubnet, `{"CidrBlock": "10.0.1.0/24"}`)
+	if err == nil {
+		t.Errorf("subnet without VpcId should fail")
+	}
+	expectCalls(t, calls)
+}
+
+func TestSecurityGroupRules(t *testing.T) {
+	aout, calls, err := runEC2Action(t, actors.CreateSecurityGroup, `{"GroupName": "web", "Description": "web", "VpcId": "vpc-1", "_waiters": ["WaitUntilSecurityGroupExists"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "CreateSecurityGroup:web", "WaitUntilSecurityGroupExists:sg-1")
+	if aout.Records[0].ValueID != "sg-1" {
+		t.Errorf("unexpected value id %s", aout.Records[0].ValueID)
+	}
+
+	_, calls, err = runEC2Action(t, actors.AuthorizeSecurityGroupIngress, `{
+		"GroupId": "sg-1",
+		"IpPermissions": [{"IpProtocol": "tcp", "FromPort": 443, "ToPort": 443, "IpRanges": [{"CidrIp": "0.0.0.0/0"}]}]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "AuthorizeSecurityGroupIngress:sg-1:0.0.0.0/0")
+
+	_, calls, err = runEC2Action(t, actors.RevokeSecurityGroupEgress, `{
+		"GroupId": "sg-1",
+		"IpPermissions": [{"IpProtocol": "-1", "IpRanges": [{"CidrIp": "0.0.0.0/0"}]}]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "RevokeSecurityGroupEgress:sg-1")
+
+	_, _, err = runEC2Action(t, actors.CreateSecurityGroup, `{"GroupName": "web"}`)
+	if err == nil {
+		t.Errorf("security group without Description should fail")
+	}
+}
+
+func TestGateways(t *testing.T) {
+	aout, calls, err := runEC2Action(t, actors.CreateInternetGateway, `{}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "CreateInternetGateway")
+	if aout.Records[0].ValueID != "igw-1" {
+		t.Errorf("unexpected value id %s", aout.Records[0].ValueID)
+	}
+
+	_, calls, err = runEC2Action(t, actors.AttachInternetGateway, `{"InternetGatewayId": "igw-1", "VpcId": "vpc-1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "AttachInternetGateway:igw-1:vpc-1")
+
+	aout, calls, err = runEC2Action(t, actors.CreateNatGateway, `{"SubnetId": "subnet-1", "AllocationId": "eipalloc-1", "_waiters": ["WaitUntilNatGatewayAvailable"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "CreateNatGateway:subnet-1", "WaitUntilNatGatewayAvailable:nat-1")
+	if aout.Records[0].ValueID != "nat-1" {
+		t.Errorf("unexpected value id %s", aout.Records[0].ValueID)
+	}
+
+	_, calls, err = runEC2Action(t, actors.DeleteNatGateway, `{"NatGatewayId": "nat-1", "_waiters": ["WaitUntilNatGatewayDeleted"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "DeleteNatGateway:nat-1", "WaitUntilNatGatewayDeleted:nat-1")
+}
+
+func TestRouteTables(t *testing.T) {
+	aout, calls, err := runEC2Action(t, actors.CreateRouteTable, `{"VpcId": "vpc-1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "CreateRouteTable:vpc-1")
+	if aout.Records[0].ValueID != "rtb-1" {
+		t.Errorf("unexpected value id %s", aout.Records[0].ValueID)
+	}
+
+	_, calls, err = runEC2Action(t, actors.CreateRoute, `{"RouteTableId": "rtb-1", "DestinationCidrBlock": "0.0.0.0/0", "GatewayId": "igw-1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "CreateRoute:rtb-1:0.0.0.0/0:igw-1")
+
+	aout, calls, err = runEC2Action(t, actors.AssociateRouteTable, `{"RouteTableId": "rtb-1", "SubnetId": "subnet-1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "AssociateRouteTable:rtb-1:subnet-1")
+	if aout.Records[0].ValueID != "rtbassoc-1" {
+		t.Errorf("unexpected value id %s", aout.Records[0].ValueID)
+	}
+}
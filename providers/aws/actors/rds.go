@@ -25,6 +25,7 @@ package actors
 import (
 	"fmt"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/rds"
 	"github.com/develatio/nebulant-cli/base"
 	"github.com/develatio/nebulant-cli/util"
@@ -34,7 +35,11 @@ import (
 func FindDatabases(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(rds.DescribeDBInstancesInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -46,8 +51,34 @@ func FindDatabases(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+
 	svc := rds.New(ctx.AwsSess)
-	result, err := svc.DescribeDBInstances(awsinput)
+	if len(tags.Tags) <= 0 {
+		result, err := svc.DescribeDBInstances(awsinput)
+		if err != nil {
+			return nil, err
+		}
+		return base.NewActionOutput(ctx.Action, result, nil), nil
+	}
+
+	// rds has no tag filters, filter the results of
+	// every page here
+	result := new(rds.DescribeDBInstancesOutput)
+	err = svc.DescribeDBInstancesPages(awsinput, func(page *rds.DescribeDBInstancesOutput, lastPage bool) bool {
+		for _, instance := range page.DBInstances {
+			instanceTags := make(map[string]string, len(instance.TagList))
+			for _, tag := range instance.TagList {
+				instanceTags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+			if tags.match(instanceTags) {
+				result.DBInstances = append(result.DBInstances, instance)
+			}
+		}
+		return true
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -84,13 +115,15 @@ func FindOneDatabase(ctx *ActionContext) (*base.ActionOutput, error) {
 func CreateDatabase(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(rds.CreateDBInstanceInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
 		return nil, nil
 	}
 
+	awsinput.Tags = withDefaultRDSTags(ctx, awsinput.Tags)
+
 	svc := rds.New(ctx.AwsSess)
 	result, err := svc.CreateDBInstance(awsinput)
 	if err != nil {
@@ -105,7 +138,7 @@ func CreateDatabase(ctx *ActionContext) (*base.ActionOutput, error) {
 func DeleteDatabase(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(rds.DeleteDBInstanceInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -130,7 +163,7 @@ func DeleteDatabase(ctx *ActionContext) (*base.ActionOutput, error) {
 func CreateSnapshotDatabase(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(rds.CreateDBSnapshotInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -142,6 +175,8 @@ func CreateSnapshotDatabase(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
+	awsinput.Tags = withDefaultRDSTags(ctx, awsinput.Tags)
+
 	svc := rds.New(ctx.AwsSess)
 	awsout, err := svc.CreateDBSnapshot(awsinput)
 	if err != nil {
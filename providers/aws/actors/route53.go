@@ -0,0 +1,436 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/util"
+)
+
+// defaultRecordTTL is used for non alias records without TTL
+const defaultRecordTTL = 300
+
+// max ids per ListTagsForResources request
+const route53ListTagsBatchSize = 10
+
+type findHostedZonesParameters struct {
+	DNSName     *string
+	PrivateZone *bool
+}
+
+type findRecordsParameters struct {
+	HostedZoneId   *string
+	HostedZoneName *string
+	Name           *string
+	Type           *string
+	SetIdentifier  *string
+}
+
+func (p *findRecordsParameters) Validate() error {
+	if p.HostedZoneId == nil && p.HostedZoneName == nil {
+		return fmt.Errorf("HostedZoneId or HostedZoneName is required")
+	}
+	if p.Type != nil && p.Name == nil {
+		return fmt.Errorf("Type requires Name")
+	}
+	return nil
+}
+
+type recordSetParameters struct {
+	HostedZoneId   *string
+	HostedZoneName *string
+	Comment        *string
+	route53.ResourceRecordSet
+	// shorthand for ResourceRecords
+	Values []*string
+}
+
+func (p *recordSetParameters) Validate() error {
+	if p.HostedZoneId == nil && p.HostedZoneName == nil {
+		return fmt.Errorf("HostedZoneId or HostedZoneName is required")
+	}
+	return p.ResourceRecordSet.Validate()
+}
+
+// recordSetChangeOutput is the output of the record set actions
+type recordSetChangeOutput struct {
+	HostedZoneId      *string
+	ResourceRecordSet *route53.ResourceRecordSet
+	ChangeInfo        *route53.ChangeInfo
+}
+
+// dnsName normalizes a name as returned by route53 to compare it
+func dnsName(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	return strings.ReplaceAll(name, `\052`, "*")
+}
+
+// hostedZoneID returns zoneID or the id of the hosted zone named zoneName
+func hostedZoneID(svc route53iface.Route53API, zoneID *string, zoneName *string) (*string, error) {
+	if zoneID != nil {
+		return zoneID, nil
+	}
+	result, err := svc.ListHostedZonesByName(&route53.ListHostedZonesByNameInput{
+		DNSName: zoneName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var found []*route53.HostedZone
+	for _, z := range result.HostedZones {
+		if dnsName(aws.StringValue(z.Name)) == dnsName(*zoneName) {
+			found = append(found, z)
+		}
+	}
+	if len(found) > 1 {
+		return nil, fmt.Errorf("too many hosted zones named %s, use HostedZoneId", *zoneName)
+	}
+	if len(found) <= 0 {
+		return nil, fmt.Errorf("no hosted zone found")
+	}
+	return found[0].Id, nil
+}
+
+// findRecordSets returns the record sets of the zone matching name, type
+// and set identifier. All of them are optional.
+func findRecordSets(svc route53iface.Route53API, zoneID *string, name *string, rtype *string, setID *string) ([]*route53.ResourceRecordSet, error) {
+	found := []*route53.ResourceRecordSet{}
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    zoneID,
+		StartRecordName: name,
+		StartRecordType: rtype,
+	}
+	err := svc.ListResourceRecordSetsPages(input, func(page *route53.ListResourceRecordSetsOutput, lastPage bool) bool {
+		for _, rrs := range page.ResourceRecordSets {
+			if name != nil && dnsName(aws.StringValue(rrs.Name)) != dnsName(*name) {
+				// records are sorted by name, so there is nothing
+				// else to look for
+				return false
+			}
+			if rtype != nil && !strings.EqualFold(aws.StringValue(rrs.Type), *rtype) {
+				continue
+			}
+			if setID != nil && aws.StringValue(rrs.SetIdentifier) != *setID {
+				continue
+			}
+			found = append(found, rrs)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// route53TaggedZones returns the ids of the hosted zones that match
+// the tags. Route53 has no tag filters, the tags of each zone are
+// retrieved and compared here.
+func route53TaggedZones(svc route53iface.Route53API, zones []*route53.HostedZone, tags *findTagsParameters) (map[string]bool, error) {
+	matched := make(map[string]bool)
+	for start := 0; start < len(zones); start += route53ListTagsBatchSize {
+		end := start + route53ListTagsBatchSize
+		if end > len(zones) {
+			end = len(zones)
+		}
+		ids := make([]*string, 0, end-start)
+		for _, z := range zones[start:end] {
+			ids = append(ids, aws.String(strings.TrimPrefix(aws.StringValue(z.Id), "/hostedzone/")))
+		}
+		result, err := svc.ListTagsForResources(&route53.ListTagsForResourcesInput{
+			ResourceType: aws.String(route53.TagResourceTypeHostedzone),
+			ResourceIds:  ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, rts := range result.ResourceTagSets {
+			resourceTags := make(map[string]string, len(rts.Tags))
+			for _, tag := range rts.Tags {
+				resourceTags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+			if tags.match(resourceTags) {
+				matched[aws.StringValue(rts.ResourceId)] = true
+			}
+		}
+	}
+	return matched, nil
+}
+
+// FindHostedZones func
+func FindHostedZones(ctx *ActionContext) (*base.ActionOutput, error) {
+	params := new(findHostedZonesParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+
+	ctx.Logger.LogInfo("Looking for hosted zones...")
+	svc := ctx.NewRoute53Client()
+	zones := []*route53.HostedZone{}
+	err = svc.ListHostedZonesPages(&route53.ListHostedZonesInput{}, func(page *route53.ListHostedZonesOutput, lastPage bool) bool {
+		for _, z := range page.HostedZones {
+			if params.DNSName != nil && dnsName(aws.StringValue(z.Name)) != dnsName(*params.DNSName) {
+				continue
+			}
+			if params.PrivateZone != nil && z.Config != nil && aws.BoolValue(z.Config.PrivateZone) != *params.PrivateZone {
+				continue
+			}
+			zones = append(zones, z)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tags.Tags) > 0 && len(zones) > 0 {
+		matched, err := route53TaggedZones(svc, zones, tags)
+		if err != nil {
+			return nil, err
+		}
+		tagged := []*route53.HostedZone{}
+		for _, z := range zones {
+			if matched[strings.TrimPrefix(aws.StringValue(z.Id), "/hostedzone/")] {
+				tagged = append(tagged, z)
+			}
+		}
+		zones = tagged
+	}
+
+	aout := base.NewActionOutput(ctx.Action, &route53.ListHostedZonesOutput{HostedZones: zones}, nil)
+	return aout, nil
+}
+
+// FindOneHostedZone func
+func FindOneHostedZone(ctx *ActionContext) (*base.ActionOutput, error) {
+	aout, err := FindHostedZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+	if len(aout.Records) <= 0 {
+		return nil, fmt.Errorf("no hosted zone found")
+	}
+	raw := aout.Records[0].Value.(*route53.ListHostedZonesOutput)
+	found := len(raw.HostedZones)
+	if found > 1 {
+		return nil, fmt.Errorf("too many results")
+	}
+	if found <= 0 {
+		return nil, fmt.Errorf("no hosted zone found")
+	}
+	aout = base.NewActionOutput(ctx.Action, raw.HostedZones[0], raw.HostedZones[0].Id)
+	return aout, nil
+}
+
+// FindRecords func. Record sets are not taggable resources, so the
+// tags shorthand of the other find actions is not accepted here; use
+// the tags of the hosted zone instead.
+func FindRecords(ctx *ActionContext) (*base.ActionOutput, error) {
+	params := new(findRecordsParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewRoute53Client()
+	zoneID, err := hostedZoneID(svc, params.HostedZoneId, params.HostedZoneName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Logger.LogInfo("Looking for records in hosted zone " + *zoneID)
+	records, err := findRecordSets(svc, zoneID, params.Name, params.Type, params.SetIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, &route53.ListResourceRecordSetsOutput{ResourceRecordSets: records}, nil)
+	return aout, nil
+}
+
+// FindOneRecord func
+func FindOneRecord(ctx *ActionContext) (*base.ActionOutput, error) {
+	aout, err := FindRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+	if len(aout.Records) <= 0 {
+		return nil, fmt.Errorf("no record found")
+	}
+	raw := aout.Records[0].Value.(*route53.ListResourceRecordSetsOutput)
+	found := len(raw.ResourceRecordSets)
+	if found > 1 {
+		return nil, fmt.Errorf("too many results")
+	}
+	if found <= 0 {
+		return nil, fmt.Errorf("no record found")
+	}
+	aout = base.NewActionOutput(ctx.Action, raw.ResourceRecordSets[0], raw.ResourceRecordSets[0].Name)
+	return aout, nil
+}
+
+func changeRecordSet(ctx *ActionContext, changeAction string) (*base.ActionOutput, error) {
+	params := new(recordSetParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+
+	rrs := &params.ResourceRecordSet
+	for _, v := range params.Values {
+		rrs.ResourceRecords = append(rrs.ResourceRecords, &route53.ResourceRecord{Value: v})
+	}
+
+	svc := ctx.NewRoute53Client()
+	zoneID, err := hostedZoneID(svc, params.HostedZoneId, params.HostedZoneName)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case changeAction == route53.ChangeActionDelete && len(rrs.ResourceRecords) <= 0 && rrs.AliasTarget == nil:
+		// route53 needs the exact record to delete it, use
+		// the current one
+		current, err := findRecordSets(svc, zoneID, rrs.Name, rrs.Type, rrs.SetIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		if len(current) > 1 {
+			return nil, fmt.Errorf("too many records %s %s, use SetIdentifier", *rrs.Name, *rrs.Type)
+		}
+		if len(current) <= 0 {
+			return nil, fmt.Errorf("no record %s %s found", *rrs.Name, *rrs.Type)
+		}
+		rrs = current[0]
+	case changeAction != route53.ChangeActionDelete && rrs.AliasTarget == nil && rrs.TTL == nil:
+		rrs.TTL = aws.Int64(defaultRecordTTL)
+	}
+
+	ctx.Logger.LogInfo(fmt.Sprintf("%s record %s %s in hosted zone %s", changeAction, *rrs.Name, *rrs.Type, *zoneID))
+	result, err := svc.ChangeResourceRecordSets(&route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: zoneID,
+		ChangeBatch: &route53.ChangeBatch{
+			Comment: params.Comment,
+			Changes: []*route53.Change{
+				{
+					Action:            aws.String(changeAction),
+					ResourceRecordSet: rrs,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if internalparams.Waiters != nil {
+		waitinput := &route53.GetChangeInput{
+			Id: result.ChangeInfo.Id,
+		}
+		for _, waitername := range internalparams.Waiters {
+			switch waitername {
+			case "WaitUntilResourceRecordSetsChanged":
+				ctx.Logger.LogInfo("Waiting for change to be INSYNC...")
+				err = svc.WaitUntilResourceRecordSetsChanged(waitinput)
+				if err != nil {
+					return nil, err
+				}
+				result.ChangeInfo.Status = aws.String(route53.ChangeStatusInsync)
+			default:
+				return nil, fmt.Errorf("unkown waiter")
+			}
+		}
+	}
+
+	out := &recordSetChangeOutput{
+		HostedZoneId:      zoneID,
+		ResourceRecordSet: rrs,
+		ChangeInfo:        result.ChangeInfo,
+	}
+	aout := base.NewActionOutput(ctx.Action, out, rrs.Name)
+	return aout, nil
+}
+
+// CreateRecord func
+func CreateRecord(ctx *ActionContext) (*base.ActionOutput, error) {
+	return changeRecordSet(ctx, route53.ChangeActionCreate)
+}
+
+// UpsertRecord func
+func UpsertRecord(ctx *ActionContext) (*base.ActionOutput, error) {
+	return changeRecordSet(ctx, route53.ChangeActionUpsert)
+}
+
+// DeleteRecord func
+func DeleteRecord(ctx *ActionContext) (*base.ActionOutput, error) {
+	return changeRecordSet(ctx, route53.ChangeActionDelete)
+}
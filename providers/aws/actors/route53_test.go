@@ -0,0 +1,220 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/develatio/nebulant-cli/providers/aws/actors"
+)
+
+type fakeRoute53Client struct {
+	route53iface.Route53API
+}
+
+var fakeHostedZones = []*route53.HostedZone{
+	{Id: aws.String("/hostedzone/Z1"), Name: aws.String("example.com."), Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(false)}},
+	{Id: aws.String("/hostedzone/Z2"), Name: aws.String("example.com."), Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(true)}},
+	{Id: aws.String("/hostedzone/Z3"), Name: aws.String("example.org."), Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(false)}},
+}
+
+// sorted like route53 does
+var fakeRecordSets = []*route53.ResourceRecordSet{
+	{Name: aws.String("example.org."), Type: aws.String("NS"), TTL: aws.Int64(172800), ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("ns-1.awsdns.com.")}}},
+	{Name: aws.String("api.example.org."), Type: aws.String("A"), TTL: aws.Int64(60), ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("192.0.2.10")}}},
+	{Name: aws.String("api.example.org."), Type: aws.String("AAAA"), TTL: aws.Int64(60), ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("2001:db8::10")}}},
+	{Name: aws.String("\\052.example.org."), Type: aws.String("CNAME"), TTL: aws.Int64(300), ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("example.org")}}},
+	{Name: aws.String("www.example.org."), Type: aws.String("A"), TTL: aws.Int64(300), ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("192.0.2.20")}}},
+}
+
+func (f *fakeRoute53Client) ListHostedZonesPages(input *route53.ListHostedZonesInput, fn func(*route53.ListHostedZonesOutput, bool) bool) error {
+	fakeEC2Calls = append(fakeEC2Calls, "ListHostedZones")
+	// two pages
+	if fn(&route53.ListHostedZonesOutput{HostedZones: fakeHostedZones[:2]}, false) {
+		fn(&route53.ListHostedZonesOutput{HostedZones: fakeHostedZones[2:]}, true)
+	}
+	return nil
+}
+
+// only Z2 is tagged as production
+func (f *fakeRoute53Client) ListTagsForResources(input *route53.ListTagsForResourcesInput) (*route53.ListTagsForResourcesOutput, error) {
+	call := "ListTagsForResources:" + *input.ResourceType
+	out := &route53.ListTagsForResourcesOutput{}
+	for _, id := range input.ResourceIds {
+		call += ":" + *id
+		env := "staging"
+		if *id == "Z2" {
+			env = "production"
+		}
+		out.ResourceTagSets = append(out.ResourceTagSets, &route53.ResourceTagSet{
+			ResourceId:   id,
+			ResourceType: input.ResourceType,
+			Tags:         []*route53.Tag{{Key: aws.String("env"), Value: aws.String(env)}},
+		})
+	}
+	fakeEC2Calls = append(fakeEC2Calls, call)
+	return out, nil
+}
+
+func (f *fakeRoute53Client) ListHostedZonesByName(input *route53.ListHostedZonesByNameInput) (*route53.ListHostedZonesByNameOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "ListHostedZonesByName:"+*input.DNSName)
+	out := &route53.ListHostedZonesByNameOutput{}
+	for i, z := range fakeHostedZones {
+		if *z.Name >= *input.DNSName {
+			out.HostedZones = fakeHostedZones[i:]
+			break
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRoute53Client) ListResourceRecordSetsPages(input *route53.ListResourceRecordSetsInput, fn func(*route53.ListResourceRecordSetsOutput, bool) bool) error {
+	fakeEC2Calls = append(fakeEC2Calls, "ListResourceRecordSets:"+*input.HostedZoneId)
+	start := 0
+	if input.StartRecordName != nil {
+		for start < len(fakeRecordSets) && strings.TrimSuffix(*fakeRecordSets[start].Name, ".") != strings.TrimSuffix(*input.StartRecordName, ".") {
+			start++
+		}
+	}
+	for i := start; i < len(fakeRecordSets); i++ {
+		if !fn(&route53.ListResourceRecordSetsOutput{ResourceRecordSets: fakeRecordSets[i : i+1]}, i == len(fakeRecordSets)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeRoute53Client) ChangeResourceRecordSets(input *route53.ChangeResourceRecordSetsInput) (*route53.ChangeResourceRecordSetsOutput, error) {
+	change := input.ChangeBatch.Changes[0]
+	rrs := change.ResourceRecordSet
+	call := "ChangeResourceRecordSets:" + *input.HostedZoneId + ":" + *change.Action + ":" + *rrs.Name + ":" + *rrs.Type
+	if rrs.TTL != nil {
+		call += ":" + strconv.FormatInt(*rrs.TTL, 10)
+	}
+	for _, r := range rrs.ResourceRecords {
+		call += ":" + *r.Value
+	}
+	fakeEC2Calls = append(fakeEC2Calls, call)
+	return &route53.ChangeResourceRecordSetsOutput{
+		ChangeInfo: &route53.ChangeInfo{Id: aws.String("/change/C1"), Status: aws.String(route53.ChangeStatusPending)},
+	}, nil
+}
+
+func (f *fakeRoute53Client) WaitUntilResourceRecordSetsChanged(input *route53.GetChangeInput) error {
+	fakeEC2Calls = append(fakeEC2Calls, "WaitUntilResourceRecordSetsChanged:"+*input.Id)
+	return nil
+}
+
+func TestFindHostedZones(t *testing.T) {
+	aout, calls, err := runEC2Action(t, actors.FindHostedZones, `{"DNSName": "Example.com"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "ListHostedZones")
+	if zones := aout.Records[0].Value.(*route53.ListHostedZonesOutput).HostedZones; len(zones) != 2 {
+		t.Errorf("expected 2 zones, got %v", zones)
+	}
+
+	aout, _, err = runEC2Action(t, actors.FindOneHostedZone, `{"DNSName": "example.com.", "PrivateZone": true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aout.Records[0].ValueID != "/hostedzone/Z2" {
+		t.Errorf("unexpected zone %v", aout.Records[0].ValueID)
+	}
+
+	if _, _, err = runEC2Action(t, actors.FindOneHostedZone, `{"DNSName": "example.com"}`); err == nil {
+		t.Error("findone with two matching zones should fail")
+	}
+
+	aout, calls, err = runEC2Action(t, actors.FindOneHostedZone, `{"DNSName": "example.com", "tags": {"env": "production"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "ListHostedZones", "ListTagsForResources:hostedzone:Z1:Z2")
+	if aout.Records[0].ValueID != "/hostedzone/Z2" {
+		t.Errorf("unexpected zone %v", aout.Records[0].ValueID)
+	}
+}
+
+func TestFindRecords(t *testing.T) {
+	aout, calls, err := runEC2Action(t, actors.FindRecords, `{"HostedZoneName": "example.org", "Name": "api.example.org."}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "ListHostedZonesByName:example.org", "ListResourceRecordSets:/hostedzone/Z3")
+	if records := aout.Records[0].Value.(*route53.ListResourceRecordSetsOutput).ResourceRecordSets; len(records) != 2 {
+		t.Errorf("expected 2 records, got %v", records)
+	}
+
+	aout, _, err = runEC2Action(t, actors.FindOneRecord, `{"HostedZoneId": "Z3", "Name": "api.example.org.", "Type": "AAAA"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rrs := aout.Records[0].Value.(*route53.ResourceRecordSet); *rrs.ResourceRecords[0].Value != "2001:db8::10" {
+		t.Errorf("unexpected record %v", rrs)
+	}
+
+	if _, _, err = runEC2Action(t, actors.FindRecords, `{"HostedZoneName": "example.com"}`); err == nil {
+		t.Error("ambiguous hosted zone name should fail")
+	}
+	if _, _, err = runEC2Action(t, actors.FindRecords, `{"Name": "api.example.org"}`); err == nil {
+		t.Error("missing hosted zone should fail")
+	}
+}
+
+func TestChangeRecords(t *testing.T) {
+	aout, calls, err := runEC2Action(t, actors.UpsertRecord, `{"HostedZoneId": "Z3", "Name": "app.example.org", "Type": "A", "Values": ["192.0.2.30"], "_waiters": ["WaitUntilResourceRecordSetsChanged"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "ChangeResourceRecordSets:Z3:UPSERT:app.example.org:A:300:192.0.2.30", "WaitUntilResourceRecordSetsChanged:/change/C1")
+	if aout.Records[0].ValueID != "app.example.org" {
+		t.Errorf("unexpected value id %v", aout.Records[0].ValueID)
+	}
+
+	_, calls, err = runEC2Action(t, actors.CreateRecord, `{"HostedZoneName": "example.org", "Name": "cdn.example.org", "Type": "A", "AliasTarget": {"DNSName": "d1.cloudfront.net", "HostedZoneId": "Z2FDTNDATAQYW2", "EvaluateTargetHealth": false}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "ListHostedZonesByName:example.org", "ChangeResourceRecordSets:/hostedzone/Z3:CREATE:cdn.example.org:A")
+
+	// delete without values deletes the current record
+	_, calls, err = runEC2Action(t, actors.DeleteRecord, `{"HostedZoneId": "Z3", "Name": "www.example.org", "Type": "A"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "ListResourceRecordSets:Z3", "ChangeResourceRecordSets:Z3:DELETE:www.example.org.:A:300:192.0.2.20")
+
+	if _, _, err = runEC2Action(t, actors.DeleteRecord, `{"HostedZoneId": "Z3", "Name": "missing.example.org", "Type": "A"}`); err == nil {
+		t.Error("deleting a missing record should fail")
+	}
+	if _, _, err = runEC2Action(t, actors.CreateRecord, `{"HostedZoneId": "Z3", "Type": "A"}`); err == nil {
+		t.Error("record without name should fail")
+	}
+}
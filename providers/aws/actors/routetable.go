@@ -0,0 +1,187 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/util"
+)
+
+// CreateRouteTable func
+func CreateRouteTable(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.CreateRouteTableInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	awsinput.TagSpecifications = withDefaultTags(ctx, ec2.ResourceTypeRouteTable, awsinput.TagSpecifications)
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.CreateRouteTable(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result.RouteTable, result.RouteTable.RouteTableId)
+	return aout, nil
+}
+
+// AssociateRouteTable func
+func AssociateRouteTable(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.AssociateRouteTableInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.AssociateRouteTable(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, result.AssociationId)
+	return aout, nil
+}
+
+// DisassociateRouteTable func
+func DisassociateRouteTable(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.DisassociateRouteTableInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.DisassociateRouteTable(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// DeleteRouteTable func
+func DeleteRouteTable(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.DeleteRouteTableInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.DeleteRouteTable(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// CreateRoute func
+func CreateRoute(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.CreateRouteInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.CreateRoute(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// DeleteRoute func
+func DeleteRoute(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.DeleteRouteInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.DeleteRoute(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
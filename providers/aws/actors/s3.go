@@ -0,0 +1,41 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/providers/objectstore"
+)
+
+// s3Action adapts a shared object store action to the aws ActionFunc
+func s3Action(f objectstore.ActionFunc) ActionFunc {
+	return func(ctx *ActionContext) (*base.ActionOutput, error) {
+		return f(&objectstore.Context{
+			Rehearsal: ctx.Rehearsal,
+			Action:    ctx.Action,
+			Store:     ctx.Store,
+			Logger:    ctx.Logger,
+			NewClient: ctx.NewS3Client,
+		})
+	}
+}
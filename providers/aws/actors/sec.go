@@ -23,18 +23,24 @@
 package actors
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
 	"github.com/develatio/nebulant-cli/util"
 )
 
 // FindSecurityGroups func
 func FindSecurityGroups(ctx *ActionContext) (*base.ActionOutput, error) {
 	awsinput := new(ec2.DescribeSecurityGroupsInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -44,6 +50,11 @@ func FindSecurityGroups(ctx *ActionContext) (*base.ActionOutput, error) {
 	region := ctx.AwsSess.Config.Region
 	ctx.Logger.LogInfo("Looking for seg in region " + *region)
 
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+	awsinput.Filters = append(awsinput.Filters, tags.ec2Filters()...)
+
 	svc := ctx.NewEC2Client()
 	result, err := svc.DescribeSecurityGroups(awsinput)
 	if err != nil {
@@ -91,11 +102,165 @@ func FindOneSecurityGroup(ctx *ActionContext) (*base.ActionOutput, error) {
 	return aout, nil
 }
 
+// CreateSecurityGroup func
+func CreateSecurityGroup(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(ec2.CreateSecurityGroupInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	awsinput.TagSpecifications = withDefaultTags(ctx, ec2.ResourceTypeSecurityGroup, awsinput.TagSpecifications)
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.CreateSecurityGroup(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	if internalparams.Waiters != nil {
+		waitinput := &ec2.DescribeSecurityGroupsInput{
+			GroupIds: []*string{result.GroupId},
+		}
+		for _, waitername := range internalparams.Waiters {
+			switch waitername {
+			case "WaitUntilSecurityGroupExists":
+				ctx.Logger.LogInfo("Waiting for security group to exist...")
+				err = svc.WaitUntilSecurityGroupExists(waitinput)
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("unkown waiter")
+			}
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, result.GroupId)
+	return aout, nil
+}
+
+// AuthorizeSecurityGroupIngress func
+func AuthorizeSecurityGroupIngress(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.AuthorizeSecurityGroupIngressInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.AuthorizeSecurityGroupIngress(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// AuthorizeSecurityGroupEgress func
+func AuthorizeSecurityGroupEgress(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.AuthorizeSecurityGroupEgressInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.AuthorizeSecurityGroupEgress(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// RevokeSecurityGroupIngress func
+func RevokeSecurityGroupIngress(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.RevokeSecurityGroupIngressInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.RevokeSecurityGroupIngress(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// RevokeSecurityGroupEgress func
+func RevokeSecurityGroupEgress(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.RevokeSecurityGroupEgressInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.RevokeSecurityGroupEgress(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
 // DeleteSecurityGroup func
 func DeleteSecurityGroup(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.DeleteSecurityGroupInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -0,0 +1,174 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/util"
+)
+
+// FindSnapshots func. Without ids or owners only the snapshots of
+// the account are looked for, not every public snapshot
+func FindSnapshots(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.DescribeSnapshotsInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(awsinput.SnapshotIds) <= 0 && len(awsinput.OwnerIds) <= 0 && len(awsinput.RestorableByUserIds) <= 0 {
+		awsinput.OwnerIds = []*string{aws.String("self")}
+	}
+
+	ctx.Logger.LogInfo("Looking for snapshots in region " + aws.StringValue(ctx.AwsSess.Config.Region))
+
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+	awsinput.Filters = append(awsinput.Filters, tags.ec2Filters()...)
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.DescribeSnapshots(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
+
+// FindOneSnapshot func
+func FindOneSnapshot(ctx *ActionContext) (*base.ActionOutput, error) {
+	aout, err := FindSnapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+	raw := aout.Records[0].Value.(*ec2.DescribeSnapshotsOutput)
+	found := len(raw.Snapshots)
+	if found > 1 {
+		return nil, fmt.Errorf("too many results")
+	}
+	if found <= 0 {
+		return nil, fmt.Errorf("no snapshot found")
+	}
+
+	aout = base.NewActionOutput(ctx.Action, raw.Snapshots[0], raw.Snapshots[0].SnapshotId)
+	return aout, nil
+}
+
+// CreateSnapshot func. Creates a snapshot of an EBS volume
+func CreateSnapshot(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(ec2.CreateSnapshotInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	awsinput.TagSpecifications = withDefaultTags(ctx, ec2.ResourceTypeSnapshot, awsinput.TagSpecifications)
+
+	svc := ctx.NewEC2Client()
+	snapshot, err := svc.CreateSnapshot(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	if internalparams.Waiters != nil {
+		waitinput := &ec2.DescribeSnapshotsInput{
+			SnapshotIds: []*string{snapshot.SnapshotId},
+		}
+		for _, waitername := range internalparams.Waiters {
+			switch waitername {
+			case "WaitUntilSnapshotCompleted":
+				ctx.Logger.LogInfo("Waiting for snapshot to be completed...")
+				err = svc.WaitUntilSnapshotCompleted(waitinput)
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("unkown waiter")
+			}
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, snapshot, snapshot.SnapshotId)
+	return aout, nil
+}
+
+// DeleteSnapshot func
+func DeleteSnapshot(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	awsinput := new(ec2.DeleteSnapshotInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.DeleteSnapshot(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
@@ -0,0 +1,310 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/util"
+)
+
+const (
+	runShellScriptDocument      = "AWS-RunShellScript"
+	runPowerShellScriptDocument = "AWS-RunPowerShellScript"
+	// the status of the command is polled with a backoff from
+	// runCommandMinPoll to runCommandMaxPoll
+	runCommandMinPoll = 500 * time.Millisecond
+	runCommandMaxPoll = 5 * time.Second
+	// SSM runs the commands for up to one hour by default
+	runCommandDefaultTimeout = 3600
+	// the polling gives up runCommandGrace after the execution
+	// timeout, leaving room for the delivery of the command
+	runCommandGrace = 2 * time.Minute
+)
+
+var envVarNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// runCommandParameters uses the same names than the parameters of
+// run_script, so the generic provider can pass them as they are
+type runCommandParameters struct {
+	// instance id
+	Target     *string           `json:"target" validate:"required"`
+	Command    *string           `json:"command"`
+	ScriptText *string           `json:"script"`
+	ScriptPath *string           `json:"scriptPath"`
+	Vars       map[string]string `json:"vars"`
+	// AWS-RunShellScript by default, AWS-RunPowerShellScript for windows
+	Document         *string `json:"document" validate:"omitempty,oneof=AWS-RunShellScript AWS-RunPowerShellScript"`
+	WorkingDirectory *string `json:"working_directory"`
+	// execution timeout in seconds, one hour by default
+	Timeout int64   `json:"timeout" validate:"gte=0"`
+	Comment *string `json:"comment"`
+}
+
+func (p *runCommandParameters) Validate() error {
+	n := 0
+	for _, s := range []*string{p.Command, p.ScriptText, p.ScriptPath} {
+		if s != nil {
+			n++
+		}
+	}
+	if n <= 0 {
+		return fmt.Errorf("no script provided")
+	}
+	if n > 1 {
+		return fmt.Errorf("command, script and scriptPath are mutually exclusive")
+	}
+	for key := range p.Vars {
+		if !envVarNameRe.MatchString(key) {
+			return fmt.Errorf("invalid var name %s", key)
+		}
+	}
+	return nil
+}
+
+// runCommandOutput has the same shape than the output of run_script
+type runCommandOutput struct {
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	Error      string `json:"error"`
+	ExitCode   string `json:"exit_code"`
+	CommandID  string `json:"command_id"`
+	InstanceID string `json:"instance_id"`
+	Status     string `json:"status"`
+}
+
+// CommandOutput func
+func (r *runCommandOutput) CommandOutput() (string, string) {
+	return r.Stdout, r.Stderr
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+func powerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// commands returns the lines to send to the document
+func (p *runCommandParameters) commands(document string) ([]*string, error) {
+	var lines []string
+	keys := make([]string, 0, len(p.Vars))
+	for key := range p.Vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if document == runPowerShellScriptDocument {
+			lines = append(lines, fmt.Sprintf("$env:%s = %s", key, powerShellQuote(p.Vars[key])))
+		} else {
+			lines = append(lines, fmt.Sprintf("export %s=%s", key, shellQuote(p.Vars[key])))
+		}
+	}
+
+	script := ""
+	switch {
+	case p.Command != nil:
+		lines = append(lines, *p.Command)
+		return aws.StringSlice(lines), nil
+	case p.ScriptPath != nil:
+		data, err := os.ReadFile(*p.ScriptPath) // #nosec G304 -- script to run is chosen by the blueprint
+		if err != nil {
+			return nil, err
+		}
+		script = string(data)
+	default:
+		script = *p.ScriptText
+	}
+
+	if document == runPowerShellScriptDocument {
+		lines = append(lines, script)
+		return aws.StringSlice(lines), nil
+	}
+	// write the script to a file and run it, as the ssh target does,
+	// so scripts with a shebang work
+	if !strings.HasSuffix(script, "\n") {
+		script = script + "\n"
+	}
+	lines = append(lines,
+		"NEBULANT_SCRIPT=$(mktemp)",
+		"cat > \"$NEBULANT_SCRIPT\" <<'NEBULANT_SCRIPT_EOF'\n"+script+"NEBULANT_SCRIPT_EOF",
+		"chmod 700 \"$NEBULANT_SCRIPT\"",
+		"\"$NEBULANT_SCRIPT\"",
+		"NEBULANT_RC=$?",
+		"rm -f \"$NEBULANT_SCRIPT\"",
+		"exit $NEBULANT_RC",
+	)
+	return aws.StringSlice(lines), nil
+}
+
+// RunCommand func. Runs a command or script in an instance through SSM
+// run command. Stdout and stderr are truncated by SSM to 24000 and 8000
+// chars.
+func RunCommand(ctx *ActionContext) (*base.ActionOutput, error) {
+	params := new(runCommandParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(*params.Target) == "" {
+		return nil, fmt.Errorf("the target instance id is empty. Please provide one")
+	}
+
+	document := runShellScriptDocument
+	if params.Document != nil {
+		document = *params.Document
+	}
+	commands, err := params.commands(document)
+	if err != nil {
+		return nil, err
+	}
+	docparams := map[string][]*string{
+		"commands": commands,
+	}
+	if params.WorkingDirectory != nil {
+		docparams["workingDirectory"] = []*string{params.WorkingDirectory}
+	}
+	if params.Timeout > 0 {
+		docparams["executionTimeout"] = []*string{aws.String(strconv.FormatInt(params.Timeout, 10))}
+	}
+
+	svc := ctx.NewSSMClient()
+	ctx.Logger.LogInfo(fmt.Sprintf("Sending command to %s...", *params.Target))
+	sent, err := svc.SendCommand(&ssm.SendCommandInput{
+		DocumentName: aws.String(document),
+		InstanceIds:  []*string{params.Target},
+		Parameters:   docparams,
+		Comment:      params.Comment,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &runCommandOutput{
+		CommandID:  aws.StringValue(sent.Command.CommandId),
+		InstanceID: *params.Target,
+	}
+	prefix := *params.Target + ":ssm> "
+	input := &ssm.GetCommandInvocationInput{
+		CommandId:  sent.Command.CommandId,
+		InstanceId: params.Target,
+	}
+	timeout := time.Duration(runCommandDefaultTimeout) * time.Second
+	if params.Timeout > 0 {
+		timeout = time.Duration(params.Timeout) * time.Second
+	}
+	pollctx, cancel := context.WithTimeout(context.Background(), timeout+runCommandGrace)
+	defer cancel()
+	wait := runCommandMinPoll
+	var inv *ssm.GetCommandInvocationOutput
+	for {
+		inv, err = svc.GetCommandInvocationWithContext(pollctx, input)
+		if pollctx.Err() != nil {
+			// the instance is not reporting, stop the command
+			// in case it is still delivered later
+			_, _ = svc.CancelCommand(&ssm.CancelCommandInput{
+				CommandId:   sent.Command.CommandId,
+				InstanceIds: []*string{params.Target},
+			})
+			return nil, fmt.Errorf("command %s did not finish after %s", result.CommandID, timeout+runCommandGrace)
+		}
+		if err != nil {
+			// the invocation is not available right after send
+			if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != ssm.ErrCodeInvocationDoesNotExist {
+				return nil, err
+			}
+		} else {
+			if status := aws.StringValue(inv.Status); status != result.Status {
+				result.Status = status
+				ctx.Logger.LogInfo(fmt.Sprintf("Command %s: %s", result.CommandID, status))
+			}
+			// log the output as it grows
+			stdout := aws.StringValue(inv.StandardOutputContent)
+			if strings.HasPrefix(stdout, result.Stdout) && len(stdout) > len(result.Stdout) {
+				ctx.Logger.ByteLogInfo([]byte(prefix + stdout[len(result.Stdout):]))
+			}
+			result.Stdout = stdout
+			stderr := aws.StringValue(inv.StandardErrorContent)
+			if strings.HasPrefix(stderr, result.Stderr) && len(stderr) > len(result.Stderr) {
+				ctx.Logger.ByteLogErr([]byte(prefix + stderr[len(result.Stderr):]))
+			}
+			result.Stderr = stderr
+			if runCommandFinished(result.Status) {
+				break
+			}
+		}
+		select {
+		case <-pollctx.Done():
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > runCommandMaxPoll {
+			wait = runCommandMaxPoll
+		}
+	}
+
+	// It is a string, as in run_script, because the conditional
+	// evaluation of this result is defined from the graphical app
+	// and in it the value to compare will always be a string.
+	result.ExitCode = strconv.FormatInt(aws.Int64Value(inv.ResponseCode), 10)
+	if result.Status != ssm.CommandInvocationStatusSuccess {
+		if aws.Int64Value(inv.ResponseCode) > 0 {
+			err = fmt.Errorf("Exit status != 0 (%s)", result.ExitCode)
+		} else {
+			err = fmt.Errorf("command %s %s: %s", result.CommandID, result.Status, aws.StringValue(inv.StatusDetails))
+		}
+		result.Error = err.Error()
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result, sent.Command.CommandId)
+	return aout, err
+}
+
+func runCommandFinished(status string) bool {
+	switch status {
+	case ssm.CommandInvocationStatusSuccess,
+		ssm.CommandInvocationStatusCancelled,
+		ssm.CommandInvocationStatusTimedOut,
+		ssm.CommandInvocationStatusFailed:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/providers/aws/actors"
+)
+
+type fakeSSMClient struct {
+	ssmiface.SSMAPI
+}
+
+// fakeSSMCommands are the commands received by SendCommand
+var fakeSSMCommands []string
+
+// fakeSSMPolls are the results returned by each GetCommandInvocation
+var fakeSSMPolls []*ssm.GetCommandInvocationOutput
+
+func (f *fakeSSMClient) SendCommand(input *ssm.SendCommandInput) (*ssm.SendCommandOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "SendCommand:"+*input.DocumentName+":"+*input.InstanceIds[0])
+	fakeSSMCommands = aws.StringValueSlice(input.Parameters["commands"])
+	return &ssm.SendCommandOutput{Command: &ssm.Command{CommandId: aws.String("cmd-1")}}, nil
+}
+
+func (f *fakeSSMClient) GetCommandInvocation(input *ssm.GetCommandInvocationInput) (*ssm.GetCommandInvocationOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "GetCommandInvocation:"+*input.CommandId)
+	poll := fakeSSMPolls[0]
+	fakeSSMPolls = fakeSSMPolls[1:]
+	if poll == nil {
+		return nil, awserr.New(ssm.ErrCodeInvocationDoesNotExist, "not yet", nil)
+	}
+	return poll, nil
+}
+
+// fakeSSMDeadline is the deadline of the last GetCommandInvocation
+var fakeSSMDeadline time.Time
+
+func (f *fakeSSMClient) GetCommandInvocationWithContext(ctx context.Context, input *ssm.GetCommandInvocationInput, opts ...request.Option) (*ssm.GetCommandInvocationOutput, error) {
+	fakeSSMDeadline, _ = ctx.Deadline()
+	return f.GetCommandInvocation(input)
+}
+
+func TestRunCommand(t *testing.T) {
+	fakeSSMPolls = []*ssm.GetCommandInvocationOutput{
+		nil,
+		{Status: aws.String("InProgress"), StandardOutputContent: aws.String("hel"), ResponseCode: aws.Int64(-1)},
+		{Status: aws.String("Success"), StandardOutputContent: aws.String("hello\n"), ResponseCode: aws.Int64(0)},
+	}
+	aout, calls, err := runEC2Action(t, actors.RunCommand, `{"target": "i-1", "target_type": "ssm", "username": "ignored", "script": "#!/bin/bash\necho hello", "vars": {"NAME": "it's"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "SendCommand:AWS-RunShellScript:i-1", "GetCommandInvocation:cmd-1", "GetCommandInvocation:cmd-1", "GetCommandInvocation:cmd-1")
+	if fakeSSMCommands[0] != `export NAME='it'"'"'s'` || !strings.Contains(fakeSSMCommands[2], "\n#!/bin/bash\necho hello\nNEBULANT_SCRIPT_EOF") {
+		t.Errorf("unexpected commands %q", fakeSSMCommands)
+	}
+	out := aout.Records[0].Value
+	cmdout, ok := out.(base.ICommandOutput)
+	if !ok {
+		t.Fatalf("unexpected output %T", out)
+	}
+	if stdout, _ := cmdout.CommandOutput(); stdout != "hello\n" {
+		t.Errorf("unexpected stdout %q", stdout)
+	}
+	if aout.Records[0].ValueID != "cmd-1" {
+		t.Errorf("unexpected value id %v", aout.Records[0].ValueID)
+	}
+}
+
+func TestRunCommandFailure(t *testing.T) {
+	fakeSSMPolls = []*ssm.GetCommandInvocationOutput{
+		{Status: aws.String("Failed"), StandardErrorContent: aws.String("boom"), ResponseCode: aws.Int64(3)},
+	}
+	aout, _, err := runEC2Action(t, actors.RunCommand, `{"target": "i-1", "command": "exit 3", "document": "AWS-RunPowerShellScript"}`)
+	if err == nil || err.Error() != "Exit status != 0 (3)" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if fakeSSMCommands[0] != "exit 3" {
+		t.Errorf("unexpected commands %q", fakeSSMCommands)
+	}
+	if _, stderr := aout.Records[0].Value.(base.ICommandOutput).CommandOutput(); stderr != "boom" {
+		t.Errorf("unexpected stderr %q", stderr)
+	}
+
+	// the polling ends a while after the execution timeout
+	fakeSSMPolls = []*ssm.GetCommandInvocationOutput{
+		{Status: aws.String("Success"), ResponseCode: aws.Int64(0)},
+	}
+	if _, _, err = runEC2Action(t, actors.RunCommand, `{"target": "i-1", "command": "ls", "timeout": 60}`); err != nil {
+		t.Fatal(err)
+	}
+	if left := time.Until(fakeSSMDeadline); left <= time.Minute || left > 5*time.Minute {
+		t.Errorf("unexpected polling deadline in %s", left)
+	}
+
+	if _, _, err = runEC2Action(t, actors.RunCommand, `{"target": "i-1"}`); err == nil {
+		t.Error("no script should fail")
+	}
+	if _, _, err = runEC2Action(t, actors.RunCommand, `{"target": "i-1", "command": "ls", "vars": {"A-B": "x"}}`); err == nil {
+		t.Error("invalid var name should fail")
+	}
+}
@@ -23,17 +23,23 @@
 package actors
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
 	"github.com/develatio/nebulant-cli/util"
 )
 
 // FindSubnets func
 func FindSubnets(ctx *ActionContext) (*base.ActionOutput, error) {
 	awsinput := new(ec2.DescribeSubnetsInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -43,6 +49,11 @@ func FindSubnets(ctx *ActionContext) (*base.ActionOutput, error) {
 	region := ctx.AwsSess.Config.Region
 	ctx.Logger.LogInfo("Looking for subnets in region " + *region)
 
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+	awsinput.Filters = append(awsinput.Filters, tags.ec2Filters()...)
+
 	svc := ctx.NewEC2Client()
 	result, err := svc.DescribeSubnets(awsinput)
 	if err != nil {
@@ -77,11 +88,61 @@ func FindOneSubnet(ctx *ActionContext) (*base.ActionOutput, error) {
 	return aout, nil
 }
 
+// CreateSubnet func
+func CreateSubnet(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(ec2.CreateSubnetInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	awsinput.TagSpecifications = withDefaultTags(ctx, ec2.ResourceTypeSubnet, awsinput.TagSpecifications)
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.CreateSubnet(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	if internalparams.Waiters != nil {
+		waitinput := &ec2.DescribeSubnetsInput{
+			SubnetIds: []*string{result.Subnet.SubnetId},
+		}
+		for _, waitername := range internalparams.Waiters {
+			switch waitername {
+			case "WaitUntilSubnetAvailable":
+				ctx.Logger.LogInfo("Waiting for subnet to be available...")
+				err = svc.WaitUntilSubnetAvailable(waitinput)
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("unkown waiter")
+			}
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result.Subnet, result.Subnet.SubnetId)
+	return aout, nil
+}
+
 // DeleteSubnet func
 func DeleteSubnet(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.DeleteSubnetInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -0,0 +1,423 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/util"
+)
+
+const (
+	// defaultTagsPrivateVar holds the *defaultTagsConfig
+	// set by the set_default_tags action
+	defaultTagsPrivateVar = "awsDefaultTags"
+
+	executionUUIDTagKey = "nebulant:execution-uuid"
+	blueprintTagKey     = "nebulant:blueprint"
+
+	// max ARNs per TagResources/UntagResources request
+	taggingBatchSize = 20
+)
+
+type defaultTagsConfig struct {
+	Tags           map[string]string
+	DisableBuiltin bool
+}
+
+type setDefaultTagsParameters struct {
+	Tags map[string]string `json:"tags"`
+	// do not add the execution uuid and blueprint name tags
+	DisableBuiltinTags bool `json:"disable_builtin_tags"`
+}
+
+func (p *setDefaultTagsParameters) Validate() error {
+	return validateTagKeys(p.Tags)
+}
+
+type tagResourcesParameters struct {
+	// resource ids (ec2) or ARNs
+	Resources []*string         `json:"resources" validate:"required,min=1"`
+	Tags      map[string]string `json:"tags" validate:"required,min=1"`
+}
+
+func (p *tagResourcesParameters) Validate() error {
+	return validateTagKeys(p.Tags)
+}
+
+type untagResourcesParameters struct {
+	// resource ids (ec2) or ARNs
+	Resources []*string `json:"resources" validate:"required,min=1"`
+	TagKeys   []*string `json:"tag_keys" validate:"required,min=1,dive,required"`
+}
+
+// findTagsParameters is the tags shorthand of the find actions:
+// {"tags": {"k": "v"}} filters by the tag k with value v. An
+// empty or "*" value only filters by the tag key.
+type findTagsParameters struct {
+	Tags map[string]string `json:"tags"`
+}
+
+// tagsOutput struct
+type tagsOutput struct {
+	Resources []*string         `json:"resources"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	TagKeys   []*string         `json:"tag_keys,omitempty"`
+}
+
+func validateTagKeys(tags map[string]string) error {
+	for k := range tags {
+		if k == "" {
+			return fmt.Errorf("empty tag key")
+		}
+		if strings.HasPrefix(strings.ToLower(k), "aws:") {
+			return fmt.Errorf("tag keys with the aws: prefix are reserved: %s", k)
+		}
+	}
+	return nil
+}
+
+// interpolateTags returns a copy of tags with interpolated values
+func interpolateTags(store base.IStore, tags map[string]string) (map[string]string, error) {
+	out := make(map[string]string, len(tags))
+	for k, v := range tags {
+		if err := store.Interpolate(&v); err != nil {
+			return nil, err
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+func sortedTagKeys(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetDefaultTags func. Sets the tags added to the resources
+// created by the next actions
+func SetDefaultTags(ctx *ActionContext) (*base.ActionOutput, error) {
+	params := new(setDefaultTagsParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	tags, err := interpolateTags(ctx.Store, params.Tags)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Logger.LogInfo(fmt.Sprintf("Setting %d default tags", len(tags)))
+	ctx.Store.SetPrivateVar(defaultTagsPrivateVar, &defaultTagsConfig{
+		Tags:           tags,
+		DisableBuiltin: params.DisableBuiltinTags,
+	})
+
+	return nil, nil
+}
+
+// defaultTags returns the tags to add to every created resource:
+// the execution uuid and blueprint name plus the tags set by
+// set_default_tags, which take precedence
+func defaultTags(ctx *ActionContext) map[string]string {
+	tags := make(map[string]string)
+	conf, _ := ctx.Store.GetPrivateVar(defaultTagsPrivateVar).(*defaultTagsConfig)
+	if conf == nil || !conf.DisableBuiltin {
+		if uuid, ok := ctx.Store.GetPrivateVar(blueprint.ExecutionUUIDPrivateVar).(string); ok && uuid != "" {
+			tags[executionUUIDTagKey] = uuid
+		}
+		if name, ok := ctx.Store.GetPrivateVar(blueprint.BlueprintNamePrivateVar).(string); ok && name != "" {
+			tags[blueprintTagKey] = name
+		}
+	}
+	if conf != nil {
+		for k, v := range conf.Tags {
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
+// withDefaultTags adds the default tags to the tag specification of
+// resourceType. Tags set in the action parameters are kept.
+func withDefaultTags(ctx *ActionContext, resourceType string, specs []*ec2.TagSpecification) []*ec2.TagSpecification {
+	tags := defaultTags(ctx)
+	if len(tags) <= 0 {
+		return specs
+	}
+	var spec *ec2.TagSpecification
+	for _, s := range specs {
+		if s != nil && aws.StringValue(s.ResourceType) == resourceType {
+			spec = s
+			break
+		}
+	}
+	if spec == nil {
+		spec = &ec2.TagSpecification{ResourceType: aws.String(resourceType)}
+		specs = append(specs, spec)
+	}
+	present := make(map[string]bool)
+	for _, tag := range spec.Tags {
+		present[aws.StringValue(tag.Key)] = true
+	}
+	for _, k := range sortedTagKeys(tags) {
+		if !present[k] {
+			spec.Tags = append(spec.Tags, &ec2.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+		}
+	}
+	return specs
+}
+
+// withDefaultRDSTags is the rds version of withDefaultTags
+func withDefaultRDSTags(ctx *ActionContext, rdstags []*rds.Tag) []*rds.Tag {
+	tags := defaultTags(ctx)
+	present := make(map[string]bool)
+	for _, tag := range rdstags {
+		present[aws.StringValue(tag.Key)] = true
+	}
+	for _, k := range sortedTagKeys(tags) {
+		if !present[k] {
+			rdstags = append(rdstags, &rds.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+		}
+	}
+	return rdstags
+}
+
+// withDefaultELBv2Tags is the elbv2 version of withDefaultTags
+func withDefaultELBv2Tags(ctx *ActionContext, elbtags []*elbv2.Tag) []*elbv2.Tag {
+	tags := defaultTags(ctx)
+	present := make(map[string]bool)
+	for _, tag := range elbtags {
+		present[aws.StringValue(tag.Key)] = true
+	}
+	for _, k := range sortedTagKeys(tags) {
+		if !present[k] {
+			elbtags = append(elbtags, &elbv2.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+		}
+	}
+	return elbtags
+}
+
+// splitResources splits ec2 resource ids and ARNs, which
+// are tagged through the resource groups tagging api
+func splitResources(resources []*string) ([]*string, []*string) {
+	var ids, arns []*string
+	for _, r := range resources {
+		if strings.HasPrefix(aws.StringValue(r), "arn:") {
+			arns = append(arns, r)
+		} else {
+			ids = append(ids, r)
+		}
+	}
+	return ids, arns
+}
+
+// taggingFailures builds an error from the failed
+// resources of a TagResources/UntagResources call
+func taggingFailures(failed map[string]*resourcegroupstaggingapi.FailureInfo) error {
+	if len(failed) <= 0 {
+		return nil
+	}
+	arns := make([]string, 0, len(failed))
+	for arn := range failed {
+		arns = append(arns, arn)
+	}
+	sort.Strings(arns)
+	msgs := make([]string, 0, len(arns))
+	for _, arn := range arns {
+		msgs = append(msgs, arn+": "+aws.StringValue(failed[arn].ErrorMessage))
+	}
+	return fmt.Errorf("cannot tag %d resources: %s", len(arns), strings.Join(msgs, "; "))
+}
+
+// TagResources func
+func TagResources(ctx *ActionContext) (*base.ActionOutput, error) {
+	params := new(tagResourcesParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := interpolateTags(ctx.Store, params.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, arns := splitResources(params.Resources)
+	if len(ids) > 0 {
+		ec2tags := make([]*ec2.Tag, 0, len(tags))
+		for _, k := range sortedTagKeys(tags) {
+			ec2tags = append(ec2tags, &ec2.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+		}
+		ctx.Logger.LogInfo(fmt.Sprintf("Tagging %d resources", len(ids)))
+		svc := ctx.NewEC2Client()
+		_, err = svc.CreateTags(&ec2.CreateTagsInput{Resources: ids, Tags: ec2tags})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(arns) > 0 {
+		ctx.Logger.LogInfo(fmt.Sprintf("Tagging %d resources by ARN", len(arns)))
+		svc := ctx.NewTaggingClient()
+		for start := 0; start < len(arns); start += taggingBatchSize {
+			end := start + taggingBatchSize
+			if end > len(arns) {
+				end = len(arns)
+			}
+			result, err := svc.TagResources(&resourcegroupstaggingapi.TagResourcesInput{
+				ResourceARNList: arns[start:end],
+				Tags:            aws.StringMap(tags),
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := taggingFailures(result.FailedResourcesMap); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, &tagsOutput{Resources: params.Resources, Tags: tags}, nil)
+	return aout, nil
+}
+
+// UntagResources func
+func UntagResources(ctx *ActionContext) (*base.ActionOutput, error) {
+	params := new(untagResourcesParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, arns := splitResources(params.Resources)
+	if len(ids) > 0 {
+		ec2tags := make([]*ec2.Tag, 0, len(params.TagKeys))
+		for _, k := range params.TagKeys {
+			ec2tags = append(ec2tags, &ec2.Tag{Key: k})
+		}
+		ctx.Logger.LogInfo(fmt.Sprintf("Untagging %d resources", len(ids)))
+		svc := ctx.NewEC2Client()
+		_, err = svc.DeleteTags(&ec2.DeleteTagsInput{Resources: ids, Tags: ec2tags})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(arns) > 0 {
+		ctx.Logger.LogInfo(fmt.Sprintf("Untagging %d resources by ARN", len(arns)))
+		svc := ctx.NewTaggingClient()
+		for start := 0; start < len(arns); start += taggingBatchSize {
+			end := start + taggingBatchSize
+			if end > len(arns) {
+				end = len(arns)
+			}
+			result, err := svc.UntagResources(&resourcegroupstaggingapi.UntagResourcesInput{
+				ResourceARNList: arns[start:end],
+				TagKeys:         params.TagKeys,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := taggingFailures(result.FailedResourcesMap); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, &tagsOutput{Resources: params.Resources, TagKeys: params.TagKeys}, nil)
+	return aout, nil
+}
+
+// unmarshalFindTags parses the tags shorthand of the find actions
+func unmarshalFindTags(params []byte) (*findTagsParameters, error) {
+	tags := new(findTagsParameters)
+	if err := util.UnmarshalValidJSON(params, tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// interpolate interpolates the values of the tags
+func (p *findTagsParameters) interpolate(store base.IStore) error {
+	if len(p.Tags) <= 0 {
+		return nil
+	}
+	tags, err := interpolateTags(store, p.Tags)
+	if err != nil {
+		return err
+	}
+	p.Tags = tags
+	return nil
+}
+
+// ec2Filters returns the tags as ec2 filters
+func (p *findTagsParameters) ec2Filters() []*ec2.Filter {
+	filters := make([]*ec2.Filter, 0, len(p.Tags))
+	for _, k := range sortedTagKeys(p.Tags) {
+		v := p.Tags[k]
+		if v == "" || v == "*" {
+			filters = append(filters, &ec2.Filter{Name: aws.String("tag-key"), Values: []*string{aws.String(k)}})
+			continue
+		}
+		filters = append(filters, &ec2.Filter{Name: aws.String("tag:" + k), Values: []*string{aws.String(v)}})
+	}
+	return filters
+}
+
+// match returns true if resourceTags has all the tags
+func (p *findTagsParameters) match(resourceTags map[string]string) bool {
+	for k, v := range p.Tags {
+		value, exists := resourceTags[k]
+		if !exists || (v != "" && v != "*" && value != v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/providers/aws/actors"
+	"github.com/develatio/nebulant-cli/storage"
+)
+
+type fakeTaggingClient struct {
+	resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
+}
+
+func (f *fakeTaggingClient) TagResources(input *resourcegroupstaggingapi.TagResourcesInput) (*resourcegroupstaggingapi.TagResourcesOutput, error) {
+	tags := make([]string, 0, len(input.Tags))
+	for k, v := range input.Tags {
+		tags = append(tags, k+"="+*v)
+	}
+	sort.Strings(tags)
+	fakeEC2Calls = append(fakeEC2Calls, "TagResources:"+strings.Join(aws.StringValueSlice(input.ResourceARNList), ",")+":"+strings.Join(tags, ","))
+	return &resourcegroupstaggingapi.TagResourcesOutput{}, nil
+}
+
+func (f *fakeTaggingClient) UntagResources(input *resourcegroupstaggingapi.UntagResourcesInput) (*resourcegroupstaggingapi.UntagResourcesOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "UntagResources:"+strings.Join(aws.StringValueSlice(input.ResourceARNList), ",")+":"+strings.Join(aws.StringValueSlice(input.TagKeys), ","))
+	return &resourcegroupstaggingapi.UntagResourcesOutput{}, nil
+}
+
+func ec2Tags(tags []*ec2.Tag) string {
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		out = append(out, *tag.Key+"="+aws.StringValue(tag.Value))
+	}
+	return strings.Join(out, ",")
+}
+
+func (f *fakeEC2Client) CreateVolume(input *ec2.CreateVolumeInput) (*ec2.Volume, error) {
+	call := "CreateVolume"
+	for _, spec := range input.TagSpecifications {
+		call += ":" + *spec.ResourceType + "[" + ec2Tags(spec.Tags) + "]"
+	}
+	fakeEC2Calls = append(fakeEC2Calls, call)
+	return &ec2.Volume{VolumeId: aws.String("vol-1")}, nil
+}
+
+func (f *fakeEC2Client) CreateTags(input *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "CreateTags:"+strings.Join(aws.StringValueSlice(input.Resources), ",")+":"+ec2Tags(input.Tags))
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+func (f *fakeEC2Client) DeleteTags(input *ec2.DeleteTagsInput) (*ec2.DeleteTagsOutput, error) {
+	fakeEC2Calls = append(fakeEC2Calls, "DeleteTags:"+strings.Join(aws.StringValueSlice(input.Resources), ",")+":"+ec2Tags(input.Tags))
+	return &ec2.DeleteTagsOutput{}, nil
+}
+
+func (f *fakeEC2Client) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	filters := make([]string, 0, len(input.Filters))
+	for _, filter := range input.Filters {
+		filters = append(filters, *filter.Name+"="+strings.Join(aws.StringValueSlice(filter.Values), "|"))
+	}
+	fakeEC2Calls = append(fakeEC2Calls, "DescribeInstances:"+strings.Join(filters, ","))
+	return &ec2.DescribeInstancesOutput{}, nil
+}
+
+func TestDefaultTags(t *testing.T) {
+	store := storage.NewStore()
+	_, calls, err := runEC2ActionWithStore(t, actors.CreateVolume, store, `{"AvailabilityZone": "eu-west-1a", "Size": 8}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "CreateVolume")
+
+	store.SetPrivateVar(blueprint.ExecutionUUIDPrivateVar, "uuid-1")
+	store.SetPrivateVar(blueprint.BlueprintNamePrivateVar, "web")
+	_, calls, err = runEC2ActionWithStore(t, actors.CreateVolume, store, `{
+		"AvailabilityZone": "eu-west-1a",
+		"TagSpecifications": [{"ResourceType": "volume", "Tags": [{"Key": "Name", "Value": "data"}, {"Key": "nebulant:blueprint", "Value": "custom"}]}]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "CreateVolume:volume[Name=data,nebulant:blueprint=custom,nebulant:execution-uuid=uuid-1]")
+
+	_, _, err = runEC2ActionWithStore(t, actors.SetDefaultTags, store, `{"tags": {"team": "infra"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, calls, err = runEC2ActionWithStore(t, actors.CreateVolume, store, `{"AvailabilityZone": "eu-west-1a"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "CreateVolume:volume[nebulant:blueprint=web,nebulant:execution-uuid=uuid-1,team=infra]")
+	// CopyImage can not tag on create
+	_, calls, err = runEC2ActionWithStore(t, actors.CopyImage, store, `{"SourceImageId": "ami-1", "SourceRegion": "eu-west-1", "Name": "golden"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "CopyImage:ami-1:eu-west-1", "CreateTags:ami-2:nebulant:blueprint=web,nebulant:execution-uuid=uuid-1,team=infra")
+
+	_, _, err = runEC2ActionWithStore(t, actors.SetDefaultTags, store, `{"tags": {"team": "infra"}, "disable_builtin_tags": true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, calls, err = runEC2ActionWithStore(t, actors.CreateVolume, store, `{"AvailabilityZone": "eu-west-1a"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "CreateVolume:volume[team=infra]")
+
+	_, _, err = runEC2ActionWithStore(t, actors.SetDefaultTags, store, `{"tags": {"aws:team": "infra"}}`)
+	if err == nil {
+		t.Errorf("aws: prefixed tags should fail")
+	}
+}
+
+func TestTagResources(t *testing.T) {
+	_, calls, err := runEC2Action(t, actors.TagResources, `{"resources": ["i-1", "vol-1", "arn:aws:s3:::bucket"], "tags": {"env": "prod", "team": "infra"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "CreateTags:i-1,vol-1:env=prod,team=infra", "TagResources:arn:aws:s3:::bucket:env=prod,team=infra")
+
+	_, calls, err = runEC2Action(t, actors.UntagResources, `{"resources": ["i-1", "arn:aws:s3:::bucket"], "tag_keys": ["env"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "DeleteTags:i-1:env=", "UntagResources:arn:aws:s3:::bucket:env")
+
+	_, calls, err = runEC2Action(t, actors.TagResources, `{"resources": ["i-1"]}`)
+	if err == nil {
+		t.Errorf("tag_resources without tags should fail")
+	}
+	expectCalls(t, calls)
+}
+
+func TestFindTags(t *testing.T) {
+	_, calls, err := runEC2Action(t, actors.FindInstances, `{
+		"Filters": [{"Name": "instance-state-name", "Values": ["running"]}],
+		"tags": {"env": "prod", "owner": "*"}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "DescribeInstances:instance-state-name=running,tag:env=prod,tag-key=owner")
+
+	_, _, err = runEC2Action(t, actors.FindInstances, `{"tags": ["env"]}`)
+	if err == nil {
+		t.Errorf("tags should be an object")
+	}
+}
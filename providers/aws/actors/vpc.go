@@ -23,17 +23,23 @@
 package actors
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
 	"github.com/develatio/nebulant-cli/util"
 )
 
 // FindVpcs func
 func FindVpcs(ctx *ActionContext) (*base.ActionOutput, error) {
 	awsinput := new(ec2.DescribeVpcsInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	tags, err := unmarshalFindTags(ctx.Action.Parameters)
+	if err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -43,6 +49,11 @@ func FindVpcs(ctx *ActionContext) (*base.ActionOutput, error) {
 	region := ctx.AwsSess.Config.Region
 	ctx.Logger.LogInfo("Looking for vpcs in region " + *region)
 
+	if err := tags.interpolate(ctx.Store); err != nil {
+		return nil, err
+	}
+	awsinput.Filters = append(awsinput.Filters, tags.ec2Filters()...)
+
 	svc := ctx.NewEC2Client()
 	result, err := svc.DescribeVpcs(awsinput)
 	if err != nil {
@@ -77,11 +88,67 @@ func FindOneVpc(ctx *ActionContext) (*base.ActionOutput, error) {
 	return aout, nil
 }
 
+// CreateVpc func
+func CreateVpc(ctx *ActionContext) (*base.ActionOutput, error) {
+	awsinput := new(ec2.CreateVpcInput)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	awsinput.TagSpecifications = withDefaultTags(ctx, ec2.ResourceTypeVpc, awsinput.TagSpecifications)
+
+	svc := ctx.NewEC2Client()
+	result, err := svc.CreateVpc(awsinput)
+	if err != nil {
+		return nil, err
+	}
+
+	if internalparams.Waiters != nil {
+		waitinput := &ec2.DescribeVpcsInput{
+			VpcIds: []*string{result.Vpc.VpcId},
+		}
+		for _, waitername := range internalparams.Waiters {
+			switch waitername {
+			case "WaitUntilVpcExists":
+				ctx.Logger.LogInfo("Waiting for vpc to exist...")
+				err = svc.WaitUntilVpcExists(waitinput)
+				if err != nil {
+					return nil, err
+				}
+			case "WaitUntilVpcAvailable":
+				ctx.Logger.LogInfo("Waiting for vpc to be available...")
+				err = svc.WaitUntilVpcAvailable(waitinput)
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("unkown waiter")
+			}
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, result.Vpc, result.Vpc.VpcId)
+	return aout, nil
+}
+
 // DeleteVpc func
 func DeleteVpc(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	awsinput := new(ec2.DeleteVpcInput)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, awsinput); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, awsinput, ctx.Store); err != nil {
 		return nil, err
 	}
 	if ctx.Rehearsal {
@@ -23,11 +23,17 @@
 package actors
 
 import (
+	"net/http"
+
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 
 	"github.com/develatio/nebulant-cli/base"
 	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/cast"
+	"github.com/develatio/nebulant-cli/providers/objectstore"
+	"github.com/develatio/nebulant-cli/util"
 )
 
 type s3ClientFunc func() *s3.Client
@@ -46,6 +52,14 @@ type ActionContext struct {
 var NewActionContext = func(awsConf aws.Config, action *blueprint.Action, store base.IStore, logger base.ILogger) *ActionContext {
 	l := logger.Duplicate()
 	l.SetActionID(action.ActionID)
+	if action.DebugNetwork {
+		var baseClient *http.Client
+		if bc, ok := awsConf.HTTPClient.(*awshttp.BuildableClient); ok {
+			baseClient = &http.Client{Transport: bc.GetTransport(), Timeout: bc.GetTimeout()}
+		}
+		awsConf = awsConf.Copy()
+		awsConf.HTTPClient = util.NewDebugHTTPClient(baseClient, cast.NetworkTracer(l))
+	}
 	return &ActionContext{
 		AwsConfig: awsConf,
 		Action:    action,
@@ -79,5 +93,5 @@ type ActionLayout struct {
 
 // ActionFuncMap map
 var ActionFuncMap map[string]*ActionLayout = map[string]*ActionLayout{
-	"r2_upload": {F: R2Upload, N: NextOKKO},
+	"r2_upload": {F: r2Action(objectstore.UploadFiles), N: NextOKKO},
 }
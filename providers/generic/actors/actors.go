@@ -118,7 +118,14 @@ var ActionFuncMap map[string]*ActionLayout = map[string]*ActionLayout{
 	"http_request":     {F: HttpRequest, N: NextOKKO, R: true},
 	"read_file":        {F: ReadFile, N: NextOKKO, R: false},
 	"write_file":       {F: WriteFile, N: NextOKKO, R: false},
+	"approval":         {F: Approval, N: NextOKKO, R: false},
+	"wait_for_event":   {F: WaitForEvent, N: NextOKKO, R: false},
+	"wait_until":       {F: WaitUntil, N: NextOKKO, R: false},
+	"probe_tcp":        {F: ProbeTCP, N: NextOKKO, R: true},
+	"probe_http":       {F: ProbeHTTP, N: NextOKKO, R: true},
+	"probe_ssh":        {F: ProbeSSH, N: NextOKKO, R: true},
+	"resolve_dns":      {F: ResolveDNS, N: NextOKKO, R: true},
 	// handled by core stage
-	"join_threads": {F: NOOP, N: NextOK, R: false},
+	"join_threads": {F: NOOP, N: NextOKKO, R: false},
 	"debug":        {F: NOOP, N: NextOK, R: false},
 }
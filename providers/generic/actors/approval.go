@@ -0,0 +1,158 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/cast"
+	"github.com/develatio/nebulant-cli/util"
+)
+
+const (
+	approvalOnTimeoutReject  = "reject"
+	approvalOnTimeoutApprove = "approve"
+)
+
+type approvalParameters struct {
+	Message   string   `json:"message" validate:"required"`
+	Approvers []string `json:"approvers"`
+	// seconds, 0 waits forever
+	Timeout   int64  `json:"timeout" validate:"gte=0"`
+	OnTimeout string `json:"on_timeout"`
+}
+
+func (p *approvalParameters) Validate() error {
+	switch p.OnTimeout {
+	case "", approvalOnTimeoutReject, approvalOnTimeoutApprove:
+		return nil
+	}
+	return fmt.Errorf("invalid on_timeout value %s, expected %s or %s", p.OnTimeout, approvalOnTimeoutReject, approvalOnTimeoutApprove)
+}
+
+type approvalResult struct {
+	ID          string    `json:"id"`
+	Message     string    `json:"message"`
+	Approved    bool      `json:"approved"`
+	By          string    `json:"by"`
+	At          time.Time `json:"at"`
+	Comment     string    `json:"comment"`
+	TimedOut    bool      `json:"timed_out"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// Approval func. Pause the thread until someone approves or
+// rejects the action. Approved goes to OK port, rejected to KO.
+func Approval(ctx *ActionContext) (*base.ActionOutput, error) {
+	params := new(approvalParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	if err := ctx.Store.Interpolate(&params.Message); err != nil {
+		return nil, err
+	}
+
+	executionUUID, _, threadID := cast.LoggerIDs(ctx.Logger)
+	actionID := ctx.Action.ActionID
+	approval := &cast.Approval{
+		ExecutionUUID: executionUUID,
+		ActionID:      &actionID,
+		ThreadID:      threadID,
+		Message:       params.Message,
+		Approvers:     params.Approvers,
+	}
+	var timeout <-chan time.Time
+	if params.Timeout > 0 {
+		expiresAt := time.Now().UTC().Add(time.Duration(params.Timeout) * time.Second)
+		approval.ExpiresAt = &expiresAt
+		timer := time.NewTimer(time.Duration(params.Timeout) * time.Second)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	decisions, err := cast.Approvals.Request(approval)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Logger.LogInfo(fmt.Sprintf("Waiting for approval %s: %s", approval.ID, params.Message))
+
+	var decision *cast.ApprovalDecision
+	select {
+	case decision = <-decisions:
+	case <-timeout:
+		select {
+		case decision = <-decisions:
+			// resolved by someone right before the timeout
+		default:
+			err := cast.Approvals.Resolve(approval.ID, &cast.ApprovalDecision{
+				Approved: params.OnTimeout == approvalOnTimeoutApprove,
+				By:       "timeout",
+				TimedOut: true,
+			})
+			if err != nil {
+				// resolved by someone meanwhile, his
+				// decision is on the way
+				ctx.Logger.LogDebug(err.Error())
+			}
+			decision = <-decisions
+		}
+	case <-ctx.Actx.Done():
+		// a nil Done chan blocks forever, so this
+		// only happens on action cancellation
+		cast.Approvals.Cancel(approval.ID)
+		return nil, fmt.Errorf("approval cancelled")
+	}
+
+	result := &approvalResult{
+		ID:          approval.ID,
+		Message:     params.Message,
+		Approved:    decision.Approved,
+		By:          decision.By,
+		At:          decision.At,
+		Comment:     decision.Comment,
+		TimedOut:    decision.TimedOut,
+		RequestedAt: approval.RequestedAt,
+	}
+	aout := base.NewActionOutput(ctx.Action, result, &approval.ID)
+
+	status := "rejected"
+	if decision.Approved {
+		status = "approved"
+	}
+	if decision.TimedOut {
+		ctx.Logger.LogInfo(fmt.Sprintf("Approval %s timed out, %s", approval.ID, status))
+	} else {
+		ctx.Logger.LogInfo(fmt.Sprintf("Approval %s %s by %s at %s", approval.ID, status, decision.By, decision.At.Format(time.RFC3339)))
+	}
+	if !decision.Approved {
+		return aout, fmt.Errorf("approval %s", status)
+	}
+	return aout, nil
+}
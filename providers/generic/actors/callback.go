@@ -0,0 +1,77 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/cast"
+	"github.com/develatio/nebulant-cli/util"
+)
+
+type waitForEventParameters struct {
+	// seconds, 0 waits forever
+	Timeout int64 `json:"timeout" validate:"gte=0"`
+}
+
+// WaitForEvent func. Pause the thread until the callback token of the
+// action is signaled through http (server mode) or `nebulant signal`. The received
+// JSON body is stored into the body attr of the output.
+func WaitForEvent(ctx *ActionContext) (*base.ActionOutput, error) {
+	params := new(waitForEventParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	cb, ok := ctx.Store.GetPrivateVar(blueprint.CallbackPrivateVarPrefix + ctx.Action.ActionID).(*cast.Callback)
+	if !ok {
+		return nil, fmt.Errorf("no callback token registered for this action")
+	}
+
+	var timeout <-chan time.Time
+	if params.Timeout > 0 {
+		timer := time.NewTimer(time.Duration(params.Timeout) * time.Second)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	ctx.Logger.LogInfo("Waiting for event on callback token " + cb.Token)
+	select {
+	case evt := <-cb.Events():
+		ctx.Logger.LogInfo(fmt.Sprintf("Event received from %s", evt.Source))
+		return base.NewActionOutput(ctx.Action, evt, &cb.Token), nil
+	case <-timeout:
+		return nil, fmt.Errorf("timeout waiting for event")
+	case <-ctx.Actx.Done():
+		// a nil Done chan blocks forever, so this
+		// only happens on action cancellation
+		return nil, fmt.Errorf("wait for event cancelled")
+	}
+}
@@ -32,7 +32,6 @@ package actors
 import (
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/develatio/nebulant-cli/base"
 	nebulantssh "github.com/develatio/nebulant-cli/netproto/ssh"
@@ -61,7 +60,7 @@ func ScpCopy(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	var i int
 	params := new(scpCopyParameters)
-	err = util.UnmarshalValidJSON(ctx.Action.Parameters, params)
+	err = util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store)
 	if err != nil {
 		return nil, err
 	}
@@ -159,9 +158,6 @@ func ScpCopy(ctx *ActionContext) (*base.ActionOutput, error) {
 			case <-out:
 				ctx.Logger.LogDebug("Should out from go routine")
 				break L1
-			default:
-				ctx.Logger.LogDebug("Waiting scp event...")
-				time.Sleep(200000 * time.Microsecond)
 			}
 		}
 	}()
@@ -358,7 +358,7 @@ func Stop(ctx *ActionContext) (*base.ActionOutput, error) {
 func ConditionParse(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	params := new(conditionParameters)
-	if err = util.UnmarshalValidJSON(ctx.Action.Parameters, params); err != nil {
+	if err = util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -379,7 +379,7 @@ func ConditionParse(ctx *ActionContext) (*base.ActionOutput, error) {
 // Sleep func
 func Sleep(ctx *ActionContext) (*base.ActionOutput, error) {
 	params := new(SleepParameters)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, params); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -388,14 +388,22 @@ func Sleep(ctx *ActionContext) (*base.ActionOutput, error) {
 	}
 	ctx.Logger.LogInfo("Sleeping for " + strconv.FormatInt(params.Seconds, 10) + " seconds")
 	// Duration == type int64
-	time.Sleep(time.Duration(params.Seconds) * time.Second)
+	timer := time.NewTimer(time.Duration(params.Seconds) * time.Second)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Actx.Done():
+		// a nil Done chan blocks forever, so this
+		// only happens on action cancellation
+		return nil, fmt.Errorf("sleep cancelled")
+	}
 	return nil, nil
 }
 
 // OKKO func
 func OKKO(ctx *ActionContext) (*base.ActionOutput, error) {
 	params := new(okkoParameters)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, params); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -428,7 +436,7 @@ func OKKO(ctx *ActionContext) (*base.ActionOutput, error) {
 func Log(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	params := new(logParameters)
-	if err = util.UnmarshalValidJSON(ctx.Action.Parameters, params); err != nil {
+	if err = util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -448,7 +456,7 @@ func Log(ctx *ActionContext) (*base.ActionOutput, error) {
 func Panic(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	params := new(panicParameters)
-	err = util.UnmarshalValidJSON(ctx.Action.Parameters, params)
+	err = util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store)
 	if err != nil {
 		if ctx.Rehearsal {
 			return nil, err
@@ -484,7 +492,7 @@ func Panic(ctx *ActionContext) (*base.ActionOutput, error) {
 // DefineVars func
 func DefineVars(ctx *ActionContext) (*base.ActionOutput, error) {
 	params := new(defineVarsParameters)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, params); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
 		return nil, err
 	}
 
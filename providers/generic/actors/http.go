@@ -52,6 +52,7 @@ import (
 	"golang.org/x/net/html/charset"
 
 	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/cast"
 	"github.com/develatio/nebulant-cli/term"
 	"github.com/develatio/nebulant-cli/util"
 )
@@ -170,7 +171,7 @@ func HttpRequest(ctx *ActionContext) (*base.ActionOutput, error) {
 		// content_type: "",
 		// }
 		param := &httpRequestParametersMultiPartBody{}
-		if err := util.UnmarshalValidJSON(ctx.Action.Parameters, param); err != nil {
+		if err := util.UnmarshalParameters(ctx.Action.Parameters, param, ctx.Store); err != nil {
 			return nil, err
 		}
 
@@ -239,7 +240,7 @@ func HttpRequest(ctx *ActionContext) (*base.ActionOutput, error) {
 		// body part definitions
 		// body is {name: "campo1", value: "valor1"}
 		param := &httpRequestParametersUrlEncodedBody{}
-		if err := util.UnmarshalValidJSON(ctx.Action.Parameters, param); err != nil {
+		if err := util.UnmarshalParameters(ctx.Action.Parameters, param, ctx.Store); err != nil {
 			return nil, err
 		}
 		// append key:value
@@ -258,7 +259,7 @@ func HttpRequest(ctx *ActionContext) (*base.ActionOutput, error) {
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	case BodyTypeRaw:
 		param := &httpRequestParametersRawBody{}
-		if err := util.UnmarshalValidJSON(ctx.Action.Parameters, param); err != nil {
+		if err := util.UnmarshalParameters(ctx.Action.Parameters, param, ctx.Store); err != nil {
 			return nil, err
 		}
 		body := strings.NewReader(*param.RawBody)
@@ -271,7 +272,7 @@ func HttpRequest(ctx *ActionContext) (*base.ActionOutput, error) {
 	case BodyTypeBinary:
 		param := &httpRequestParametersBinaryBody{}
 		// the body contains the path of a file
-		if err := util.UnmarshalValidJSON(ctx.Action.Parameters, param); err != nil {
+		if err := util.UnmarshalParameters(ctx.Action.Parameters, param, ctx.Store); err != nil {
 			return nil, err
 		}
 		file, err := os.Open(*param.BinaryBody)
@@ -312,7 +313,7 @@ func HttpRequest(ctx *ActionContext) (*base.ActionOutput, error) {
 		if err != nil {
 			return nil, err
 		}
-		ctx.Logger.LogDebug("Use request header, " + *hh.Key + ": " + *hh.Value)
+		ctx.Logger.LogDebug("Use request header " + *hh.Key)
 		req.Header.Set(*hh.Key, *hh.Value)
 	}
 
@@ -328,6 +329,9 @@ func HttpRequest(ctx *ActionContext) (*base.ActionOutput, error) {
 		},
 	}
 	client := &http.Client{Transport: tr}
+	if ctx.Action.DebugNetwork {
+		client.Transport = util.NewDebugTransport(tr, cast.NetworkTracer(ctx.Logger))
+	}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -412,7 +416,7 @@ func HttpRequest(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil && err != io.EOF {
 		return nil, err
 	}
-	ctx.Logger.LogDebug("Body: " + string(swb))
+	ctx.Logger.LogDebug("Body: " + string(swb[:n]))
 	if written > int64(n) {
 		ctx.Logger.LogDebug("...[Truncated]")
 	}
@@ -65,7 +65,7 @@ type sendMailParameters struct {
 
 func SendMail(ctx *ActionContext) (*base.ActionOutput, error) {
 	params := new(sendMailParameters)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, params); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
 		return nil, err
 	}
 
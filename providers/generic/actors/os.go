@@ -56,6 +56,11 @@ type runLocalScriptOutput struct {
 	ExitCode  string        `json:"exit_code"`
 }
 
+// CommandOutput func
+func (r *runLocalScriptOutput) CommandOutput() (string, string) {
+	return r.Stdout, r.Stderr
+}
+
 type runLocalParameters struct {
 	Target *string `json:"target" validate:"required"`
 	// Username       *string `json:"username", validate:"required"`
@@ -320,7 +325,7 @@ func RunLocalScript(ctx *ActionContext) (*base.ActionOutput, error) {
 
 func DefineEnvs(ctx *ActionContext) (*base.ActionOutput, error) {
 	params := new(defineEnvsParameters)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, params); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -351,7 +356,7 @@ func DefineEnvs(ctx *ActionContext) (*base.ActionOutput, error) {
 
 func ReadFile(ctx *ActionContext) (*base.ActionOutput, error) {
 	params := new(readFileParameters)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, params); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -393,7 +398,7 @@ func ReadFile(ctx *ActionContext) (*base.ActionOutput, error) {
 
 func WriteFile(ctx *ActionContext) (*base.ActionOutput, error) {
 	params := new(writeFileParameters)
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, params); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
 		return nil, err
 	}
 
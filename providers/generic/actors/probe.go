@@ -0,0 +1,537 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/develatio/nebulant-cli/base"
+	nebulantssh "github.com/develatio/nebulant-cli/netproto/ssh"
+	"github.com/develatio/nebulant-cli/util"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// max bytes of the body stored by the http probes
+	probeMaxBodySize = 65536
+	// seconds
+	probeDefaultTimeout = 5
+	probeDefaultBackoff = 1
+	probeMaxBackoff     = 60
+)
+
+// probeRetryParameters are shared by all the probe actions
+type probeRetryParameters struct {
+	// seconds for each attempt
+	Timeout int64 `json:"timeout" validate:"gte=0"`
+	// attempts after the first one
+	Retries int `json:"retries" validate:"gte=0"`
+	// seconds before the first retry, doubled on each retry
+	Backoff float64 `json:"backoff" validate:"gte=0"`
+}
+
+func (p *probeRetryParameters) timeout() time.Duration {
+	if p.Timeout <= 0 {
+		return probeDefaultTimeout * time.Second
+	}
+	return time.Duration(p.Timeout) * time.Second
+}
+
+// probeAttemptFunc runs one attempt of a probe. ok is false if
+// the probed resource is not ready yet. err aborts the probe.
+type probeAttemptFunc func(cctx context.Context, timeout time.Duration) (ok bool, err error)
+
+// retryProbe runs the attempt func until it is ok or the retries
+// are exhausted. Returns the number of attempts.
+func retryProbe(ctx *ActionContext, p *probeRetryParameters, attempt probeAttemptFunc) (int, bool, error) {
+	cctx, cancel := doneContext(ctx.Actx.Done())
+	defer cancel()
+	backoff := probeDefaultBackoff * time.Second
+	if p.Backoff > 0 {
+		backoff = time.Duration(p.Backoff * float64(time.Second))
+	}
+	for n := 1; ; n++ {
+		ok, err := attempt(cctx, p.timeout())
+		if err != nil || ok || n > p.Retries {
+			return n, ok, err
+		}
+		ctx.Logger.LogDebug(fmt.Sprintf("Probe attempt %d failed, retrying in %v", n, backoff))
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-cctx.Done():
+			timer.Stop()
+			return n, false, fmt.Errorf("probe cancelled")
+		}
+		backoff *= 2
+		if backoff > probeMaxBackoff*time.Second {
+			backoff = probeMaxBackoff * time.Second
+		}
+	}
+}
+
+// doneContext returns a context cancelled when done is closed
+func doneContext(done <-chan struct{}) (context.Context, context.CancelFunc) {
+	cctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-cctx.Done():
+		}
+	}()
+	return cctx, cancel
+}
+
+type probeTCPParameters struct {
+	probeRetryParameters
+	// host:port
+	Address string `json:"address" validate:"required"`
+}
+
+type tcpProbeResult struct {
+	Address   string `json:"address"`
+	Open      bool   `json:"open"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error"`
+}
+
+type probeTCPOutput struct {
+	tcpProbeResult
+	Attempts int `json:"attempts"`
+}
+
+// probeTCP tries to open a tcp connection to address. A closed
+// port is not an error, it is reported in the result.
+func probeTCP(cctx context.Context, address string, timeout time.Duration) *tcpProbeResult {
+	result := &tcpProbeResult{Address: address}
+	dialer := &net.Dialer{Timeout: timeout}
+	start := time.Now()
+	conn, err := dialer.DialContext(cctx, "tcp", address)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	conn.Close() // #nosec G104 -- Unhandle is OK here
+	result.Open = true
+	return result
+}
+
+// ProbeTCP func. Checks that a tcp port accepts connections
+func ProbeTCP(ctx *ActionContext) (*base.ActionOutput, error) {
+	params := new(probeTCPParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	if err := ctx.Store.DeepInterpolation(params); err != nil {
+		return nil, err
+	}
+
+	result := &probeTCPOutput{}
+	n, ok, err := retryProbe(ctx, &params.probeRetryParameters, func(cctx context.Context, timeout time.Duration) (bool, error) {
+		result.tcpProbeResult = *probeTCP(cctx, params.Address, timeout)
+		return result.Open, nil
+	})
+	result.Attempts = n
+	return probeOutput(ctx, result, ok, err, "port "+params.Address+" is not open")
+}
+
+type probeHTTPParameters struct {
+	probeRetryParameters
+	URL              string `json:"url" validate:"required"`
+	Method           string `json:"method"`
+	IgnoreInvalidSSL bool   `json:"ignore_invalid_certs"`
+	// expected status codes, any 2xx by default
+	ExpectedStatus []int `json:"expected_status"`
+}
+
+type httpProbeResult struct {
+	URL        string `json:"url"`
+	Status     string `json:"status"`
+	StatusCode int    `json:"status_code"`
+	LatencyMs  int64  `json:"latency_ms"`
+	Body       string `json:"body"`
+	Error      string `json:"error"`
+}
+
+type probeHTTPOutput struct {
+	httpProbeResult
+	Attempts int `json:"attempts"`
+}
+
+// probeHTTP sends a request to url. Connection errors are not an
+// error, they are reported in the result.
+func probeHTTP(cctx context.Context, method string, url string, insecure bool, timeout time.Duration) (*httpProbeResult, error) {
+	result := &httpProbeResult{URL: url}
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(cctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// #nosec G402 -- Leave to user the choose to be insecure
+			TLSClientConfig: &tls.Config{
+				MinVersion:         tls.VersionTLS12,
+				InsecureSkipVerify: insecure,
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	defer resp.Body.Close()
+	result.Status = resp.Status
+	result.StatusCode = resp.StatusCode
+	body, err := io.ReadAll(io.LimitReader(resp.Body, probeMaxBodySize))
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Body = string(body)
+	return result, nil
+}
+
+func (p *probeHTTPParameters) expected(code int) bool {
+	if len(p.ExpectedStatus) <= 0 {
+		return code >= 200 && code < 300
+	}
+	for _, ecode := range p.ExpectedStatus {
+		if ecode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// ProbeHTTP func. Checks that an url answers with the expected status
+func ProbeHTTP(ctx *ActionContext) (*base.ActionOutput, error) {
+	params := new(probeHTTPParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	if err := ctx.Store.DeepInterpolation(params); err != nil {
+		return nil, err
+	}
+
+	result := &probeHTTPOutput{}
+	n, ok, err := retryProbe(ctx, &params.probeRetryParameters, func(cctx context.Context, timeout time.Duration) (bool, error) {
+		res, err := probeHTTP(cctx, params.Method, params.URL, params.IgnoreInvalidSSL, timeout)
+		if err != nil {
+			return false, err
+		}
+		result.httpProbeResult = *res
+		return res.Error == "" && params.expected(res.StatusCode), nil
+	})
+	result.Attempts = n
+	return probeOutput(ctx, result, ok, err, fmt.Sprintf("unexpected response from %s: %s", params.URL, result.Status))
+}
+
+type probeSSHParameters struct {
+	probeRetryParameters
+	nebulantssh.ClientConfigParameters
+	// only wait for the ssh banner, do not authenticate
+	SkipAuth bool `json:"skip_auth"`
+}
+
+type probeSSHOutput struct {
+	Address       string `json:"address"`
+	Banner        string `json:"banner"`
+	Authenticated bool   `json:"authenticated"`
+	LatencyMs     int64  `json:"latency_ms"`
+	Attempts      int    `json:"attempts"`
+	Error         string `json:"error"`
+}
+
+// bannerConn keeps the first line (the ssh version banner)
+// read from the server
+type bannerConn struct {
+	net.Conn
+	mu     sync.Mutex
+	banner strings.Builder
+	done   bool
+}
+
+func (b *bannerConn) Read(p []byte) (int, error) {
+	n, err := b.Conn.Read(p)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.done && n > 0 {
+		line, _, found := strings.Cut(string(p[:n]), "\n")
+		b.banner.WriteString(line)
+		b.done = found
+	}
+	return n, err
+}
+
+func (b *bannerConn) Banner() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.TrimSpace(b.banner.String())
+}
+
+// ProbeSSH func. Checks that a ssh server answers and accepts the
+// credentials. Only the handshake is done, no session is opened.
+func ProbeSSH(ctx *ActionContext) (*base.ActionOutput, error) {
+	params := new(probeSSHParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	if err := ctx.Store.DeepInterpolation(params); err != nil {
+		return nil, err
+	}
+	if len(params.Proxies) > 0 {
+		return nil, fmt.Errorf("proxies are not supported by ssh probes")
+	}
+
+	port := params.Port
+	if port == 0 {
+		port = 22
+	}
+	address := net.JoinHostPort(*params.Target, strconv.Itoa(int(port)))
+	sshConfig, err := nebulantssh.GetSSHClientConfig(&params.ClientConfigParameters)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &probeSSHOutput{Address: address}
+	n, ok, err := retryProbe(ctx, &params.probeRetryParameters, func(cctx context.Context, timeout time.Duration) (bool, error) {
+		dialer := &net.Dialer{Timeout: timeout}
+		start := time.Now()
+		conn, err := dialer.DialContext(cctx, "tcp", address)
+		if err != nil {
+			result.LatencyMs = time.Since(start).Milliseconds()
+			result.Error = err.Error()
+			return false, nil
+		}
+		bconn := &bannerConn{Conn: conn}
+		defer bconn.Close()
+		if err := bconn.SetDeadline(time.Now().Add(timeout)); err != nil {
+			return false, err
+		}
+		c, chans, reqs, err := ssh.NewClientConn(bconn, address, sshConfig)
+		result.LatencyMs = time.Since(start).Milliseconds()
+		result.Banner = bconn.Banner()
+		if err != nil {
+			result.Error = err.Error()
+			// the server answered but refused the credentials
+			return params.SkipAuth && result.Banner != "", nil
+		}
+		go ssh.DiscardRequests(reqs)
+		go func() {
+			for ch := range chans {
+				ch.Reject(ssh.Prohibited, "probe") // #nosec G104 -- Unhandle is OK here
+			}
+		}()
+		c.Close() // #nosec G104 -- Unhandle is OK here
+		result.Error = ""
+		result.Authenticated = true
+		return true, nil
+	})
+	result.Attempts = n
+	return probeOutput(ctx, result, ok, err, "ssh server "+address+" is not ready")
+}
+
+type resolveDNSParameters struct {
+	probeRetryParameters
+	Hostname string `json:"hostname" validate:"required"`
+	// ip (A and AAAA), A, AAAA, CNAME, MX, NS or TXT
+	RecordType string `json:"record_type" validate:"omitempty,oneof=ip A AAAA CNAME MX NS TXT"`
+	// host:port of the dns server, system resolver by default
+	Resolver string `json:"resolver"`
+	// values that must be resolved, eg. the ip of a new server
+	Expected []string `json:"expected"`
+}
+
+type resolveDNSOutput struct {
+	Hostname   string   `json:"hostname"`
+	RecordType string   `json:"record_type"`
+	Records    []string `json:"records"`
+	IPs        []string `json:"ips"`
+	LatencyMs  int64    `json:"latency_ms"`
+	Attempts   int      `json:"attempts"`
+	Error      string   `json:"error"`
+}
+
+func (p *resolveDNSParameters) resolver() *net.Resolver {
+	if p.Resolver == "" {
+		return net.DefaultResolver
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(cctx context.Context, network, address string) (net.Conn, error) {
+			d := net.Dialer{}
+			return d.DialContext(cctx, network, p.Resolver)
+		},
+	}
+}
+
+func (p *resolveDNSParameters) lookup(cctx context.Context) ([]string, error) {
+	r := p.resolver()
+	var records []string
+	switch p.RecordType {
+	case "", "ip", "A", "AAAA":
+		network := "ip"
+		if p.RecordType == "A" {
+			network = "ip4"
+		} else if p.RecordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := r.LookupIP(cctx, network, p.Hostname)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			records = append(records, ip.String())
+		}
+	case "CNAME":
+		cname, err := r.LookupCNAME(cctx, p.Hostname)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, cname)
+	case "MX":
+		mxs, err := r.LookupMX(cctx, p.Hostname)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			records = append(records, mx.Host)
+		}
+	case "NS":
+		nss, err := r.LookupNS(cctx, p.Hostname)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nss {
+			records = append(records, ns.Host)
+		}
+	case "TXT":
+		return r.LookupTXT(cctx, p.Hostname)
+	}
+	return records, nil
+}
+
+// ResolveDNS func. Resolves a hostname, optionally waiting
+// for the expected values
+func ResolveDNS(ctx *ActionContext) (*base.ActionOutput, error) {
+	params := new(resolveDNSParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	if err := ctx.Store.DeepInterpolation(params); err != nil {
+		return nil, err
+	}
+
+	result := &resolveDNSOutput{Hostname: params.Hostname, RecordType: params.RecordType}
+	if result.RecordType == "" {
+		result.RecordType = "ip"
+	}
+	n, ok, err := retryProbe(ctx, &params.probeRetryParameters, func(cctx context.Context, timeout time.Duration) (bool, error) {
+		lctx, cancel := context.WithTimeout(cctx, timeout)
+		defer cancel()
+		start := time.Now()
+		records, err := params.lookup(lctx)
+		result.LatencyMs = time.Since(start).Milliseconds()
+		result.Records = records
+		result.IPs = nil
+		for _, record := range records {
+			if net.ParseIP(record) != nil {
+				result.IPs = append(result.IPs, record)
+			}
+		}
+		if err != nil {
+			result.Error = err.Error()
+			return false, nil
+		}
+		result.Error = ""
+		for _, expected := range params.Expected {
+			found := false
+			for _, record := range records {
+				if strings.EqualFold(strings.TrimSuffix(record, "."), strings.TrimSuffix(expected, ".")) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				result.Error = "expected value " + expected + " not resolved"
+				return false, nil
+			}
+		}
+		return len(records) > 0, nil
+	})
+	result.Attempts = n
+	return probeOutput(ctx, result, ok, err, "cannot resolve "+params.Hostname)
+}
+
+// probeOutput builds the output of a probe. A not ready resource
+// returns the output and an error, so the action goes to KO.
+func probeOutput(ctx *ActionContext, result interface{}, ok bool, err error, notReadyMsg string) (*base.ActionOutput, error) {
+	if err != nil {
+		return nil, err
+	}
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	if !ok {
+		return aout, errors.New(notReadyMsg)
+	}
+	return aout, nil
+}
@@ -35,10 +35,13 @@ import (
 	"strings"
 
 	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
 )
 
 type runScriptParameters struct {
 	Target *string `json:"target" validate:"required"`
+	// ssh (default) or ssm. Local scripts use target "local"
+	TargetType *string `json:"target_type"`
 	// Unnused:
 	// Username       *string `json:"username", validate:"required"`
 	// PrivateKeyPath *string `json:"keyfile"`
@@ -59,6 +62,13 @@ func RunScript(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, fmt.Errorf("target cannot be empty")
 	}
 
+	if p.TargetType != nil && strings.ToLower(*p.TargetType) == "ssm" {
+		if !ctx.Rehearsal {
+			ctx.Logger.LogDebug("Running script through AWS SSM")
+		}
+		return RunSSMScript(ctx)
+	}
+
 	if strings.ToLower(*p.Target) == "local" {
 		if !ctx.Rehearsal {
 			ctx.Logger.LogDebug("Running local script")
@@ -70,3 +80,28 @@ func RunScript(ctx *ActionContext) (*base.ActionOutput, error) {
 	}
 	return RunRemoteScript(ctx)
 }
+
+// RunSSMScript func. Runs the script in an aws instance through the
+// run_command action of the aws provider, keeping the action id and
+// output of run_script
+func RunSSMScript(ctx *ActionContext) (*base.ActionOutput, error) {
+	subAction := &blueprint.Action{
+		Provider:     "aws",
+		ActionID:     ctx.Action.ActionID,
+		ActionName:   "run_command",
+		Parameters:   ctx.Action.Parameters,
+		Output:       ctx.Action.Output,
+		SafeID:       ctx.Action.SafeID,
+		Templates:    ctx.Action.Templates,
+		DebugNetwork: ctx.Action.DebugNetwork,
+	}
+	if ctx.Rehearsal {
+		for _, vl := range blueprint.ActionValidators {
+			if err := vl(subAction); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+	return runSubAction(ctx, subAction)
+}
@@ -41,7 +41,6 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/develatio/nebulant-cli/base"
 	nebulantssh "github.com/develatio/nebulant-cli/netproto/ssh"
@@ -85,6 +84,18 @@ type runRemoteScriptOutput struct {
 	ExitCode string        `json:"exit_code"`
 }
 
+// CommandOutput func. Stderr is empty if it was combined into stdout
+func (r *runRemoteScriptOutput) CommandOutput() (string, string) {
+	var stdout, stderr string
+	if r.Stdout != nil {
+		stdout = r.Stdout.String()
+	}
+	if r.Stderr != nil && r.Stderr != r.Stdout {
+		stderr = r.Stderr.String()
+	}
+	return stdout, stderr
+}
+
 func newSSHDebugShell(ctx *ActionContext, sshClient *nebulantssh.SSHClient) error {
 	mst := ctx.GetMustarFD()
 	svu := ctx.GetSluvaFD()
@@ -190,9 +201,6 @@ func RunRemoteScript(ctx *ActionContext) (*base.ActionOutput, error) {
 				}
 			case <-out:
 				break L1
-			default:
-				// ctx.Logger.LogDebug("Waiting ssh event...")
-				time.Sleep(200000 * time.Microsecond)
 			}
 		}
 	}()
@@ -0,0 +1,251 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/cast"
+	"github.com/develatio/nebulant-cli/util"
+)
+
+const (
+	waitUntilDefaultInterval    = 5
+	waitUntilDefaultMaxDuration = 600
+	waitUntilDefaultResultVar   = "RESULT"
+)
+
+type waitUntilParametersAction struct {
+	Provider   string          `json:"provider" validate:"required"`
+	ActionName string          `json:"action" validate:"required"`
+	Parameters json.RawMessage `json:"parameters"`
+}
+
+// waitUntilProbeActions maps the probe types to the
+// probe actions. The probe object is used as parameters.
+var waitUntilProbeActions = map[string]string{
+	"tcp":  "probe_tcp",
+	"http": "probe_http",
+	"ssh":  "probe_ssh",
+	"dns":  "resolve_dns",
+}
+
+type waitUntilParametersProbe struct {
+	Type string `json:"type" validate:"required,oneof=tcp http ssh dns"`
+}
+
+type waitUntilParameters struct {
+	Action *waitUntilParametersAction `json:"action"`
+	Probe  json.RawMessage            `json:"probe"`
+	// evaluated after each attempt with the result stored into
+	// the output var of the action (or RESULT if there is no
+	// output). Without condition, waits for an attempt without
+	// errors.
+	Until *Condition `json:"until"`
+	// seconds between attempts
+	Interval int64 `json:"interval" validate:"gte=0"`
+	// max seconds to wait
+	MaxDuration int64 `json:"max_duration" validate:"gte=0"`
+}
+
+func (p *waitUntilParameters) Validate() error {
+	if (p.Action == nil) == (len(p.Probe) <= 0) {
+		return fmt.Errorf("please set action OR probe to wait for")
+	}
+	if len(p.Probe) > 0 {
+		return util.UnmarshalValidJSON(p.Probe, new(waitUntilParametersProbe))
+	}
+	return nil
+}
+
+// waitedAction returns the action to run on each attempt,
+// the probe action if a probe is set
+func (p *waitUntilParameters) waitedAction() (*waitUntilParametersAction, error) {
+	if p.Action != nil {
+		return p.Action, nil
+	}
+	probe := new(waitUntilParametersProbe)
+	if err := util.UnmarshalValidJSON(p.Probe, probe); err != nil {
+		return nil, err
+	}
+	return &waitUntilParametersAction{
+		Provider:   "generic",
+		ActionName: waitUntilProbeActions[probe.Type],
+		Parameters: p.Probe,
+	}, nil
+}
+
+// WaitUntil func. Runs an action or probe until the condition is met
+func WaitUntil(ctx *ActionContext) (*base.ActionOutput, error) {
+	params := new(waitUntilParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	resultVar := waitUntilDefaultResultVar
+	if ctx.Action.Output != nil {
+		resultVar = *ctx.Action.Output
+	}
+
+	waited, err := params.waitedAction()
+	if err != nil {
+		return nil, err
+	}
+	subAction := &blueprint.Action{
+		Provider:   waited.Provider,
+		ActionID:   ctx.Action.ActionID,
+		ActionName: waited.ActionName,
+		Parameters: waited.Parameters,
+		Output:     &resultVar,
+		SafeID:     ctx.Action.SafeID,
+		Templates:  ctx.Action.Templates,
+	}
+	if len(subAction.Parameters) <= 0 {
+		subAction.Parameters = json.RawMessage("{}")
+	}
+	if subAction.ActionName == blueprint.JoinThreadsActionName || subAction.ActionName == blueprint.WaitForEventActionName || subAction.ActionName == blueprint.WaitUntilActionName {
+		return nil, fmt.Errorf("cannot wait until %s", subAction.ActionName)
+	}
+
+	if ctx.Rehearsal {
+		for _, vl := range blueprint.ActionValidators {
+			if err := vl(subAction); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+
+	interval := time.Duration(waitUntilDefaultInterval) * time.Second
+	if params.Interval > 0 {
+		interval = time.Duration(params.Interval) * time.Second
+	}
+	maxDuration := time.Duration(waitUntilDefaultMaxDuration) * time.Second
+	if params.MaxDuration > 0 {
+		maxDuration = time.Duration(params.MaxDuration) * time.Second
+	}
+	deadline := time.NewTimer(maxDuration)
+	defer deadline.Stop()
+
+	var aout *base.ActionOutput
+	for attempt := 1; ; attempt++ {
+		var met bool
+		aout, met, err = params.attempt(ctx, subAction)
+		if err != nil {
+			return aout, err
+		}
+		if met {
+			ctx.Logger.LogInfo(fmt.Sprintf("Condition met after %d attempts", attempt))
+			break
+		}
+		ctx.Logger.LogDebug(fmt.Sprintf("Condition not met on attempt %d, retrying in %v", attempt, interval))
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-deadline.C:
+			timer.Stop()
+			return aout, fmt.Errorf("condition not met after %v (%d attempts)", maxDuration, attempt)
+		case <-ctx.Actx.Done():
+			// a nil Done chan blocks forever, so this
+			// only happens on action cancellation
+			timer.Stop()
+			return aout, fmt.Errorf("wait until cancelled")
+		}
+	}
+
+	if aout != nil && ctx.Action.Output == nil {
+		// no output var, only the default var
+		// was used to evaluate the condition
+		for _, record := range aout.Records {
+			record.RefName = ""
+		}
+	}
+	return aout, nil
+}
+
+// attempt runs the action or probe once and evaluates the condition.
+// An error is only returned when the wait should be aborted.
+func (p *waitUntilParameters) attempt(ctx *ActionContext, subAction *blueprint.Action) (*base.ActionOutput, bool, error) {
+	aout, err := runSubAction(ctx, subAction)
+	met := err == nil
+	if err != nil {
+		ctx.Logger.LogDebug("Attempt failed: " + err.Error())
+	}
+
+	if p.Until == nil || aout == nil || len(aout.Records) <= 0 {
+		return aout, met, nil
+	}
+
+	// evaluate the condition with the result of this attempt
+	store := ctx.Store.Duplicate()
+	for _, record := range aout.Records {
+		if record.RefName == "" {
+			continue
+		}
+		if err := store.Insert(record, subAction.Provider); err != nil {
+			return aout, false, err
+		}
+	}
+	cctx := *ctx
+	cctx.Store = store
+	until := *p.Until
+	until.ctx = &cctx
+	met, err = until.evaluate()
+	if err != nil {
+		// the result could be not complete yet
+		ctx.Logger.LogDebug("Condition evaluation failed: " + err.Error())
+		return aout, false, nil
+	}
+	return aout, met, nil
+}
+
+// runSubAction runs the action through his provider, as the
+// runtime would do
+func runSubAction(ctx *ActionContext, subAction *blueprint.Action) (*base.ActionOutput, error) {
+	var provider base.IProvider
+	var err error
+	if ctx.Store.ExistsProvider(subAction.Provider) {
+		provider, err = ctx.Store.GetProvider(subAction.Provider)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		providerInitFunc, err := cast.SBus.GetProviderInitFunc(subAction.Provider)
+		if err != nil {
+			return nil, err
+		}
+		provider, err = providerInitFunc(ctx.Store)
+		if err != nil {
+			return nil, err
+		}
+		ctx.Store.StoreProvider(subAction.Provider, provider)
+	}
+
+	// the provider sees the sub action instead of wait_until
+	return provider.HandleAction(base.WithAction(ctx.Actx, subAction))
+}
@@ -27,14 +27,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"strings"
-	"time"
 
 	"github.com/develatio/nebulant-cli/base"
 	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/cast"
 	"github.com/develatio/nebulant-cli/util"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
-	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
 )
 
 // ActionContext struct
@@ -44,96 +42,16 @@ type ActionContext struct {
 	Action    *blueprint.Action
 	Store     base.IStore
 	Logger    base.ILogger
+	// context of the api requests
+	ctx context.Context
 }
 
-func (a *ActionContext) WaitForAndLog(action schema.Action, msg string) error {
-	act := hcloud.ActionFromSchema(action)
-	a.Logger.LogDebug(fmt.Sprintf("waiting for action %v", act.ID))
-	okCh, errCh := a.HClient.Action.WatchProgress(context.Background(), act)
-	var err error
-	noprogress_msg := msg + " ... "
-	progress_msg := msg + " (%v%%...) "
-
-	errCount := 0
-L:
-	for {
-		select {
-		case progress := <-okCh:
-			if progress == 0 {
-				a.Logger.LogInfo(fmt.Sprint(noprogress_msg))
-			} else {
-				a.Logger.LogInfo(fmt.Sprintf(progress_msg, progress))
-			}
-		case err = <-errCh:
-			// sometimes hc api ret err even on non
-			// failing event try to retry 5 times
-			// and exit after all if err persist
-			if err != nil {
-				// if api did not return any action, maybe the action has finished
-				if strings.HasSuffix(err.Error(), "action not returned from API") {
-					a.Logger.LogDebug(err.Error())
-					err = nil
-					break L
-				}
-				if errCount < 5 {
-					errCount++
-					time.Sleep(3 * time.Second)
-					okCh, errCh = a.HClient.Action.WatchProgress(context.Background(), act)
-					continue
-				}
-				// retry count end, let err fly as free bird
-			}
-			// on sucess, err is nil
-			break L
-		}
+// Context returns the context of the api requests of the action
+func (a *ActionContext) Context() context.Context {
+	if a.ctx == nil {
+		return context.Background()
 	}
-	return err
-}
-
-func (a *ActionContext) WaitForManyAndLog(actions []schema.Action, msg string) error {
-	act := hcloud.ActionsFromSchema(actions)
-	for _, aa := range act {
-		a.Logger.LogDebug(fmt.Sprintf("waiting for action %v", aa.ID))
-	}
-	okCh, errCh := a.HClient.Action.WatchOverallProgress(context.Background(), act)
-	var err error
-	noprogress_msg := msg + " ... "
-	progress_msg := msg + " (%v%%...) "
-
-	errCount := 0
-L:
-	for {
-		select {
-		case progress := <-okCh:
-			if progress == 0 {
-				a.Logger.LogInfo(fmt.Sprint(noprogress_msg))
-			} else {
-				a.Logger.LogInfo(fmt.Sprintf(progress_msg, progress))
-			}
-		case err = <-errCh:
-			// sometimes hc api ret err even on non
-			// failing event try to retry 5 times
-			// and exit after all if err persist
-			if err != nil {
-				// if api did not return any action, maybe the action has finished
-				if strings.HasSuffix(err.Error(), "action not returned from API") {
-					a.Logger.LogDebug(err.Error())
-					err = nil
-					break L
-				}
-				if errCount < 5 {
-					errCount++
-					time.Sleep(3 * time.Second)
-					okCh, errCh = a.HClient.Action.WatchOverallProgress(context.Background(), act)
-					continue
-				}
-				// retry count end, let err fly as free bird
-			}
-			// on sucess, err is nil
-			break L
-		}
-	}
-	return err
+	return a.ctx
 }
 
 func UnmarshallHCloudToSchema(response *hcloud.Response, v interface{}) error {
@@ -152,12 +70,17 @@ func UnmarshallHCloudToSchema(response *hcloud.Response, v interface{}) error {
 var NewActionContext = func(client *hcloud.Client, action *blueprint.Action, store base.IStore, logger base.ILogger) *ActionContext {
 	l := logger.Duplicate()
 	l.SetActionID(action.ActionID)
-	return &ActionContext{
+	ac := &ActionContext{
 		HClient: client,
 		Action:  action,
 		Store:   store,
 		Logger:  l,
 	}
+	if action.DebugNetwork {
+		// the client is shared, trace only the requests of this action
+		ac.ctx = util.WithHTTPTracer(context.Background(), cast.NetworkTracer(l))
+	}
+	return ac
 }
 
 // ActionFunc func
@@ -201,6 +124,15 @@ var ActionFuncMap map[string]*ActionLayout = map[string]*ActionLayout{
 	"attach_server_to_network":   {F: AttachServerToNetwork, N: NextOKKO},
 	"detach_server_from_network": {F: DetachServerFromNetwork, N: NextOKKO},
 	"create_image_from_server":   {F: CreateImageFromServer, N: NextOKKO},
+	"rebuild_server":             {F: RebuildServer, N: NextOKKO},
+	"change_server_type":         {F: ChangeServerType, N: NextOKKO},
+	"enable_rescue":              {F: EnableRescueServer, N: NextOKKO},
+	"disable_rescue":             {F: DisableRescueServer, N: NextOKKO},
+	"reboot_server":              {F: RebootServer, N: NextOKKO},
+	"reset_server":               {F: ResetServer, N: NextOKKO},
+	"reset_root_password":        {F: ResetRootPasswordServer, N: NextOKKO},
+	"request_console":            {F: RequestConsoleServer, N: NextOKKO},
+	"protect_server":             {F: ProtectServer, N: NextOKKO},
 
 	"create_network":             {F: CreateNetwork, N: NextOKKO},
 	"delete_network":             {F: DeleteNetwork, N: NextOKKO},
@@ -257,6 +189,21 @@ var ActionFuncMap map[string]*ActionLayout = map[string]*ActionLayout{
 	"delete_ssh_key":  {F: DeleteSSHKey, N: NextOKKO},
 	"find_ssh_keys":   {F: FindSSHKeys, N: NextOKKO},
 	"findone_ssh_key": {F: FindOneSSHKey, N: NextOKKO},
+
+	"create_placement_group":  {F: CreatePlacementGroup, N: NextOKKO},
+	"delete_placement_group":  {F: DeletePlacementGroup, N: NextOKKO},
+	"find_placement_groups":   {F: FindPlacementGroups, N: NextOKKO},
+	"findone_placement_group": {F: FindOnePlacementGroup, N: NextOKKO},
+
+	"create_certificate":  {F: CreateCertificate, N: NextOKKO},
+	"delete_certificate":  {F: DeleteCertificate, N: NextOKKO},
+	"find_certificates":   {F: FindCertificates, N: NextOKKO},
+	"findone_certificate": {F: FindOneCertificate, N: NextOKKO},
+
+	"find_server_types":   {F: FindServerTypes, N: NextOKKO},
+	"findone_server_type": {F: FindOneServerType, N: NextOKKO},
+
+	"find_pricing": {F: FindPricing, N: NextOKKO},
 }
 
 // GenericHCloudOutput unmarshall response into v and return ActionContext with
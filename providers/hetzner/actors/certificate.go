@@ -0,0 +1,295 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/util"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
+)
+
+type hcCertificateWrap struct {
+	hcloud.Certificate
+	ID *string `validate:"required"`
+}
+
+func (v *hcCertificateWrap) unwrap() (*hcloud.Certificate, error) {
+	int64id, err := strconv.ParseInt(*v.ID, 10, 64)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("cannot use '%v' as int64 ID", *v.ID), err)
+	}
+	return &hcloud.Certificate{ID: int64id}, nil
+}
+
+// hcCertificateCreateOptsWrap uses string pointers so the PEM
+// encoded certificate and key can be read from variables
+type hcCertificateCreateOptsWrap struct {
+	Name        *string `validate:"required"`
+	Type        hcloud.CertificateType
+	Certificate *string
+	PrivateKey  *string
+	Labels      map[string]string
+	DomainNames []*string
+}
+
+func (v *hcCertificateCreateOptsWrap) unwrap() *hcloud.CertificateCreateOpts {
+	opts := &hcloud.CertificateCreateOpts{
+		Type:   v.Type,
+		Labels: v.Labels,
+	}
+	if v.Name != nil {
+		opts.Name = *v.Name
+	}
+	if v.Certificate != nil {
+		opts.Certificate = *v.Certificate
+	}
+	if v.PrivateKey != nil {
+		opts.PrivateKey = *v.PrivateKey
+	}
+	for _, dn := range v.DomainNames {
+		if dn != nil {
+			opts.DomainNames = append(opts.DomainNames, *dn)
+		}
+	}
+	return opts
+}
+
+func (v *hcCertificateCreateOptsWrap) Validate() error {
+	return v.unwrap().Validate()
+}
+
+type hcCertificateListOptsWrap struct {
+	hcloud.CertificateListOpts
+	ID *string `json:"id"` // for GetByID
+}
+
+func (v *hcCertificateListOptsWrap) unwrap() (*hcloud.CertificateListOpts, error) {
+	return &hcloud.CertificateListOpts{
+		ListOpts: v.ListOpts,
+		Name:     v.Name,
+		Sort:     v.Sort,
+	}, nil
+}
+
+type CertificateListResponseWithMeta struct {
+	schema.CertificateListResponse
+	Meta schema.Meta `json:"meta"`
+}
+
+func CreateCertificate(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	input := &hcCertificateCreateOptsWrap{}
+	output := &schema.CertificateCreateResponse{}
+
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	_, response, err := ctx.HClient.Certificate.CreateCertificate(ctx.Context(), *input.unwrap())
+	if err != nil {
+		return nil, HCloudErrResponse(err, response)
+	}
+
+	err = UnmarshallHCloudToSchema(response, output)
+	if err != nil {
+		return nil, err
+	}
+	// only managed certificates are issued through an action
+	if output.Action != nil {
+		err = ctx.WaitForAndLog(*output.Action, "Waiting for certificate issuance")
+		if err != nil {
+			return nil, err
+		}
+	}
+	id := fmt.Sprintf("%v", output.Certificate.ID)
+	return base.NewActionOutput(ctx.Action, output, &id), nil
+}
+
+func DeleteCertificate(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	// only Certificate.ID attr is really used
+	input := &hcCertificateWrap{}
+
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	hcert, err := input.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := ctx.HClient.Certificate.Delete(ctx.Context(), hcert)
+	if err != nil {
+		return nil, HCloudErrResponse(err, response)
+	}
+
+	aout := base.NewActionOutput(ctx.Action, nil, nil)
+	return aout, nil
+}
+
+func FindCertificates(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	input := &hcCertificateListOptsWrap{}
+
+	if err := json.Unmarshal(ctx.Action.Parameters, input); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := input.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	if input.ID != nil {
+		int64id, err := strconv.ParseInt(*input.ID, 10, 64)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("cannot use '%v' as int64 ID", *input.ID), err)
+		}
+		_, response, err := ctx.HClient.Certificate.GetByID(ctx.Context(), int64id)
+		if err != nil {
+			return nil, HCloudErrResponse(err, response)
+		}
+		certsch := &schema.CertificateGetResponse{}
+		err = UnmarshallHCloudToSchema(response, certsch)
+		if err != nil {
+			return nil, err
+		}
+		output := &CertificateListResponseWithMeta{
+			Meta: schema.Meta{
+				Pagination: &schema.MetaPagination{
+					Page:         1,
+					LastPage:     1,
+					NextPage:     0,
+					TotalEntries: 1,
+				},
+			},
+		}
+		output.Certificates = []schema.Certificate{certsch.Certificate}
+		return base.NewActionOutput(ctx.Action, output, nil), nil
+	}
+
+	if opts.Page > 0 {
+		// a concrete page was requested, return it as is
+		_, response, err := ctx.HClient.Certificate.List(ctx.Context(), *opts)
+		if err != nil {
+			return nil, HCloudErrResponse(err, response)
+		}
+		return GenericHCloudOutput(ctx, response, &CertificateListResponseWithMeta{})
+	}
+
+	// no page requested, walk all of them
+	var certs []schema.Certificate
+	opts.Page = 1     // min allowed (0 means no page)
+	opts.PerPage = 50 // max allowed
+	for {
+		_, _rsp, err := ctx.HClient.Certificate.List(ctx.Context(), *opts)
+		if err != nil {
+			return nil, HCloudErrResponse(err, _rsp)
+		}
+		_v := &CertificateListResponseWithMeta{}
+		err = UnmarshallHCloudToSchema(_rsp, _v)
+		if err != nil {
+			return nil, err
+		}
+		if len(_v.Certificates) <= 0 {
+			break
+		}
+		certs = append(certs, _v.Certificates...)
+		if _rsp.Meta.Pagination == nil || _rsp.Meta.Pagination.NextPage == 0 {
+			break
+		}
+		opts.Page = _rsp.Meta.Pagination.NextPage
+	}
+	output := &CertificateListResponseWithMeta{
+		Meta: schema.Meta{
+			Pagination: &schema.MetaPagination{
+				Page:         1,
+				LastPage:     1,
+				NextPage:     0,
+				TotalEntries: len(certs),
+			},
+		},
+	}
+	output.Certificates = certs
+	return base.NewActionOutput(ctx.Action, output, nil), nil
+}
+
+func FindOneCertificate(ctx *ActionContext) (*base.ActionOutput, error) {
+	aout, err := FindCertificates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+	if len(aout.Records) <= 0 {
+		return nil, fmt.Errorf("no certificate found")
+	}
+	raw := aout.Records[0].Value.(*CertificateListResponseWithMeta)
+	found := len(raw.Certificates)
+	if found > 1 {
+		return nil, fmt.Errorf("too many results")
+	}
+	if found <= 0 {
+		return nil, fmt.Errorf("no certificate found")
+	}
+	output := &schema.CertificateGetResponse{}
+	output.Certificate = raw.Certificates[0]
+	id := fmt.Sprintf("%v", raw.Certificates[0].ID)
+	aout = base.NewActionOutput(ctx.Action, output, &id)
+	return aout, nil
+}
@@ -23,7 +23,6 @@
 package actors
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -31,7 +30,6 @@ import (
 	"strconv"
 
 	"github.com/develatio/nebulant-cli/base"
-	"github.com/develatio/nebulant-cli/blueprint"
 	"github.com/develatio/nebulant-cli/util"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
@@ -171,12 +169,6 @@ func CreateFirewall(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
-		return nil, err
-	}
-
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -191,7 +183,7 @@ func CreateFirewall(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Firewall.Create(context.Background(), *opts)
+	_, response, err := ctx.HClient.Firewall.Create(ctx.Context(), *opts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -200,15 +192,9 @@ func CreateFirewall(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForManyAndLog(output.Actions, "Waiting for firewall")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForManyAndLog(output.Actions, "Waiting for firewall")
+	if err != nil {
+		return nil, err
 	}
 	id := fmt.Sprintf("%v", output.Firewall.ID)
 	return base.NewActionOutput(ctx.Action, output, &id), nil
@@ -219,7 +205,7 @@ func DeleteFirewall(ctx *ActionContext) (*base.ActionOutput, error) {
 	// only Firewall.ID attr are really used
 	input := &hcFirewallWrap{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -237,7 +223,7 @@ func DeleteFirewall(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	response, err := ctx.HClient.Firewall.Delete(context.Background(), hfwall)
+	response, err := ctx.HClient.Firewall.Delete(ctx.Context(), hfwall)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -249,7 +235,7 @@ func DeleteFirewall(ctx *ActionContext) (*base.ActionOutput, error) {
 func FindFirewalls(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcloud.FirewallListOpts{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -262,7 +248,7 @@ func FindFirewalls(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Firewall.List(context.Background(), *input)
+	_, response, err := ctx.HClient.Firewall.List(ctx.Context(), *input)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -275,7 +261,7 @@ func FindOneFirewall(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	input := &findOneFirewallParameters{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -294,7 +280,7 @@ func FindOneFirewall(ctx *ActionContext) (*base.ActionOutput, error) {
 		if err != nil {
 			return nil, errors.Join(fmt.Errorf("cannot use '%v' as int64 ID", *input.ID), err)
 		}
-		_, response, err := ctx.HClient.Firewall.GetByID(context.Background(), int64id)
+		_, response, err := ctx.HClient.Firewall.GetByID(ctx.Context(), int64id)
 		if err != nil {
 			return nil, HCloudErrResponse(err, response)
 		}
@@ -332,20 +318,14 @@ func ApplyFirewallToResources(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &applyResourcesParameters{}
 	output := &schema.FirewallActionApplyToResourcesResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
 	if ctx.Rehearsal {
 		return nil, nil
 	}
-	internalparams := &blueprint.InternalParameters{}
-	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
-		return nil, err
-	}
-
-	err = ctx.Store.DeepInterpolation(input)
+	err := ctx.Store.DeepInterpolation(input)
 	if err != nil {
 		return nil, err
 	}
@@ -364,7 +344,7 @@ func ApplyFirewallToResources(ctx *ActionContext) (*base.ActionOutput, error) {
 		resources = append(resources, *hres)
 	}
 
-	_, response, err := ctx.HClient.Firewall.ApplyResources(context.Background(), hfwall, resources)
+	_, response, err := ctx.HClient.Firewall.ApplyResources(ctx.Context(), hfwall, resources)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -373,15 +353,9 @@ func ApplyFirewallToResources(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForManyAndLog(output.Actions, "Waiting for fw resources")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForManyAndLog(output.Actions, "Waiting for fw resources")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -390,16 +364,11 @@ func RemoveFirewallFromResources(ctx *ActionContext) (*base.ActionOutput, error)
 	input := &removeResourcesParameters{}
 	output := &schema.FirewallActionRemoveFromResourcesResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
+	var err error
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -423,7 +392,7 @@ func RemoveFirewallFromResources(ctx *ActionContext) (*base.ActionOutput, error)
 		resources = append(resources, *hres)
 	}
 
-	_, response, err := ctx.HClient.Firewall.RemoveResources(context.Background(), hfwall, resources)
+	_, response, err := ctx.HClient.Firewall.RemoveResources(ctx.Context(), hfwall, resources)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -432,15 +401,9 @@ func RemoveFirewallFromResources(ctx *ActionContext) (*base.ActionOutput, error)
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForManyAndLog(output.Actions, "Waiting firewall rm")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForManyAndLog(output.Actions, "Waiting firewall rm")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -449,16 +412,11 @@ func SetRulesFirewall(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &setRulesParameters{}
 	output := &schema.FirewallActionSetRulesResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
+	var err error
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -473,7 +431,7 @@ func SetRulesFirewall(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Firewall.SetRules(context.Background(), hfwall, input.Opts)
+	_, response, err := ctx.HClient.Firewall.SetRules(ctx.Context(), hfwall, input.Opts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -482,15 +440,9 @@ func SetRulesFirewall(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForManyAndLog(output.Actions, "Waiting for firewall rules set")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForManyAndLog(output.Actions, "Waiting for firewall rules set")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -23,14 +23,11 @@
 package actors
 
 import (
-	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
 
 	"github.com/develatio/nebulant-cli/base"
-	"github.com/develatio/nebulant-cli/blueprint"
 	"github.com/develatio/nebulant-cli/util"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
@@ -77,13 +74,7 @@ func CreateFloatingIP(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcloud.FloatingIPCreateOpts{}
 	output := &schema.FloatingIPCreateResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -96,7 +87,7 @@ func CreateFloatingIP(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.FloatingIP.Create(context.Background(), *input)
+	_, response, err := ctx.HClient.FloatingIP.Create(ctx.Context(), *input)
 	if err != nil {
 		return nil, err
 	}
@@ -105,14 +96,10 @@ func CreateFloatingIP(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" && output.Action != nil {
-				err = ctx.WaitForAndLog(*output.Action, "Waiting for floating ip")
-				if err != nil {
-					return nil, err
-				}
-			}
+	if output.Action != nil {
+		err = ctx.WaitForAndLog(*output.Action, "Waiting for floating ip")
+		if err != nil {
+			return nil, err
 		}
 	}
 	id := fmt.Sprintf("%v", output.FloatingIP.ID)
@@ -125,7 +112,7 @@ func DeleteFloatingIP(ctx *ActionContext) (*base.ActionOutput, error) {
 	// https://github.com/hetznercloud/hcloud-go/blob/v2.3.0/hcloud/floating_ip.go#L279
 	input := &hcFloatingIPWrap{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -143,7 +130,7 @@ func DeleteFloatingIP(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, err = ctx.HClient.FloatingIP.Delete(context.Background(), hfip)
+	_, err = ctx.HClient.FloatingIP.Delete(ctx.Context(), hfip)
 	if err != nil {
 		return nil, err
 	}
@@ -155,7 +142,7 @@ func DeleteFloatingIP(ctx *ActionContext) (*base.ActionOutput, error) {
 func FindFloatingIPs(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcloud.FloatingIPListOpts{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -163,7 +150,7 @@ func FindFloatingIPs(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, nil
 	}
 
-	_, response, err := ctx.HClient.FloatingIP.List(context.Background(), *input)
+	_, response, err := ctx.HClient.FloatingIP.List(ctx.Context(), *input)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -176,7 +163,7 @@ func FindOneFloatingIP(ctx *ActionContext) (*base.ActionOutput, error) {
 	var err error
 	input := &findOneFloatingIPParameters{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -195,7 +182,7 @@ func FindOneFloatingIP(ctx *ActionContext) (*base.ActionOutput, error) {
 		if err != nil {
 			return nil, errors.Join(fmt.Errorf("cannot use '%v' as int64 ID", *input.ID), err)
 		}
-		_, response, err := ctx.HClient.FloatingIP.GetByID(context.Background(), int64id)
+		_, response, err := ctx.HClient.FloatingIP.GetByID(ctx.Context(), int64id)
 		if err != nil {
 			return nil, HCloudErrResponse(err, response)
 		}
@@ -231,13 +218,7 @@ func AssignFloatingIP(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &assignFloatingIPParameters{}
 	output := &schema.FloatingIPActionAssignResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -260,7 +241,7 @@ func AssignFloatingIP(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.FloatingIP.Assign(context.Background(), hfip, hsrv)
+	_, response, err := ctx.HClient.FloatingIP.Assign(ctx.Context(), hfip, hsrv)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -269,15 +250,9 @@ func AssignFloatingIP(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for floating ip assignation")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for floating ip assignation")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -287,13 +262,7 @@ func UnassignFloatingIP(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &unassignFloatingIPParameters{}
 	output := &schema.FloatingIPActionUnassignResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -311,7 +280,7 @@ func UnassignFloatingIP(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.FloatingIP.Unassign(context.Background(), hfip)
+	_, response, err := ctx.HClient.FloatingIP.Unassign(ctx.Context(), hfip)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -320,15 +289,9 @@ func UnassignFloatingIP(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for floating ip unassignation")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for floating ip unassignation")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
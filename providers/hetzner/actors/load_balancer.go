@@ -23,8 +23,6 @@
 package actors
 
 import (
-	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -33,7 +31,6 @@ import (
 	"time"
 
 	"github.com/develatio/nebulant-cli/base"
-	"github.com/develatio/nebulant-cli/blueprint"
 	"github.com/develatio/nebulant-cli/util"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
@@ -496,13 +493,7 @@ func CreateLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcLoadBalancerCreateOptsWrap{}
 	output := &schema.LoadBalancerCreateResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -520,7 +511,7 @@ func CreateLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.LoadBalancer.Create(context.Background(), *opts)
+	_, response, err := ctx.HClient.LoadBalancer.Create(ctx.Context(), *opts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -529,15 +520,9 @@ func CreateLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for load balancer")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for load balancer")
+	if err != nil {
+		return nil, err
 	}
 	id := fmt.Sprintf("%v", output.LoadBalancer.ID)
 	return base.NewActionOutput(ctx.Action, output, &id), nil
@@ -548,7 +533,7 @@ func DeleteLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 	// only LoadBalancer.ID attr is really used
 	input := &hcLoadBalancerWrap{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -566,7 +551,7 @@ func DeleteLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, err = ctx.HClient.LoadBalancer.Delete(context.Background(), hlb)
+	_, err = ctx.HClient.LoadBalancer.Delete(ctx.Context(), hlb)
 	if err != nil {
 		return nil, err
 	}
@@ -578,7 +563,7 @@ func DeleteLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 func FindLoadBalancers(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcloud.LoadBalancerListOpts{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -591,7 +576,7 @@ func FindLoadBalancers(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.LoadBalancer.List(context.Background(), *input)
+	_, response, err := ctx.HClient.LoadBalancer.List(ctx.Context(), *input)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -629,16 +614,11 @@ func FindOneLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 func AttachLoadBalancerToNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &loadbalancerAttachToNetworkParameters{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
+	var err error
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -663,7 +643,7 @@ func AttachLoadBalancerToNetwork(ctx *ActionContext) (*base.ActionOutput, error)
 	if input.AttachOpts.lookupAvailableIP != nil {
 		ipnet := input.AttachOpts.lookupAvailableIP
 		hnetID := opts.Network.ID
-		hnet, response, err := ctx.HClient.Network.GetByID(context.Background(), hnetID)
+		hnet, response, err := ctx.HClient.Network.GetByID(ctx.Context(), hnetID)
 		if err != nil {
 			return nil, HCloudErrResponse(err, response)
 		}
@@ -701,7 +681,7 @@ func AttachLoadBalancerToNetwork(ctx *ActionContext) (*base.ActionOutput, error)
 				return nil, fmt.Errorf("cannot determine a valid ip for subnet %s", ipnet.String())
 			}
 			opts.IP = net.ParseIP(addr.String())
-			_, response, err := ctx.HClient.LoadBalancer.AttachToNetwork(context.Background(), hlb, *opts)
+			_, response, err := ctx.HClient.LoadBalancer.AttachToNetwork(ctx.Context(), hlb, *opts)
 			if herr, ok := err.(hcloud.Error); ok {
 				if herr.Code == hcloud.ErrorCodeIPNotAvailable {
 					// ok, already used ip, keep trying
@@ -718,20 +698,14 @@ func AttachLoadBalancerToNetwork(ctx *ActionContext) (*base.ActionOutput, error)
 			if err != nil {
 				return nil, err
 			}
-			if internalparams.Waiters != nil {
-				for _, wnam := range internalparams.Waiters {
-					if wnam == "success" {
-						err = ctx.WaitForAndLog(output.Action, "Waiting for lb attach")
-						if err != nil {
-							return nil, err
-						}
-					}
-				}
+			err = ctx.WaitForAndLog(output.Action, "Waiting for lb attach")
+			if err != nil {
+				return nil, err
 			}
 			return aout, err
 		}
 	}
-	_, response, err := ctx.HClient.LoadBalancer.AttachToNetwork(context.Background(), hlb, *opts)
+	_, response, err := ctx.HClient.LoadBalancer.AttachToNetwork(ctx.Context(), hlb, *opts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -741,15 +715,9 @@ func AttachLoadBalancerToNetwork(ctx *ActionContext) (*base.ActionOutput, error)
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for lb attach")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for lb attach")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -758,16 +726,11 @@ func DetachLoadBalancerFromNetwork(ctx *ActionContext) (*base.ActionOutput, erro
 	input := &loadbalancerDetachFromNetworkParameters{}
 	output := &schema.LoadBalancerActionDetachFromNetworkResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
+	var err error
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -786,7 +749,7 @@ func DetachLoadBalancerFromNetwork(ctx *ActionContext) (*base.ActionOutput, erro
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.LoadBalancer.DetachFromNetwork(context.Background(), hlb, *opts)
+	_, response, err := ctx.HClient.LoadBalancer.DetachFromNetwork(ctx.Context(), hlb, *opts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -795,15 +758,9 @@ func DetachLoadBalancerFromNetwork(ctx *ActionContext) (*base.ActionOutput, erro
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for lb detach")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for lb detach")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -812,16 +769,11 @@ func AddTargetToLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &loadbalancerAddTargetParameters{}
 	output := &schema.LoadBalancerActionAddTargetResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
+	var err error
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -852,7 +804,7 @@ func AddTargetToLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 		if input.ServerOpts != nil && input.ServerOpts.UsePrivateIP != nil {
 			opts.UsePrivateIP = input.ServerOpts.UsePrivateIP
 		}
-		_, response, err = ctx.HClient.LoadBalancer.AddServerTarget(context.Background(), hlb, *opts)
+		_, response, err = ctx.HClient.LoadBalancer.AddServerTarget(ctx.Context(), hlb, *opts)
 		if err != nil {
 			return nil, err
 		}
@@ -864,7 +816,7 @@ func AddTargetToLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 		if err != nil {
 			return nil, err
 		}
-		_, response, err = ctx.HClient.LoadBalancer.AddIPTarget(context.Background(), hlb, *opts)
+		_, response, err = ctx.HClient.LoadBalancer.AddIPTarget(ctx.Context(), hlb, *opts)
 		if err != nil {
 			return nil, err
 		}
@@ -872,7 +824,7 @@ func AddTargetToLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 		if input.LabelSelectorOpts == nil {
 			return nil, fmt.Errorf("please, set label selector opts (label_selector_opts)")
 		}
-		_, response, err = ctx.HClient.LoadBalancer.AddLabelSelectorTarget(context.Background(), hlb, *input.LabelSelectorOpts)
+		_, response, err = ctx.HClient.LoadBalancer.AddLabelSelectorTarget(ctx.Context(), hlb, *input.LabelSelectorOpts)
 		if err != nil {
 			return nil, err
 		}
@@ -885,15 +837,9 @@ func AddTargetToLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for target addition")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for target addition")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -902,16 +848,11 @@ func RemoveTargetFromLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error
 	input := &loadbalancerRemoveTargetParameters{}
 	output := &schema.LoadBalancerActionRemoveTargetResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
+	var err error
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -936,7 +877,7 @@ func RemoveTargetFromLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error
 		if err != nil {
 			return nil, err
 		}
-		_, response, err = ctx.HClient.LoadBalancer.RemoveServerTarget(context.Background(), hlb, hsrv)
+		_, response, err = ctx.HClient.LoadBalancer.RemoveServerTarget(ctx.Context(), hlb, hsrv)
 		if err != nil {
 			return nil, err
 		}
@@ -948,12 +889,12 @@ func RemoveTargetFromLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error
 		if ip == nil {
 			return nil, fmt.Errorf("invalid ip addr")
 		}
-		_, response, err = ctx.HClient.LoadBalancer.RemoveIPTarget(context.Background(), hlb, ip)
+		_, response, err = ctx.HClient.LoadBalancer.RemoveIPTarget(ctx.Context(), hlb, ip)
 		if err != nil {
 			return nil, err
 		}
 	case "label_selector":
-		_, response, err = ctx.HClient.LoadBalancer.RemoveLabelSelectorTarget(context.Background(), hlb, *input.LabelSelector)
+		_, response, err = ctx.HClient.LoadBalancer.RemoveLabelSelectorTarget(ctx.Context(), hlb, *input.LabelSelector)
 		if err != nil {
 			return nil, err
 		}
@@ -966,15 +907,9 @@ func RemoveTargetFromLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for target rm from lb")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for target rm from lb")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -983,16 +918,11 @@ func AddServiceToLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &loadbalancerAddServiceParameters{}
 	output := &schema.LoadBalancerActionAddServiceResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
+	var err error
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -1011,7 +941,7 @@ func AddServiceToLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.LoadBalancer.AddService(context.Background(), hlb, *opts)
+	_, response, err := ctx.HClient.LoadBalancer.AddService(ctx.Context(), hlb, *opts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -1020,31 +950,27 @@ func AddServiceToLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for service attach")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for service attach")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
 
 func DeleteServiceFromLoadBalancer(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &loadbalancerDeleteServiceParameters{}
+	output := &schema.LoadBalancerDeleteServiceResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
+	var err error
 	if ctx.Rehearsal {
 		return nil, nil
 	}
 
-	err := ctx.Store.DeepInterpolation(input)
+	err = ctx.Store.DeepInterpolation(input)
 	if err != nil {
 		return nil, err
 	}
@@ -1059,11 +985,18 @@ func DeleteServiceFromLoadBalancer(ctx *ActionContext) (*base.ActionOutput, erro
 		return nil, errors.Join(fmt.Errorf("cannot use '%v' as listen port", input.ListenPort), err)
 	}
 
-	_, response, err := ctx.HClient.LoadBalancer.DeleteService(context.Background(), hlb, int(intPort))
+	_, response, err := ctx.HClient.LoadBalancer.DeleteService(ctx.Context(), hlb, int(intPort))
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
 
-	aout := base.NewActionOutput(ctx.Action, nil, nil)
-	return aout, nil
+	aout, err := GenericHCloudOutput(ctx, response, output)
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for service rm from lb")
+	if err != nil {
+		return nil, err
+	}
+	return aout, err
 }
@@ -23,7 +23,6 @@
 package actors
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -31,7 +30,6 @@ import (
 	"strconv"
 
 	"github.com/develatio/nebulant-cli/base"
-	"github.com/develatio/nebulant-cli/blueprint"
 	"github.com/develatio/nebulant-cli/util"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
@@ -260,7 +258,7 @@ func CreateNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Network.Create(context.Background(), *opts)
+	_, response, err := ctx.HClient.Network.Create(ctx.Context(), *opts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -279,7 +277,7 @@ func DeleteNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 	// only Network.ID attr are really used
 	input := &hcNetworkWrap{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -297,7 +295,7 @@ func DeleteNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, err = ctx.HClient.Network.Delete(context.Background(), hnet)
+	_, err = ctx.HClient.Network.Delete(ctx.Context(), hnet)
 	if err != nil {
 		return nil, err
 	}
@@ -309,7 +307,7 @@ func DeleteNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 func FindNetworks(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcloud.NetworkListOpts{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -322,7 +320,7 @@ func FindNetworks(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Network.List(context.Background(), *input)
+	_, response, err := ctx.HClient.Network.List(ctx.Context(), *input)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -362,13 +360,7 @@ func AddSubnetToNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcNetworkAddSubnetOptsWrap{}
 	output := &schema.NetworkActionAddSubnetResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -390,7 +382,7 @@ func AddSubnetToNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Network.AddSubnet(context.Background(), hnet, *opts)
+	_, response, err := ctx.HClient.Network.AddSubnet(ctx.Context(), hnet, *opts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -399,15 +391,9 @@ func AddSubnetToNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for subnet addition to net")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for subnet addition to net")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -417,13 +403,7 @@ func DeleteSubnetFromNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcNetworkDeleteSubnetOptsWrap{}
 	output := &schema.NetworkActionDeleteSubnetResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -445,7 +425,7 @@ func DeleteSubnetFromNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Network.DeleteSubnet(context.Background(), hnet, *opts)
+	_, response, err := ctx.HClient.Network.DeleteSubnet(ctx.Context(), hnet, *opts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -454,15 +434,9 @@ func DeleteSubnetFromNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for subnet deletion from net")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for subnet deletion from net")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -472,13 +446,7 @@ func AddRouteToNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcNetworkAddRouteOptsWrap{}
 	output := &schema.NetworkActionAddRouteResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -500,7 +468,7 @@ func AddRouteToNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Network.AddRoute(context.Background(), hnet, *opts)
+	_, response, err := ctx.HClient.Network.AddRoute(ctx.Context(), hnet, *opts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -509,15 +477,9 @@ func AddRouteToNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for route addition to net")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for route addition to net")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -527,13 +489,7 @@ func DeleteRouteFromNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcNetworkDeleteRouteOptsWrap{}
 	output := &schema.NetworkActionDeleteRouteResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -555,7 +511,7 @@ func DeleteRouteFromNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Network.DeleteRoute(context.Background(), hnet, *opts)
+	_, response, err := ctx.HClient.Network.DeleteRoute(ctx.Context(), hnet, *opts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -564,15 +520,9 @@ func DeleteRouteFromNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for route deletion from net")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for route deletion from net")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -0,0 +1,258 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/util"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
+)
+
+type hcPlacementGroupWrap struct {
+	hcloud.PlacementGroup
+	ID *string `validate:"required"`
+}
+
+func (v *hcPlacementGroupWrap) unwrap() (*hcloud.PlacementGroup, error) {
+	int64id, err := strconv.ParseInt(*v.ID, 10, 64)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("cannot use '%v' as int64 ID", *v.ID), err)
+	}
+	return &hcloud.PlacementGroup{ID: int64id}, nil
+}
+
+type hcPlacementGroupListOptsWrap struct {
+	hcloud.PlacementGroupListOpts
+	ID *string `json:"id"` // for GetByID
+}
+
+func (v *hcPlacementGroupListOptsWrap) unwrap() (*hcloud.PlacementGroupListOpts, error) {
+	return &hcloud.PlacementGroupListOpts{
+		ListOpts: v.ListOpts,
+		Name:     v.Name,
+		Type:     v.Type,
+		Sort:     v.Sort,
+	}, nil
+}
+
+type PlacementGroupListResponseWithMeta struct {
+	schema.PlacementGroupListResponse
+	Meta schema.Meta `json:"meta"`
+}
+
+func CreatePlacementGroup(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	input := &hcloud.PlacementGroupCreateOpts{}
+	output := &schema.PlacementGroupCreateResponse{}
+
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	_, response, err := ctx.HClient.PlacementGroup.Create(ctx.Context(), *input)
+	if err != nil {
+		return nil, HCloudErrResponse(err, response)
+	}
+
+	err = UnmarshallHCloudToSchema(response, output)
+	if err != nil {
+		return nil, err
+	}
+	if output.Action != nil {
+		err = ctx.WaitForAndLog(*output.Action, "Waiting for placement group")
+		if err != nil {
+			return nil, err
+		}
+	}
+	id := fmt.Sprintf("%v", output.PlacementGroup.ID)
+	return base.NewActionOutput(ctx.Action, output, &id), nil
+}
+
+func DeletePlacementGroup(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	// only PlacementGroup.ID attr is really used
+	input := &hcPlacementGroupWrap{}
+
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	hpg, err := input.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := ctx.HClient.PlacementGroup.Delete(ctx.Context(), hpg)
+	if err != nil {
+		return nil, HCloudErrResponse(err, response)
+	}
+
+	aout := base.NewActionOutput(ctx.Action, nil, nil)
+	return aout, nil
+}
+
+func FindPlacementGroups(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	input := &hcPlacementGroupListOptsWrap{}
+
+	if err := json.Unmarshal(ctx.Action.Parameters, input); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := input.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	if input.ID != nil {
+		int64id, err := strconv.ParseInt(*input.ID, 10, 64)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("cannot use '%v' as int64 ID", *input.ID), err)
+		}
+		_, response, err := ctx.HClient.PlacementGroup.GetByID(ctx.Context(), int64id)
+		if err != nil {
+			return nil, HCloudErrResponse(err, response)
+		}
+		pgsch := &schema.PlacementGroupGetResponse{}
+		err = UnmarshallHCloudToSchema(response, pgsch)
+		if err != nil {
+			return nil, err
+		}
+		output := &PlacementGroupListResponseWithMeta{
+			Meta: schema.Meta{
+				Pagination: &schema.MetaPagination{
+					Page:         1,
+					LastPage:     1,
+					NextPage:     0,
+					TotalEntries: 1,
+				},
+			},
+		}
+		output.PlacementGroups = []schema.PlacementGroup{pgsch.PlacementGroup}
+		return base.NewActionOutput(ctx.Action, output, nil), nil
+	}
+
+	if opts.Page > 0 {
+		// a concrete page was requested, return it as is
+		_, response, err := ctx.HClient.PlacementGroup.List(ctx.Context(), *opts)
+		if err != nil {
+			return nil, HCloudErrResponse(err, response)
+		}
+		return GenericHCloudOutput(ctx, response, &PlacementGroupListResponseWithMeta{})
+	}
+
+	// no page requested, walk all of them
+	var pgs []schema.PlacementGroup
+	opts.Page = 1     // min allowed (0 means no page)
+	opts.PerPage = 50 // max allowed
+	for {
+		_, _rsp, err := ctx.HClient.PlacementGroup.List(ctx.Context(), *opts)
+		if err != nil {
+			return nil, HCloudErrResponse(err, _rsp)
+		}
+		_v := &PlacementGroupListResponseWithMeta{}
+		err = UnmarshallHCloudToSchema(_rsp, _v)
+		if err != nil {
+			return nil, err
+		}
+		if len(_v.PlacementGroups) <= 0 {
+			break
+		}
+		pgs = append(pgs, _v.PlacementGroups...)
+		if _rsp.Meta.Pagination == nil || _rsp.Meta.Pagination.NextPage == 0 {
+			break
+		}
+		opts.Page = _rsp.Meta.Pagination.NextPage
+	}
+	output := &PlacementGroupListResponseWithMeta{
+		Meta: schema.Meta{
+			Pagination: &schema.MetaPagination{
+				Page:         1,
+				LastPage:     1,
+				NextPage:     0,
+				TotalEntries: len(pgs),
+			},
+		},
+	}
+	output.PlacementGroups = pgs
+	return base.NewActionOutput(ctx.Action, output, nil), nil
+}
+
+func FindOnePlacementGroup(ctx *ActionContext) (*base.ActionOutput, error) {
+	aout, err := FindPlacementGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+	if len(aout.Records) <= 0 {
+		return nil, fmt.Errorf("no placement group found")
+	}
+	raw := aout.Records[0].Value.(*PlacementGroupListResponseWithMeta)
+	found := len(raw.PlacementGroups)
+	if found > 1 {
+		return nil, fmt.Errorf("too many results")
+	}
+	if found <= 0 {
+		return nil, fmt.Errorf("no placement group found")
+	}
+	output := &schema.PlacementGroupGetResponse{}
+	output.PlacementGroup = raw.PlacementGroups[0]
+	id := fmt.Sprintf("%v", raw.PlacementGroups[0].ID)
+	aout = base.NewActionOutput(ctx.Action, output, &id)
+	return aout, nil
+}
@@ -0,0 +1,74 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"encoding/json"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
+)
+
+type hcPricingOpts struct {
+	// narrows server_types prices to the given server type name
+	ServerType *string `json:"server_type"`
+}
+
+func FindPricing(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	input := &hcPricingOpts{}
+
+	if err := json.Unmarshal(ctx.Action.Parameters, input); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	_, response, err := ctx.HClient.Pricing.Get(ctx.Context())
+	if err != nil {
+		return nil, HCloudErrResponse(err, response)
+	}
+
+	output := &schema.PricingGetResponse{}
+	err = UnmarshallHCloudToSchema(response, output)
+	if err != nil {
+		return nil, err
+	}
+	if input.ServerType != nil {
+		var sts []schema.PricingServerType
+		for _, st := range output.Pricing.ServerTypes {
+			if st.Name == *input.ServerType {
+				sts = append(sts, st)
+			}
+		}
+		output.Pricing.ServerTypes = sts
+	}
+	return base.NewActionOutput(ctx.Action, output, nil), nil
+}
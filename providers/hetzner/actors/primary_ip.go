@@ -23,14 +23,11 @@
 package actors
 
 import (
-	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
 
 	"github.com/develatio/nebulant-cli/base"
-	"github.com/develatio/nebulant-cli/blueprint"
 	"github.com/develatio/nebulant-cli/util"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
@@ -105,13 +102,7 @@ func CreatePrimaryIP(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcPrimaryIPCreateOptsWrap{}
 	output := &schema.PrimaryIPCreateResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -129,7 +120,7 @@ func CreatePrimaryIP(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.PrimaryIP.Create(context.Background(), *hipcreateopts)
+	_, response, err := ctx.HClient.PrimaryIP.Create(ctx.Context(), *hipcreateopts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -138,14 +129,10 @@ func CreatePrimaryIP(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" && output.Action != nil {
-				err = ctx.WaitForAndLog(*output.Action, "Waiting for primary ip")
-				if err != nil {
-					return nil, err
-				}
-			}
+	if output.Action != nil {
+		err = ctx.WaitForAndLog(*output.Action, "Waiting for primary ip")
+		if err != nil {
+			return nil, err
 		}
 	}
 	id := fmt.Sprintf("%v", output.PrimaryIP.ID)
@@ -157,7 +144,7 @@ func DeletePrimaryIP(ctx *ActionContext) (*base.ActionOutput, error) {
 	// only PrimaryIP.ID attr are really used
 	input := &hcPrimaryIPWrap{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -175,7 +162,7 @@ func DeletePrimaryIP(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, err = ctx.HClient.PrimaryIP.Delete(context.Background(), hip)
+	_, err = ctx.HClient.PrimaryIP.Delete(ctx.Context(), hip)
 	if err != nil {
 		return nil, err
 	}
@@ -187,7 +174,7 @@ func DeletePrimaryIP(ctx *ActionContext) (*base.ActionOutput, error) {
 func FindPrimaryIPs(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcloud.PrimaryIPListOpts{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -200,7 +187,7 @@ func FindPrimaryIPs(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.PrimaryIP.List(context.Background(), *input)
+	_, response, err := ctx.HClient.PrimaryIP.List(ctx.Context(), *input)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -240,16 +227,11 @@ func AssignPrimaryIP(ctx *ActionContext) (*base.ActionOutput, error) {
 	// ok to use hcloud instead scheme here
 	output := &hcloud.PrimaryIPAssignResult{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
+	var err error
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -264,7 +246,7 @@ func AssignPrimaryIP(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.PrimaryIP.Assign(context.Background(), *hipassignopts)
+	_, response, err := ctx.HClient.PrimaryIP.Assign(ctx.Context(), *hipassignopts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -273,15 +255,9 @@ func AssignPrimaryIP(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for primary ip assignation")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for primary ip assignation")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -290,16 +266,11 @@ func UnassignPrimaryIP(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &unassignPrimaryIPParameters{}
 	output := &hcloud.PrimaryIPAssignResult{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
+	var err error
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -314,7 +285,7 @@ func UnassignPrimaryIP(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, errors.Join(fmt.Errorf("cannot use '%v' as int64 ID", input.ID), err)
 	}
 
-	_, response, err := ctx.HClient.PrimaryIP.Unassign(context.Background(), int64id)
+	_, response, err := ctx.HClient.PrimaryIP.Unassign(ctx.Context(), int64id)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -323,15 +294,9 @@ func UnassignPrimaryIP(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for primary ip unassignation")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for primary ip unassignation")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -23,7 +23,6 @@
 package actors
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -32,7 +31,6 @@ import (
 	"encoding/json"
 
 	"github.com/develatio/nebulant-cli/base"
-	"github.com/develatio/nebulant-cli/blueprint"
 	"github.com/develatio/nebulant-cli/util"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
@@ -216,12 +214,6 @@ func CreateServer(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
-		return nil, err
-	}
-
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -236,7 +228,7 @@ func CreateServer(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Server.Create(context.Background(), *hopts)
+	_, response, err := ctx.HClient.Server.Create(ctx.Context(), *hopts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -245,33 +237,28 @@ func CreateServer(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for server")
-				if err != nil {
-					return nil, err
-				}
-				err = ctx.WaitForManyAndLog(output.NextActions, "Waiting for actions post server creation")
-				if err != nil {
-					return nil, err
-				}
-				if output.Server.ID == 0 {
-					out := &schema.ActionGetResponse{}
-					_, rsp, err := ctx.HClient.Action.GetByID(context.Background(), output.Action.ID)
-					if err != nil {
-						return nil, err
-					}
-					err = UnmarshallHCloudToSchema(rsp, out)
-					if err != nil {
-						return nil, err
-					}
-					for _, rr := range out.Action.Resources {
-						if rr.Type == "server" {
-							output.Server.ID = rr.ID
-						}
-					}
-				}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for server")
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.WaitForManyAndLog(output.NextActions, "Waiting for actions post server creation")
+	if err != nil {
+		return nil, err
+	}
+	if output.Server.ID == 0 {
+		out := &schema.ActionGetResponse{}
+		_, rsp, err := ctx.HClient.Action.GetByID(ctx.Context(), output.Action.ID)
+		if err != nil {
+			// not wrapped, the server exists and must not be retried
+			return nil, fmt.Errorf("cannot get the server of action %v: %v", output.Action.ID, err)
+		}
+		err = UnmarshallHCloudToSchema(rsp, out)
+		if err != nil {
+			return nil, err
+		}
+		for _, rr := range out.Action.Resources {
+			if rr.Type == "server" {
+				output.Server.ID = rr.ID
 			}
 		}
 	}
@@ -289,13 +276,7 @@ func DeleteServer(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcServerWrap{}
 	output := &schema.ServerDeleteResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -313,7 +294,7 @@ func DeleteServer(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Server.DeleteWithResult(context.Background(), hsrv)
+	_, response, err := ctx.HClient.Server.DeleteWithResult(ctx.Context(), hsrv)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -322,15 +303,9 @@ func DeleteServer(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for server delete")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for server delete")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -338,7 +313,7 @@ func DeleteServer(ctx *ActionContext) (*base.ActionOutput, error) {
 func FindServers(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcloud.ServerListOpts{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -351,7 +326,7 @@ func FindServers(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Server.List(context.Background(), *input)
+	_, response, err := ctx.HClient.Server.List(ctx.Context(), *input)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -363,7 +338,7 @@ func FindServers(ctx *ActionContext) (*base.ActionOutput, error) {
 func FindOneServer(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &findOneServerParameters{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -379,7 +354,7 @@ func FindOneServer(ctx *ActionContext) (*base.ActionOutput, error) {
 		if err != nil {
 			return nil, errors.Join(fmt.Errorf("cannot use '%v' as int64 ID", *input.ID), err)
 		}
-		_, response, err := ctx.HClient.Server.GetByID(context.Background(), int64id)
+		_, response, err := ctx.HClient.Server.GetByID(ctx.Context(), int64id)
 		if err != nil {
 			return nil, HCloudErrResponse(err, response)
 		}
@@ -428,13 +403,7 @@ func PowerOnServer(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcServerWrap{}
 	output := &schema.ServerActionPoweronResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -452,7 +421,7 @@ func PowerOnServer(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Server.Poweron(context.Background(), hsrv)
+	_, response, err := ctx.HClient.Server.Poweron(ctx.Context(), hsrv)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -461,15 +430,9 @@ func PowerOnServer(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for server power on")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for server power on")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -480,13 +443,7 @@ func PowerOffServer(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcServerWrap{}
 	output := &schema.ServerActionPoweroffResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -504,7 +461,7 @@ func PowerOffServer(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Server.Poweroff(context.Background(), hsrv)
+	_, response, err := ctx.HClient.Server.Poweroff(ctx.Context(), hsrv)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -513,15 +470,9 @@ func PowerOffServer(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for server power off")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for server power off")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -535,12 +486,6 @@ func AttachServerToNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
-		return nil, err
-	}
-
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -560,7 +505,7 @@ func AttachServerToNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Server.AttachToNetwork(context.Background(), hsrv, *opts)
+	_, response, err := ctx.HClient.Server.AttachToNetwork(ctx.Context(), hsrv, *opts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -569,15 +514,9 @@ func AttachServerToNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for server attach to net")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for server attach to net")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -591,12 +530,6 @@ func DetachServerFromNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
-		return nil, err
-	}
-
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -616,7 +549,7 @@ func DetachServerFromNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Server.DetachFromNetwork(context.Background(), hsrv, *opts)
+	_, response, err := ctx.HClient.Server.DetachFromNetwork(ctx.Context(), hsrv, *opts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -625,15 +558,9 @@ func DetachServerFromNetwork(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for server detach from net")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for server detach from net")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -647,12 +574,6 @@ func CreateImageFromServer(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
-		return nil, err
-	}
-
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -672,7 +593,7 @@ func CreateImageFromServer(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Server.CreateImage(context.Background(), hsrv, opts)
+	_, response, err := ctx.HClient.Server.CreateImage(ctx.Context(), hsrv, opts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -681,16 +602,459 @@ func CreateImageFromServer(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for image creation")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for image creation")
+	if err != nil {
+		return nil, err
 	}
 	id := fmt.Sprintf("%v", output.Image.ID)
 	return base.NewActionOutput(ctx.Action, output, &id), nil
 }
+
+type hcServerRebuildOptsWrap struct {
+	hcloud.ServerRebuildOpts
+	Server *hcServerWrap `json:"server" validate:"required"`
+	Image  *hcImageWrap  `validate:"required"`
+}
+
+func (v *hcServerRebuildOptsWrap) unwrap() (*hcloud.ServerRebuildOpts, error) {
+	him, err := v.Image.unwrap()
+	if err != nil {
+		return nil, err
+	}
+	return &hcloud.ServerRebuildOpts{Image: him}, nil
+}
+
+type hcServerChangeTypeOptsWrap struct {
+	hcloud.ServerChangeTypeOpts
+	Server *hcServerWrap `json:"server" validate:"required"`
+}
+
+func (v *hcServerChangeTypeOptsWrap) Validate() error {
+	if v.ServerType == nil || (v.ServerType.ID == 0 && v.ServerType.Name == "") {
+		return fmt.Errorf("server type required")
+	}
+	return nil
+}
+
+func (v *hcServerChangeTypeOptsWrap) unwrap() (*hcloud.ServerChangeTypeOpts, error) {
+	return &hcloud.ServerChangeTypeOpts{
+		ServerType:  v.ServerType,
+		UpgradeDisk: v.UpgradeDisk,
+	}, nil
+}
+
+type hcServerEnableRescueOptsWrap struct {
+	hcloud.ServerEnableRescueOpts
+	Server  *hcServerWrap `json:"server" validate:"required"`
+	SSHKeys []*hcSSHKeyWrap
+}
+
+func (v *hcServerEnableRescueOptsWrap) unwrap() (*hcloud.ServerEnableRescueOpts, error) {
+	out := &hcloud.ServerEnableRescueOpts{
+		Type: v.Type,
+	}
+	if out.Type == "" {
+		out.Type = hcloud.ServerRescueTypeLinux64
+	}
+	for _, s := range v.SSHKeys {
+		hssh, err := s.unwrap()
+		if err != nil {
+			return nil, err
+		}
+		out.SSHKeys = append(out.SSHKeys, hssh)
+	}
+	return out, nil
+}
+
+type hcServerChangeProtectionOptsWrap struct {
+	hcloud.ServerChangeProtectionOpts
+	Server *hcServerWrap `json:"server" validate:"required"`
+}
+
+func (v *hcServerChangeProtectionOptsWrap) Validate() error {
+	if v.Rebuild == nil && v.Delete == nil {
+		return fmt.Errorf("please set delete and/or rebuild protection")
+	}
+	return nil
+}
+
+func (v *hcServerChangeProtectionOptsWrap) unwrap() (*hcloud.ServerChangeProtectionOpts, error) {
+	return &hcloud.ServerChangeProtectionOpts{
+		Rebuild: v.Rebuild,
+		Delete:  v.Delete,
+	}, nil
+}
+
+func RebuildServer(ctx *ActionContext) (*base.ActionOutput, error) {
+	input := &hcServerRebuildOptsWrap{}
+	output := &schema.ServerActionRebuildResponse{}
+
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := input.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	hsrv, err := input.Server.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	_, response, err := ctx.HClient.Server.RebuildWithResult(ctx.Context(), hsrv, *opts)
+	if err != nil {
+		return nil, HCloudErrResponse(err, response)
+	}
+
+	aout, err := GenericHCloudOutput(ctx, response, output)
+	if err != nil {
+		return nil, err
+	}
+	// the new root password is only returned if
+	// the server has no ssh keys
+	aout.Records[0].Secret = true
+	err = ctx.WaitForAndLog(output.Action, "Waiting for server rebuild")
+	if err != nil {
+		return nil, err
+	}
+	return aout, nil
+}
+
+func ChangeServerType(ctx *ActionContext) (*base.ActionOutput, error) {
+	input := &hcServerChangeTypeOptsWrap{}
+	output := &schema.ServerActionChangeTypeResponse{}
+
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := input.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	hsrv, err := input.Server.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	_, response, err := ctx.HClient.Server.ChangeType(ctx.Context(), hsrv, *opts)
+	if err != nil {
+		return nil, HCloudErrResponse(err, response)
+	}
+
+	aout, err := GenericHCloudOutput(ctx, response, output)
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for server type change")
+	if err != nil {
+		return nil, err
+	}
+	return aout, nil
+}
+
+func EnableRescueServer(ctx *ActionContext) (*base.ActionOutput, error) {
+	input := &hcServerEnableRescueOptsWrap{}
+	output := &schema.ServerActionEnableRescueResponse{}
+
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := input.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	hsrv, err := input.Server.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	_, response, err := ctx.HClient.Server.EnableRescue(ctx.Context(), hsrv, *opts)
+	if err != nil {
+		return nil, HCloudErrResponse(err, response)
+	}
+
+	aout, err := GenericHCloudOutput(ctx, response, output)
+	if err != nil {
+		return nil, err
+	}
+	aout.Records[0].Secret = true
+	err = ctx.WaitForAndLog(output.Action, "Waiting for rescue mode")
+	if err != nil {
+		return nil, err
+	}
+	return aout, nil
+}
+
+func DisableRescueServer(ctx *ActionContext) (*base.ActionOutput, error) {
+	// only Server.ID are really used
+	input := &hcServerWrap{}
+	output := &schema.ServerActionDisableRescueResponse{}
+
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	hsrv, err := input.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	_, response, err := ctx.HClient.Server.DisableRescue(ctx.Context(), hsrv)
+	if err != nil {
+		return nil, HCloudErrResponse(err, response)
+	}
+
+	aout, err := GenericHCloudOutput(ctx, response, output)
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for rescue mode disable")
+	if err != nil {
+		return nil, err
+	}
+	return aout, nil
+}
+
+func RebootServer(ctx *ActionContext) (*base.ActionOutput, error) {
+	// only Server.ID are really used
+	input := &hcServerWrap{}
+	output := &schema.ServerActionRebootResponse{}
+
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	hsrv, err := input.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	_, response, err := ctx.HClient.Server.Reboot(ctx.Context(), hsrv)
+	if err != nil {
+		return nil, HCloudErrResponse(err, response)
+	}
+
+	aout, err := GenericHCloudOutput(ctx, response, output)
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for server reboot")
+	if err != nil {
+		return nil, err
+	}
+	return aout, nil
+}
+
+func ResetServer(ctx *ActionContext) (*base.ActionOutput, error) {
+	// only Server.ID are really used
+	input := &hcServerWrap{}
+	output := &schema.ServerActionResetResponse{}
+
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	hsrv, err := input.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	_, response, err := ctx.HClient.Server.Reset(ctx.Context(), hsrv)
+	if err != nil {
+		return nil, HCloudErrResponse(err, response)
+	}
+
+	aout, err := GenericHCloudOutput(ctx, response, output)
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for server reset")
+	if err != nil {
+		return nil, err
+	}
+	return aout, nil
+}
+
+func ResetRootPasswordServer(ctx *ActionContext) (*base.ActionOutput, error) {
+	// only Server.ID are really used
+	input := &hcServerWrap{}
+	output := &schema.ServerActionResetPasswordResponse{}
+
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	hsrv, err := input.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	_, response, err := ctx.HClient.Server.ResetPassword(ctx.Context(), hsrv)
+	if err != nil {
+		return nil, HCloudErrResponse(err, response)
+	}
+
+	aout, err := GenericHCloudOutput(ctx, response, output)
+	if err != nil {
+		return nil, err
+	}
+	aout.Records[0].Secret = true
+	err = ctx.WaitForAndLog(output.Action, "Waiting for root password reset")
+	if err != nil {
+		return nil, err
+	}
+	return aout, nil
+}
+
+func RequestConsoleServer(ctx *ActionContext) (*base.ActionOutput, error) {
+	// only Server.ID are really used
+	input := &hcServerWrap{}
+	output := &schema.ServerActionRequestConsoleResponse{}
+
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	hsrv, err := input.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	_, response, err := ctx.HClient.Server.RequestConsole(ctx.Context(), hsrv)
+	if err != nil {
+		return nil, HCloudErrResponse(err, response)
+	}
+
+	aout, err := GenericHCloudOutput(ctx, response, output)
+	if err != nil {
+		return nil, err
+	}
+	// the vnc password
+	aout.Records[0].Secret = true
+	err = ctx.WaitForAndLog(output.Action, "Waiting for console")
+	if err != nil {
+		return nil, err
+	}
+	return aout, nil
+}
+
+func ProtectServer(ctx *ActionContext) (*base.ActionOutput, error) {
+	input := &hcServerChangeProtectionOptsWrap{}
+	output := &schema.ServerActionChangeProtectionResponse{}
+
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := input.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	hsrv, err := input.Server.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	_, response, err := ctx.HClient.Server.ChangeProtection(ctx.Context(), hsrv, *opts)
+	if err != nil {
+		return nil, HCloudErrResponse(err, response)
+	}
+
+	aout, err := GenericHCloudOutput(ctx, response, output)
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for server protection change")
+	if err != nil {
+		return nil, err
+	}
+	return aout, nil
+}
@@ -0,0 +1,190 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/storage"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
+)
+
+type fakeLogger struct {
+	mu    sync.Mutex
+	infos []string
+}
+
+func (l *fakeLogger) LogCritical(s string) {}
+func (l *fakeLogger) LogErr(s string)      {}
+func (l *fakeLogger) ByteLogErr(b []byte)  {}
+func (l *fakeLogger) LogWarn(s string)     {}
+func (l *fakeLogger) LogInfo(s string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.infos = append(l.infos, s)
+}
+func (l *fakeLogger) ByteLogInfo(b []byte)    {}
+func (l *fakeLogger) LogDebug(s string)       {}
+func (l *fakeLogger) Duplicate() base.ILogger { return l }
+func (l *fakeLogger) SetActionID(ai string)   {}
+func (l *fakeLogger) SetThreadID(ti string)   {}
+
+// stubHCloud serves the server actions and the actions
+// status of the hcloud api. Actions finish on first poll.
+type stubHCloud struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func stubAction(status string, progress int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":        1,
+		"command":   "stub",
+		"status":    status,
+		"progress":  progress,
+		"started":   time.Now().Format(time.RFC3339),
+		"resources": []interface{}{},
+	}
+}
+
+func (s *stubHCloud) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.requests = append(s.requests, strings.TrimSpace(r.Method+" "+r.URL.Path+" "+string(body)))
+	s.mu.Unlock()
+
+	out := map[string]interface{}{}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/actions":
+		out["actions"] = []interface{}{stubAction("success", 100)}
+		out["meta"] = map[string]interface{}{"pagination": map[string]interface{}{"page": 1, "per_page": 50, "last_page": 1, "total_entries": 1}}
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/servers/42/actions/"):
+		out["action"] = stubAction("running", 0)
+		switch strings.TrimPrefix(r.URL.Path, "/servers/42/actions/") {
+		case "rebuild", "enable_rescue", "reset_password":
+			out["root_password"] = "s3cr3t"
+		case "request_console":
+			out["wss_url"] = "wss://console.hetzner.cloud/?server_id=42"
+			out["password"] = "vncpass"
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		out["error"] = map[string]string{"code": "not_found", "message": "not found"}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+func runServerAction(t *testing.T, f ActionFunc, params string) (*base.ActionOutput, *stubHCloud, *fakeLogger, error) {
+	t.Helper()
+	stub := &stubHCloud{}
+	aout, logger, err := runHCloudAction(t, stub, storage.NewStore(), f, params)
+	return aout, stub, logger, err
+}
+
+func runHCloudAction(t *testing.T, handler http.Handler, store base.IStore, f ActionFunc, params string) (*base.ActionOutput, *fakeLogger, error) {
+	t.Helper()
+	pollInterval, retryDelay := actionPollInterval, actionPollRetryDelay
+	actionPollInterval, actionPollRetryDelay = time.Millisecond, time.Millisecond
+	t.Cleanup(func() {
+		actionPollInterval, actionPollRetryDelay = pollInterval, retryDelay
+	})
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client := hcloud.NewClient(
+		hcloud.WithEndpoint(srv.URL),
+		hcloud.WithToken("token"),
+		hcloud.WithPollBackoffFunc(hcloud.ConstantBackoff(time.Millisecond)),
+	)
+	output := "result"
+	action := &blueprint.Action{
+		Provider:   "hetznerCloud",
+		Parameters: json.RawMessage(params),
+		Output:     &output,
+	}
+	logger := &fakeLogger{}
+	ctx := NewActionContext(client, action, store, logger)
+	aout, err := f(ctx)
+	return aout, logger, err
+}
+
+func TestServerLifecycle(t *testing.T) {
+	cases := []struct {
+		f       ActionFunc
+		params  string
+		request string
+		secret  bool
+	}{
+		{RebuildServer, `{"server": {"ID": "42"}, "Image": {"ID": "7"}}`, `POST /servers/42/actions/rebuild {"image":7}`, true},
+		{ChangeServerType, `{"server": {"ID": "42"}, "ServerType": {"Name": "cx32"}, "UpgradeDisk": true}`, `POST /servers/42/actions/change_type {"server_type":"cx32","upgrade_disk":true}`, false},
+		{EnableRescueServer, `{"server": {"ID": "42"}, "SSHKeys": [{"ID": "3"}]}`, `POST /servers/42/actions/enable_rescue {"type":"linux64","ssh_keys":[3]}`, true},
+		{DisableRescueServer, `{"ID": "42"}`, `POST /servers/42/actions/disable_rescue`, false},
+		{RebootServer, `{"ID": "42"}`, `POST /servers/42/actions/reboot`, false},
+		{ResetServer, `{"ID": "42"}`, `POST /servers/42/actions/reset`, false},
+		{ResetRootPasswordServer, `{"ID": "42"}`, `POST /servers/42/actions/reset_password`, true},
+		{RequestConsoleServer, `{"ID": "42"}`, `POST /servers/42/actions/request_console`, true},
+		{ProtectServer, `{"server": {"ID": "42"}, "Delete": true}`, `POST /servers/42/actions/change_protection {"delete":true}`, false},
+	}
+	for _, c := range cases {
+		aout, stub, logger, err := runServerAction(t, c.f, c.params)
+		if err != nil {
+			t.Fatalf("%s: %v", c.request, err)
+		}
+		if len(stub.requests) < 2 || stub.requests[0] != c.request || stub.requests[1] != "GET /actions" {
+			t.Errorf("expected %s and the action poll, got %v", c.request, stub.requests)
+		}
+		if aout.Records[0].Secret != c.secret {
+			t.Errorf("%s: expected secret %v", c.request, c.secret)
+		}
+		if len(logger.infos) <= 0 || !strings.HasPrefix(logger.infos[0], "Waiting for") {
+			t.Errorf("%s: expected progress logs, got %v", c.request, logger.infos)
+		}
+	}
+
+	aout, _, _, err := runServerAction(t, EnableRescueServer, `{"server": {"ID": "42"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pass := aout.Records[0].Value.(*schema.ServerActionEnableRescueResponse).RootPassword; pass != "s3cr3t" {
+		t.Errorf("unexpected root password %s", pass)
+	}
+
+	_, _, _, err = runServerAction(t, ProtectServer, `{"server": {"ID": "42"}}`)
+	if err == nil {
+		t.Errorf("protect_server without protection should fail")
+	}
+	_, _, _, err = runServerAction(t, RebootServer, `{}`)
+	if err == nil {
+		t.Errorf("reboot_server without server should fail")
+	}
+}
@@ -0,0 +1,174 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
+)
+
+type hcServerTypeListOptsWrap struct {
+	hcloud.ServerTypeListOpts
+	ID *string `json:"id"` // for GetByID
+}
+
+func (v *hcServerTypeListOptsWrap) unwrap() (*hcloud.ServerTypeListOpts, error) {
+	return &hcloud.ServerTypeListOpts{
+		ListOpts: v.ListOpts,
+		Name:     v.Name,
+		Sort:     v.Sort,
+	}, nil
+}
+
+type ServerTypeListResponseWithMeta struct {
+	schema.ServerTypeListResponse
+	Meta schema.Meta `json:"meta"`
+}
+
+func FindServerTypes(ctx *ActionContext) (*base.ActionOutput, error) {
+	var err error
+	input := &hcServerTypeListOptsWrap{}
+
+	if err := json.Unmarshal(ctx.Action.Parameters, input); err != nil {
+		return nil, err
+	}
+
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := input.unwrap()
+	if err != nil {
+		return nil, err
+	}
+
+	if input.ID != nil {
+		int64id, err := strconv.ParseInt(*input.ID, 10, 64)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("cannot use '%v' as int64 ID", *input.ID), err)
+		}
+		_, response, err := ctx.HClient.ServerType.GetByID(ctx.Context(), int64id)
+		if err != nil {
+			return nil, HCloudErrResponse(err, response)
+		}
+		stsch := &schema.ServerTypeGetResponse{}
+		err = UnmarshallHCloudToSchema(response, stsch)
+		if err != nil {
+			return nil, err
+		}
+		output := &ServerTypeListResponseWithMeta{
+			Meta: schema.Meta{
+				Pagination: &schema.MetaPagination{
+					Page:         1,
+					LastPage:     1,
+					NextPage:     0,
+					TotalEntries: 1,
+				},
+			},
+		}
+		output.ServerTypes = []schema.ServerType{stsch.ServerType}
+		return base.NewActionOutput(ctx.Action, output, nil), nil
+	}
+
+	if opts.Page > 0 {
+		// a concrete page was requested, return it as is
+		_, response, err := ctx.HClient.ServerType.List(ctx.Context(), *opts)
+		if err != nil {
+			return nil, HCloudErrResponse(err, response)
+		}
+		return GenericHCloudOutput(ctx, response, &ServerTypeListResponseWithMeta{})
+	}
+
+	// no page requested, walk all of them
+	var sts []schema.ServerType
+	opts.Page = 1     // min allowed (0 means no page)
+	opts.PerPage = 50 // max allowed
+	for {
+		_, _rsp, err := ctx.HClient.ServerType.List(ctx.Context(), *opts)
+		if err != nil {
+			return nil, HCloudErrResponse(err, _rsp)
+		}
+		_v := &ServerTypeListResponseWithMeta{}
+		err = UnmarshallHCloudToSchema(_rsp, _v)
+		if err != nil {
+			return nil, err
+		}
+		if len(_v.ServerTypes) <= 0 {
+			break
+		}
+		sts = append(sts, _v.ServerTypes...)
+		if _rsp.Meta.Pagination == nil || _rsp.Meta.Pagination.NextPage == 0 {
+			break
+		}
+		opts.Page = _rsp.Meta.Pagination.NextPage
+	}
+	output := &ServerTypeListResponseWithMeta{
+		Meta: schema.Meta{
+			Pagination: &schema.MetaPagination{
+				Page:         1,
+				LastPage:     1,
+				NextPage:     0,
+				TotalEntries: len(sts),
+			},
+		},
+	}
+	output.ServerTypes = sts
+	return base.NewActionOutput(ctx.Action, output, nil), nil
+}
+
+func FindOneServerType(ctx *ActionContext) (*base.ActionOutput, error) {
+	aout, err := FindServerTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+	if len(aout.Records) <= 0 {
+		return nil, fmt.Errorf("no server type found")
+	}
+	raw := aout.Records[0].Value.(*ServerTypeListResponseWithMeta)
+	found := len(raw.ServerTypes)
+	if found > 1 {
+		return nil, fmt.Errorf("too many results")
+	}
+	if found <= 0 {
+		return nil, fmt.Errorf("no server type found")
+	}
+	output := &schema.ServerTypeGetResponse{}
+	output.ServerType = raw.ServerTypes[0]
+	id := fmt.Sprintf("%v", raw.ServerTypes[0].ID)
+	aout = base.NewActionOutput(ctx.Action, output, &id)
+	return aout, nil
+}
@@ -23,14 +23,11 @@
 package actors
 
 import (
-	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
 
 	"github.com/develatio/nebulant-cli/base"
-	"github.com/develatio/nebulant-cli/blueprint"
 	"github.com/develatio/nebulant-cli/util"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
@@ -65,13 +62,7 @@ func CreateVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcloud.VolumeCreateOpts{}
 	output := &schema.VolumeCreateResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err = json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -84,7 +75,7 @@ func CreateVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Volume.Create(context.Background(), *input)
+	_, response, err := ctx.HClient.Volume.Create(ctx.Context(), *input)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -93,15 +84,14 @@ func CreateVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" && output.Action != nil {
-				err = ctx.WaitForAndLog(*output.Action, "Waiting for volume")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	// next actions are the attach and automount ones
+	actions := output.NextActions
+	if output.Action != nil {
+		actions = append([]schema.Action{*output.Action}, actions...)
+	}
+	err = ctx.WaitForManyAndLog(actions, "Waiting for volume")
+	if err != nil {
+		return nil, err
 	}
 	id := fmt.Sprintf("%v", output.Volume.ID)
 	return base.NewActionOutput(ctx.Action, output, &id), nil
@@ -112,7 +102,7 @@ func DeleteVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 	// only Volume.ID attr are really used
 	input := &hcVolumeWrap{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -130,7 +120,7 @@ func DeleteVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, err = ctx.HClient.Volume.Delete(context.Background(), hvol)
+	_, err = ctx.HClient.Volume.Delete(ctx.Context(), hvol)
 	if err != nil {
 		return nil, err
 	}
@@ -142,7 +132,7 @@ func DeleteVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 func FindVolumes(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcloud.VolumeListOpts{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
@@ -155,7 +145,7 @@ func FindVolumes(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Volume.List(context.Background(), *input)
+	_, response, err := ctx.HClient.Volume.List(ctx.Context(), *input)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -194,16 +184,11 @@ func AttachVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &volumeAttachParameters{}
 	output := &schema.VolumeActionAttachVolumeResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
+	var err error
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -224,7 +209,7 @@ func AttachVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 	}
 	input.AttachOpts.Server = hsrv
 
-	_, response, err := ctx.HClient.Volume.AttachWithOpts(context.Background(), hvol, input.AttachOpts)
+	_, response, err := ctx.HClient.Volume.AttachWithOpts(ctx.Context(), hvol, input.AttachOpts)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -233,15 +218,9 @@ func AttachVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for volume attach")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for volume attach")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
@@ -251,16 +230,11 @@ func DetachVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 	input := &hcVolumeWrap{}
 	output := &schema.VolumeActionDetachVolumeResponse{}
 
-	if err := util.UnmarshalValidJSON(ctx.Action.Parameters, input); err != nil {
-		return nil, err
-	}
-
-	internalparams := &blueprint.InternalParameters{}
-	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
-	if err != nil {
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, input, ctx.Store); err != nil {
 		return nil, err
 	}
 
+	var err error
 	if ctx.Rehearsal {
 		return nil, nil
 	}
@@ -275,7 +249,7 @@ func DetachVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 		return nil, err
 	}
 
-	_, response, err := ctx.HClient.Volume.Detach(context.Background(), hvol)
+	_, response, err := ctx.HClient.Volume.Detach(ctx.Context(), hvol)
 	if err != nil {
 		return nil, HCloudErrResponse(err, response)
 	}
@@ -284,15 +258,9 @@ func DetachVolume(ctx *ActionContext) (*base.ActionOutput, error) {
 	if err != nil {
 		return nil, err
 	}
-	if internalparams.Waiters != nil {
-		for _, wnam := range internalparams.Waiters {
-			if wnam == "success" {
-				err = ctx.WaitForAndLog(output.Action, "Waiting for volume detach")
-				if err != nil {
-					return nil, err
-				}
-			}
-		}
+	err = ctx.WaitForAndLog(output.Action, "Waiting for volume detach")
+	if err != nil {
+		return nil, err
 	}
 	return aout, err
 }
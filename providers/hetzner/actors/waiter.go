@@ -0,0 +1,224 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/develatio/nebulant-cli/cast"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
+)
+
+// ActionWaitTimeout is the max time spent waiting for the hcloud
+// actions started by a single nebulant action
+var ActionWaitTimeout = 30 * time.Minute
+
+// actionPollInterval is the time between action status requests
+var actionPollInterval = 1 * time.Second
+
+// actionPollRetryDelay is the pause before asking again for the
+// actions status after an api error
+var actionPollRetryDelay = 3 * time.Second
+
+// actionPollMaxRetries is the max consecutive api errors allowed
+// while asking for the actions status
+const actionPollMaxRetries = 5
+
+// hcloud error codes of transient conditions, the rejected request
+// can be sent again after a while
+var retryableErrorCodes = map[hcloud.ErrorCode]bool{
+	hcloud.ErrorCodeRateLimitExceeded: true,
+	hcloud.ErrorCodeLocked:            true,
+	hcloud.ErrorCodeConflict:          true,
+}
+
+// ActionFailedError is returned by the waiters when an hcloud action
+// ends with error status
+type ActionFailedError struct {
+	ActionID int64
+	Command  string
+	Code     string
+	Message  string
+}
+
+func (e *ActionFailedError) Error() string {
+	return fmt.Sprintf("hetzner action %v (%s) failed: %s (%s)", e.ActionID, e.Command, e.Message, e.Code)
+}
+
+// IsRetryableError reports whether err is a rate limit, locked
+// resource or conflict error returned by the api. Failed hcloud actions and the
+// errors got while waiting for them are not retryable, the resource
+// has been created (or changed) and running the nebulant action
+// again would create it twice.
+func IsRetryableError(err error) bool {
+	var acterr *ActionFailedError
+	if errors.As(err, &acterr) {
+		return false
+	}
+	var apierr hcloud.Error
+	if errors.As(err, &apierr) {
+		return retryableErrorCodes[apierr.Code]
+	}
+	return false
+}
+
+// WaitForAndLog waits for a single hcloud action, see WaitForManyAndLog
+func (a *ActionContext) WaitForAndLog(action schema.Action, msg string) error {
+	return a.WaitForManyAndLog([]schema.Action{action}, msg)
+}
+
+// WaitForManyAndLog waits until all the hcloud actions finish or
+// ActionWaitTimeout is reached, logging the overall progress and
+// pushing it to the bus. Every failed action is returned as an
+// *ActionFailedError, all of them joined.
+func (a *ActionContext) WaitForManyAndLog(actions []schema.Action, msg string) error {
+	if len(actions) <= 0 {
+		return nil
+	}
+
+	var errs []error
+	progress := make(map[int64]int, len(actions))
+	pending := make(map[int64]bool)
+	for _, act := range actions {
+		switch hcloud.ActionStatus(act.Status) {
+		case hcloud.ActionStatusSuccess:
+			progress[act.ID] = 100
+		case hcloud.ActionStatusError:
+			progress[act.ID] = 100
+			errs = append(errs, actionFailedErrorFromSchema(act))
+		default:
+			progress[act.ID] = act.Progress
+			pending[act.ID] = true
+			a.Logger.LogDebug(fmt.Sprintf("waiting for action %v", act.ID))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(a.Context(), ActionWaitTimeout)
+	defer cancel()
+
+	lastProgress := -1
+	errCount := 0
+	for len(pending) > 0 {
+		if p := overallProgress(progress); p != lastProgress {
+			a.logProgress(msg, p)
+			lastProgress = p
+		}
+
+		delay := actionPollInterval
+		if errCount > 0 {
+			delay = actionPollRetryDelay
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(append(errs, errWaitTimeout())...)
+		case <-time.After(delay):
+		}
+
+		opts := hcloud.ActionListOpts{}
+		for id := range pending {
+			opts.ID = append(opts.ID, id)
+		}
+		hacts, err := a.HClient.Action.AllWithOpts(ctx, opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return errors.Join(append(errs, errWaitTimeout())...)
+			}
+			// sometimes hc api ret err even on non
+			// failing event, retry before giving up
+			if errCount < actionPollMaxRetries {
+				errCount++
+				a.Logger.LogDebug(fmt.Sprintf("cannot get actions status (%v), retrying", err))
+				continue
+			}
+			// not wrapped, the action has already been
+			// sent and must not be retried
+			return errors.Join(append(errs, fmt.Errorf("cannot get hetzner actions status: %v", err))...)
+		}
+		errCount = 0
+
+		if len(hacts) <= 0 {
+			// if api did not return any action, maybe the actions have finished
+			a.Logger.LogDebug(fmt.Sprintf("actions %v not returned from API", opts.ID))
+			break
+		}
+		for _, hact := range hacts {
+			switch hact.Status {
+			case hcloud.ActionStatusRunning:
+				progress[hact.ID] = hact.Progress
+			case hcloud.ActionStatusSuccess:
+				progress[hact.ID] = 100
+				delete(pending, hact.ID)
+			case hcloud.ActionStatusError:
+				progress[hact.ID] = 100
+				delete(pending, hact.ID)
+				errs = append(errs, &ActionFailedError{
+					ActionID: hact.ID,
+					Command:  hact.Command,
+					Code:     hact.ErrorCode,
+					Message:  hact.ErrorMessage,
+				})
+			}
+		}
+	}
+	if p := overallProgress(progress); p != lastProgress {
+		a.logProgress(msg, p)
+	}
+	return errors.Join(errs...)
+}
+
+func (a *ActionContext) logProgress(msg string, progress int) {
+	if progress == 0 {
+		a.Logger.LogInfo(msg + " ... ")
+	} else {
+		a.Logger.LogInfo(fmt.Sprintf(msg+" (%v%%...) ", progress))
+	}
+	cast.PushActionProgress(a.Logger, msg, progress)
+}
+
+func errWaitTimeout() error {
+	return fmt.Errorf("timeout after %v waiting for hetzner actions", ActionWaitTimeout)
+}
+
+func overallProgress(progress map[int64]int) int {
+	total := 0
+	for _, p := range progress {
+		total += p
+	}
+	return total / len(progress)
+}
+
+func actionFailedErrorFromSchema(act schema.Action) *ActionFailedError {
+	acterr := &ActionFailedError{
+		ActionID: act.ID,
+		Command:  act.Command,
+	}
+	if act.Error != nil {
+		acterr.Code = act.Error.Code
+		acterr.Message = act.Error.Message
+	}
+	return acterr
+}
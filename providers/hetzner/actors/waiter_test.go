@@ -0,0 +1,196 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actors
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/storage"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
+)
+
+// stubActions serves the actions status of the hcloud api. Every poll
+// moves the requested actions to their next status in steps, the last
+// status is kept once reached. A non empty apiErr is returned instead.
+type stubActions struct {
+	mu     sync.Mutex
+	steps  map[int64][]map[string]interface{}
+	polls  int
+	apiErr string
+}
+
+func stubFailedAction(id int64, code string) map[string]interface{} {
+	act := stubAction("error", 100)
+	act["id"] = id
+	act["command"] = "attach_volume"
+	act["error"] = map[string]string{"code": code, "message": "failed by stub"}
+	return act
+}
+
+func stubActionWithID(id int64, status string, progress int) map[string]interface{} {
+	act := stubAction(status, progress)
+	act["id"] = id
+	return act
+}
+
+func (s *stubActions) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.polls++
+	out := map[string]interface{}{}
+	w.Header().Set("Content-Type", "application/json")
+	if s.apiErr != "" {
+		w.WriteHeader(http.StatusTooManyRequests)
+		out["error"] = map[string]string{"code": s.apiErr, "message": "stub api error"}
+		_ = json.NewEncoder(w).Encode(out)
+		return
+	}
+	var actions []interface{}
+	for _, sid := range r.URL.Query()["id"] {
+		id, _ := strconv.ParseInt(sid, 10, 64)
+		steps := s.steps[id]
+		if len(steps) <= 0 {
+			continue
+		}
+		actions = append(actions, steps[0])
+		if len(steps) > 1 {
+			s.steps[id] = steps[1:]
+		}
+	}
+	out["actions"] = actions
+	out["meta"] = map[string]interface{}{"pagination": map[string]interface{}{"page": 1, "per_page": 50, "last_page": 1, "total_entries": len(actions)}}
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+func waitWithStub(t *testing.T, stub *stubActions, actions []schema.Action) (*fakeLogger, error) {
+	t.Helper()
+	var werr error
+	f := func(ctx *ActionContext) (*base.ActionOutput, error) {
+		werr = ctx.WaitForManyAndLog(actions, "Waiting for stub")
+		return nil, nil
+	}
+	_, logger, _ := runHCloudAction(t, stub, storage.NewStore(), f, `{}`)
+	return logger, werr
+}
+
+func runningActions(ids ...int64) []schema.Action {
+	var actions []schema.Action
+	for _, id := range ids {
+		actions = append(actions, schema.Action{ID: id, Status: "running"})
+	}
+	return actions
+}
+
+func TestWaitForManyProgress(t *testing.T) {
+	stub := &stubActions{steps: map[int64][]map[string]interface{}{
+		1: {stubActionWithID(1, "running", 50), stubActionWithID(1, "success", 100)},
+		2: {stubActionWithID(2, "success", 100)},
+	}}
+	logger, err := waitWithStub(t, stub, runningActions(1, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"Waiting for stub ... ", "Waiting for stub (75%...) ", "Waiting for stub (100%...) "}
+	if strings.Join(logger.infos, "|") != strings.Join(expected, "|") {
+		t.Errorf("unexpected progress logs %q", logger.infos)
+	}
+
+	// finished actions are not polled
+	stub = &stubActions{}
+	_, err = waitWithStub(t, stub, []schema.Action{{ID: 1, Status: "success", Progress: 100}})
+	if err != nil || stub.polls != 0 {
+		t.Errorf("unexpected polls %v (err: %v)", stub.polls, err)
+	}
+}
+
+func TestWaitForManyFailed(t *testing.T) {
+	stub := &stubActions{steps: map[int64][]map[string]interface{}{
+		1: {stubFailedAction(1, "locked")},
+		2: {stubActionWithID(2, "running", 10), stubActionWithID(2, "success", 100)},
+	}}
+	_, err := waitWithStub(t, stub, runningActions(1, 2))
+	var acterr *ActionFailedError
+	if !errors.As(err, &acterr) || acterr.ActionID != 1 || acterr.Code != "locked" || acterr.Command != "attach_volume" {
+		t.Fatalf("expected failed action error, got %v", err)
+	}
+	if stub.polls != 2 {
+		t.Errorf("expected to wait for the other action, got %v polls", stub.polls)
+	}
+	if IsRetryableError(errors.Join(errors.New("KO"), err)) {
+		t.Errorf("failed action should not be retryable")
+	}
+
+	stub = &stubActions{steps: map[int64][]map[string]interface{}{
+		1: {stubFailedAction(1, "server_not_stopped")},
+	}}
+	_, err = waitWithStub(t, stub, runningActions(1))
+	if err == nil || IsRetryableError(err) {
+		t.Errorf("expected non retryable error, got %v", err)
+	}
+}
+
+func TestWaitForManyErrors(t *testing.T) {
+	stub := &stubActions{apiErr: string(hcloud.ErrorCodeRateLimitExceeded)}
+	_, err := waitWithStub(t, stub, runningActions(1))
+	if err == nil || IsRetryableError(err) {
+		t.Errorf("rate limit while waiting should not be retryable, got %v", err)
+	}
+	if stub.polls != actionPollMaxRetries+1 {
+		t.Errorf("expected %v polls, got %v", actionPollMaxRetries+1, stub.polls)
+	}
+
+	timeout := ActionWaitTimeout
+	ActionWaitTimeout = 20 * time.Millisecond
+	defer func() { ActionWaitTimeout = timeout }()
+	stub = &stubActions{steps: map[int64][]map[string]interface{}{
+		1: {stubActionWithID(1, "running", 10)},
+	}}
+	_, err = waitWithStub(t, stub, runningActions(1))
+	if err == nil || !strings.HasPrefix(err.Error(), "timeout after") || IsRetryableError(err) {
+		t.Errorf("expected non retryable timeout, got %v", err)
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	for code, retryable := range map[hcloud.ErrorCode]bool{
+		hcloud.ErrorCodeRateLimitExceeded: true,
+		hcloud.ErrorCodeLocked:            true,
+		hcloud.ErrorCodeConflict:          true,
+		hcloud.ErrorCodeInvalidInput:      false,
+		hcloud.ErrorCodeNotFound:          false,
+	} {
+		err := HCloudErrResponse(hcloud.Error{Code: code, Message: "KO"}, nil)
+		if IsRetryableError(err) != retryable {
+			t.Errorf("unexpected retryable %v for %s", !retryable, code)
+		}
+	}
+}
@@ -25,6 +25,7 @@ package hetzner
 import (
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"sync"
 
@@ -32,6 +33,7 @@ import (
 	"github.com/develatio/nebulant-cli/blueprint"
 	hook_providers "github.com/develatio/nebulant-cli/hook/providers"
 	"github.com/develatio/nebulant-cli/providers/hetzner/actors"
+	"github.com/develatio/nebulant-cli/util"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 )
 
@@ -115,6 +117,16 @@ func (p *Provider) OnActionErrorHook(aout *base.ActionOutput) ([]*blueprint.Acti
 		}
 		return hook_providers.DefaultOnActionErrorHook(phcontext, aout)
 	}
+
+	// retry on rate limit, locked and conflict errs of the api, the
+	// request has been rejected and nothing has been done
+	if actors.IsRetryableError(aout.Records[0].Error) {
+		phcontext := &hook_providers.ProviderHookContext{
+			Logger: p.Logger,
+			Store:  p.store,
+		}
+		return hook_providers.DefaultOnActionErrorHook(phcontext, aout)
+	}
 	return nil, nil
 }
 
@@ -133,7 +145,12 @@ func (p *Provider) touchSession() error {
 		return &base.ProviderAuthError{Err: fmt.Errorf("cannot found hetzner client auth token. Please set HETZNER_CLIENT_AUTH_TOKEN env var")}
 	}
 
-	client := hcloud.NewClient(hcloud.WithToken(hct))
+	// the requests of the actions with debug_network enabled
+	// are traced through the context of the action
+	client := hcloud.NewClient(
+		hcloud.WithToken(hct),
+		hcloud.WithHTTPClient(&http.Client{Transport: util.NewDebugTransport(nil, nil)}),
+	)
 	p.store.SetPrivateVar("hetznerClient", client)
 
 	// All credential parameters has been provided, but not validated
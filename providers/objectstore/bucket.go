@@ -0,0 +1,185 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package objectstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/util"
+)
+
+// bucketWaitTimeout is the max time the bucket waiters will wait
+const bucketWaitTimeout = 5 * time.Minute
+
+type createBucketParameters struct {
+	Bucket *string `json:"bucket" validate:"required"`
+	Region *string `json:"region"`
+}
+
+type deleteBucketParameters struct {
+	Bucket *string `json:"bucket" validate:"required"`
+	// Force deletes all the objects of the bucket before deleting it
+	Force bool `json:"force"`
+}
+
+// Bucket struct
+type Bucket struct {
+	Name     string `json:"name"`
+	Region   string `json:"region,omitempty"`
+	Location string `json:"location,omitempty"`
+}
+
+// CreateBucket func
+func CreateBucket(ctx *Context) (*base.ActionOutput, error) {
+	params := new(createBucketParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+
+	client := ctx.NewClient()
+	input := &s3.CreateBucketInput{
+		Bucket: params.Bucket,
+	}
+	region := stringValue(params.Region)
+	if region == "" {
+		region = client.Options().Region
+	}
+	// us-east-1 is the default location and it is rejected as an
+	// explicit LocationConstraint
+	switch region {
+	case "", "us-east-1", "auto":
+	default:
+		input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
+			LocationConstraint: types.BucketLocationConstraint(region),
+		}
+	}
+
+	ctx.Logger.LogInfo(fmt.Sprintf("Creating bucket %s...", *params.Bucket))
+	result, err := client.CreateBucket(context.TODO(), input)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, waitername := range internalparams.Waiters {
+		switch waitername {
+		case "WaitUntilBucketExists":
+			ctx.Logger.LogInfo("Waiting for bucket to exist...")
+			err = s3.NewBucketExistsWaiter(client).Wait(context.TODO(), &s3.HeadBucketInput{Bucket: params.Bucket}, bucketWaitTimeout)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unkown waiter")
+		}
+	}
+
+	bucket := &Bucket{
+		Name:     *params.Bucket,
+		Region:   region,
+		Location: stringValue(result.Location),
+	}
+	aout := base.NewActionOutput(ctx.Action, bucket, params.Bucket)
+	return aout, nil
+}
+
+// DeleteBucket func
+func DeleteBucket(ctx *Context) (*base.ActionOutput, error) {
+	params := new(deleteBucketParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	internalparams := new(blueprint.InternalParameters)
+	err := json.Unmarshal(ctx.Action.Parameters, internalparams)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err = ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+
+	client := ctx.NewClient()
+	if params.Force {
+		objects, err := listAll(client, *params.Bucket, "")
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, len(objects))
+		for i, o := range objects {
+			keys[i] = o.Key
+		}
+		ctx.Logger.LogInfo(fmt.Sprintf("Deleting %d objects from bucket %s...", len(keys), *params.Bucket))
+		if _, err := deleteKeys(client, *params.Bucket, keys); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx.Logger.LogInfo(fmt.Sprintf("Deleting bucket %s...", *params.Bucket))
+	_, err = client.DeleteBucket(context.TODO(), &s3.DeleteBucketInput{
+		Bucket: params.Bucket,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, waitername := range internalparams.Waiters {
+		switch waitername {
+		case "WaitUntilBucketNotExists":
+			ctx.Logger.LogInfo("Waiting for bucket to not exist...")
+			err = s3.NewBucketNotExistsWaiter(client).Wait(context.TODO(), &s3.HeadBucketInput{Bucket: params.Bucket}, bucketWaitTimeout)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unkown waiter")
+		}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, &Bucket{Name: *params.Bucket}, params.Bucket)
+	return aout, nil
+}
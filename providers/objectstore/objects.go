@@ -0,0 +1,309 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/util"
+)
+
+// defaultPresignExpires is the lifetime of a presigned url if none is given
+const defaultPresignExpires = 3600
+
+type listObjectsParameters struct {
+	Bucket    *string `json:"bucket" validate:"required"`
+	Prefix    *string `json:"prefix"`
+	Delimiter *string `json:"delimiter"`
+	// MaxKeys limits the number of listed objects. All the objects are
+	// listed if it is zero
+	MaxKeys int32 `json:"max_keys" validate:"gte=0"`
+}
+
+type deleteObjectsParameters struct {
+	Bucket *string   `json:"bucket" validate:"required"`
+	Keys   []*string `json:"keys"`
+	Prefix *string   `json:"prefix"`
+}
+
+func (v *deleteObjectsParameters) Validate() error {
+	if len(v.Keys) == 0 && v.Prefix == nil {
+		return fmt.Errorf("keys or prefix is required")
+	}
+	if len(v.Keys) > 0 && v.Prefix != nil {
+		return fmt.Errorf("keys and prefix are mutually exclusive")
+	}
+	return nil
+}
+
+type presignURLParameters struct {
+	Bucket  *string `json:"bucket" validate:"required"`
+	Key     *string `json:"key" validate:"required"`
+	Method  string  `json:"method" validate:"omitempty,oneof=GET PUT"`
+	Expires int64   `json:"expires" validate:"gte=0"`
+}
+
+// Object struct
+type Object struct {
+	Key          string     `json:"key"`
+	Size         int64      `json:"size"`
+	ETag         string     `json:"etag"`
+	LastModified *time.Time `json:"last_modified,omitempty"`
+	StorageClass string     `json:"storage_class,omitempty"`
+}
+
+func newObject(o types.Object) *Object {
+	return &Object{
+		Key:          aws.ToString(o.Key),
+		Size:         aws.ToInt64(o.Size),
+		ETag:         strings.Trim(aws.ToString(o.ETag), "\""),
+		LastModified: o.LastModified,
+		StorageClass: string(o.StorageClass),
+	}
+}
+
+// ObjectList struct
+type ObjectList struct {
+	Bucket         string    `json:"bucket"`
+	Prefix         string    `json:"prefix"`
+	Objects        []*Object `json:"objects"`
+	CommonPrefixes []string  `json:"common_prefixes"`
+	Count          int       `json:"count"`
+	Truncated      bool      `json:"truncated"`
+}
+
+// DeletedObjects struct
+type DeletedObjects struct {
+	Bucket  string   `json:"bucket"`
+	Deleted []string `json:"deleted"`
+	Count   int      `json:"count"`
+}
+
+// PresignedURL struct
+type PresignedURL struct {
+	URL       string    `json:"url"`
+	Method    string    `json:"method"`
+	Bucket    string    `json:"bucket"`
+	Key       string    `json:"key"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// deleteKeys deletes the keys in batches, returning the deleted ones
+func deleteKeys(client *s3.Client, bucket string, keys []string) ([]string, error) {
+	var deleted []string
+	for start := 0; start < len(keys); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ids := make([]types.ObjectIdentifier, 0, end-start)
+		for _, k := range keys[start:end] {
+			ids = append(ids, types.ObjectIdentifier{Key: aws.String(k)})
+		}
+		result, err := client.DeleteObjects(context.TODO(), &s3.DeleteObjectsInput{
+			Bucket: &bucket,
+			Delete: &types.Delete{Objects: ids, Quiet: aws.Bool(false)},
+		})
+		if err != nil {
+			return deleted, err
+		}
+		for _, d := range result.Deleted {
+			deleted = append(deleted, aws.ToString(d.Key))
+		}
+		if len(result.Errors) > 0 {
+			var errs []error
+			for _, e := range result.Errors {
+				errs = append(errs, fmt.Errorf("cannot delete %s: %s %s", aws.ToString(e.Key), aws.ToString(e.Code), aws.ToString(e.Message)))
+			}
+			return deleted, errors.Join(errs...)
+		}
+	}
+	return deleted, nil
+}
+
+// ListObjects func
+func ListObjects(ctx *Context) (*base.ActionOutput, error) {
+	params := new(listObjectsParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+	cleanPrefix(ctx, params.Prefix)
+
+	client := ctx.NewClient()
+	input := &s3.ListObjectsV2Input{
+		Bucket:    params.Bucket,
+		Prefix:    params.Prefix,
+		Delimiter: params.Delimiter,
+	}
+	list := &ObjectList{
+		Bucket:         *params.Bucket,
+		Prefix:         stringValue(params.Prefix),
+		Objects:        []*Object{},
+		CommonPrefixes: []string{},
+	}
+	p := s3.NewListObjectsV2Paginator(client, input)
+	for p.HasMorePages() {
+		page, err := p.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range page.Contents {
+			if params.MaxKeys > 0 && len(list.Objects) >= int(params.MaxKeys) {
+				list.Truncated = true
+				break
+			}
+			list.Objects = append(list.Objects, newObject(o))
+		}
+		for _, cp := range page.CommonPrefixes {
+			list.CommonPrefixes = append(list.CommonPrefixes, aws.ToString(cp.Prefix))
+		}
+		if list.Truncated {
+			break
+		}
+		if params.MaxKeys > 0 && len(list.Objects) >= int(params.MaxKeys) {
+			list.Truncated = p.HasMorePages()
+			break
+		}
+	}
+	list.Count = len(list.Objects)
+
+	aout := base.NewActionOutput(ctx.Action, list, nil)
+	return aout, nil
+}
+
+// DeleteObjects func
+func DeleteObjects(ctx *Context) (*base.ActionOutput, error) {
+	params := new(deleteObjectsParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+
+	client := ctx.NewClient()
+	var keys []string
+	if params.Prefix != nil {
+		cleanPrefix(ctx, params.Prefix)
+		objects, err := listAll(client, *params.Bucket, *params.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range objects {
+			keys = append(keys, o.Key)
+		}
+	} else {
+		for _, k := range params.Keys {
+			keys = append(keys, stringValue(k))
+		}
+	}
+
+	ctx.Logger.LogInfo(fmt.Sprintf("Deleting %d objects from bucket %s...", len(keys), *params.Bucket))
+	deleted, err := deleteKeys(client, *params.Bucket, keys)
+	if err != nil {
+		return nil, err
+	}
+	if deleted == nil {
+		deleted = []string{}
+	}
+
+	aout := base.NewActionOutput(ctx.Action, &DeletedObjects{
+		Bucket:  *params.Bucket,
+		Deleted: deleted,
+		Count:   len(deleted),
+	}, nil)
+	return aout, nil
+}
+
+// PresignURL func
+func PresignURL(ctx *Context) (*base.ActionOutput, error) {
+	params := new(presignURLParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+	cleanPrefix(ctx, params.Key)
+
+	if params.Method == "" {
+		params.Method = "GET"
+	}
+	if params.Expires == 0 {
+		params.Expires = defaultPresignExpires
+	}
+	expires := time.Duration(params.Expires) * time.Second
+
+	pc := s3.NewPresignClient(ctx.NewClient(), s3.WithPresignExpires(expires))
+	var url string
+	switch params.Method {
+	case "PUT":
+		req, err := pc.PresignPutObject(context.TODO(), &s3.PutObjectInput{Bucket: params.Bucket, Key: params.Key})
+		if err != nil {
+			return nil, err
+		}
+		url = req.URL
+	default:
+		req, err := pc.PresignGetObject(context.TODO(), &s3.GetObjectInput{Bucket: params.Bucket, Key: params.Key})
+		if err != nil {
+			return nil, err
+		}
+		url = req.URL
+	}
+
+	aout := base.NewActionOutput(ctx.Action, &PresignedURL{
+		URL:       url,
+		Method:    params.Method,
+		Bucket:    *params.Bucket,
+		Key:       *params.Key,
+		ExpiresAt: time.Now().Add(expires).UTC(),
+	}, nil)
+	return aout, nil
+}
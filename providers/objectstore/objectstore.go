@@ -0,0 +1,117 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package objectstore implements the S3 compatible actions shared by the
+// providers that talk to an object store (aws S3, cloudflare R2...).
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+)
+
+// Context struct. Providers build it from its own action context
+type Context struct {
+	Rehearsal bool
+	Action    *blueprint.Action
+	Store     base.IStore
+	Logger    base.ILogger
+	NewClient func() *s3.Client
+}
+
+// ActionFunc func
+type ActionFunc func(ctx *Context) (*base.ActionOutput, error)
+
+// deleteBatchSize is the max number of keys accepted by DeleteObjects
+const deleteBatchSize = 1000
+
+func newUploader(client *s3.Client) *manager.Uploader {
+	return manager.NewUploader(client, func(u *manager.Uploader) {
+		u.PartSize = 10 * 1024 * 1024 // 10 MiB
+		u.Concurrency = 3
+	})
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// cleanPrefix removes the starting slash of a key prefix, warning about it
+func cleanPrefix(ctx *Context, prefix *string) {
+	if prefix == nil {
+		return
+	}
+	if nprefix, ok := strings.CutPrefix(*prefix, "/"); ok {
+		ctx.Logger.LogWarn(fmt.Sprintf("starting slash of path %s will be removed: %s", *prefix, nprefix))
+		*prefix = nprefix
+	}
+}
+
+// objectKey returns the key of a file relative to a local base dir
+func objectKey(prefix string, rel string) string {
+	return path.Join(prefix, filepath.ToSlash(rel))
+}
+
+// localPath returns the local path where the object with the given key
+// should be stored, refusing keys that would escape the dest dir
+func localPath(dir string, prefix string, key string) (string, error) {
+	rel := strings.TrimPrefix(strings.TrimPrefix(key, prefix), "/")
+	if rel == "" {
+		rel = path.Base(key)
+	}
+	dst := filepath.Join(dir, filepath.FromSlash(rel))
+	if r, err := filepath.Rel(dir, dst); err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("object key %s is outside of the dest directory", key)
+	}
+	return dst, nil
+}
+
+// listAll returns all the objects under prefix
+func listAll(client *s3.Client, bucket string, prefix string) ([]*Object, error) {
+	var objects []*Object
+	p := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: &bucket,
+		Prefix: &prefix,
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range page.Contents {
+			objects = append(objects, newObject(o))
+		}
+	}
+	return objects, nil
+}
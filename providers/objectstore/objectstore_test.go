@@ -0,0 +1,561 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package objectstore_test
+
+import (
+	"context"
+	"crypto/md5" // #nosec G501 -- S3 ETags
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/providers/objectstore"
+	"github.com/develatio/nebulant-cli/storage"
+)
+
+type fakeLogger struct{}
+
+func (l *fakeLogger) LogCritical(s string)    {}
+func (l *fakeLogger) LogErr(s string)         {}
+func (l *fakeLogger) ByteLogErr(b []byte)     {}
+func (l *fakeLogger) LogWarn(s string)        {}
+func (l *fakeLogger) LogInfo(s string)        {}
+func (l *fakeLogger) ByteLogInfo(b []byte)    {}
+func (l *fakeLogger) LogDebug(s string)       {}
+func (l *fakeLogger) Duplicate() base.ILogger { return l }
+func (l *fakeLogger) SetActionID(ai string)   {}
+func (l *fakeLogger) SetThreadID(ti string)   {}
+
+// stubObject is an object stored by the stub server
+type stubObject struct {
+	data     []byte
+	etag     string
+	modified time.Time
+}
+
+// stubS3 is a minimal path style S3 compatible server
+type stubS3 struct {
+	mu       sync.Mutex
+	buckets  map[string]map[string]*stubObject
+	pageSize int
+}
+
+type stubContents struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int64
+	StorageClass string
+}
+
+type stubPrefix struct {
+	Prefix string
+}
+
+type stubListResult struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	Name                  string
+	Prefix                string
+	KeyCount              int
+	MaxKeys               int
+	IsTruncated           bool
+	Contents              []stubContents
+	CommonPrefixes        []stubPrefix
+	NextContinuationToken string `xml:",omitempty"`
+}
+
+type stubDeleteRequest struct {
+	Objects []struct {
+		Key string
+	} `xml:"Object"`
+}
+
+type stubDeleteResult struct {
+	XMLName xml.Name `xml:"DeleteResult"`
+	Deleted []struct {
+		Key string
+	}
+}
+
+func newStubS3() *stubS3 {
+	return &stubS3{buckets: make(map[string]map[string]*stubObject), pageSize: 1000}
+}
+
+func (s *stubS3) put(bucket string, key string, data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sum := md5.Sum([]byte(data)) // #nosec G401 -- S3 ETags
+	s.buckets[bucket][key] = &stubObject{data: []byte(data), etag: hex.EncodeToString(sum[:]), modified: time.Now()}
+}
+
+func (s *stubS3) get(bucket string, key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, exists := s.buckets[bucket][key]
+	if !exists {
+		return "", false
+	}
+	return string(o.data), true
+}
+
+func (s *stubS3) keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := []string{}
+	for k := range s.buckets[bucket] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *stubS3) fail(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func (s *stubS3) writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	out, _ := xml.Marshal(v)
+	w.Write(out)
+}
+
+func (s *stubS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	bucketName := parts[0]
+	key := ""
+	if len(parts) > 1 {
+		key = parts[1]
+	}
+	bucket, bucketExists := s.buckets[bucketName]
+
+	if key == "" {
+		switch {
+		case r.Method == http.MethodPut:
+			if bucketExists {
+				s.fail(w, http.StatusConflict, "BucketAlreadyOwnedByYou")
+				return
+			}
+			s.buckets[bucketName] = make(map[string]*stubObject)
+			w.Header().Set("Location", "/"+bucketName)
+		case !bucketExists:
+			s.fail(w, http.StatusNotFound, "NoSuchBucket")
+		case r.Method == http.MethodHead:
+		case r.Method == http.MethodDelete:
+			if len(bucket) > 0 {
+				s.fail(w, http.StatusConflict, "BucketNotEmpty")
+				return
+			}
+			delete(s.buckets, bucketName)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && r.URL.Query().Has("delete"):
+			req := &stubDeleteRequest{}
+			if err := xml.NewDecoder(r.Body).Decode(req); err != nil {
+				s.fail(w, http.StatusBadRequest, "MalformedXML")
+				return
+			}
+			result := &stubDeleteResult{}
+			for _, o := range req.Objects {
+				delete(bucket, o.Key)
+				result.Deleted = append(result.Deleted, struct{ Key string }{o.Key})
+			}
+			s.writeXML(w, result)
+		case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+			s.list(w, r, bucketName, bucket)
+		default:
+			s.fail(w, http.StatusNotImplemented, "NotImplemented")
+		}
+		return
+	}
+
+	if !bucketExists {
+		s.fail(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.fail(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		sum := md5.Sum(data) // #nosec G401 -- S3 ETags
+		o := &stubObject{data: data, etag: hex.EncodeToString(sum[:]), modified: time.Now()}
+		bucket[key] = o
+		w.Header().Set("ETag", "\""+o.etag+"\"")
+	case http.MethodGet, http.MethodHead:
+		o, exists := bucket[key]
+		if !exists {
+			s.fail(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", "\""+o.etag+"\"")
+		w.Header().Set("Last-Modified", o.modified.UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Length", strconv.Itoa(len(o.data)))
+		if r.Method == http.MethodGet {
+			w.Write(o.data)
+		}
+	case http.MethodDelete:
+		delete(bucket, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.fail(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func (s *stubS3) list(w http.ResponseWriter, r *http.Request, name string, bucket map[string]*stubObject) {
+	q := r.URL.Query()
+	prefix := q.Get("prefix")
+	delimiter := q.Get("delimiter")
+	start, _ := strconv.Atoi(q.Get("continuation-token"))
+
+	keys := []string{}
+	for k := range bucket {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	result := &stubListResult{Name: name, Prefix: prefix, MaxKeys: s.pageSize}
+	seen := make(map[string]bool)
+	i := start
+	for ; i < len(keys) && result.KeyCount < s.pageSize; i++ {
+		k := keys[i]
+		if delimiter != "" {
+			if idx := strings.Index(k[len(prefix):], delimiter); idx >= 0 {
+				cp := k[:len(prefix)+idx+len(delimiter)]
+				if !seen[cp] {
+					seen[cp] = true
+					result.CommonPrefixes = append(result.CommonPrefixes, stubPrefix{cp})
+					result.KeyCount++
+				}
+				continue
+			}
+		}
+		o := bucket[k]
+		result.Contents = append(result.Contents, stubContents{
+			Key:          k,
+			LastModified: o.modified.UTC().Format(time.RFC3339),
+			ETag:         "\"" + o.etag + "\"",
+			Size:         int64(len(o.data)),
+			StorageClass: "STANDARD",
+		})
+		result.KeyCount++
+	}
+	if i < len(keys) {
+		result.IsTruncated = true
+		result.NextContinuationToken = strconv.Itoa(i)
+	}
+	s.writeXML(w, result)
+}
+
+func newTestServer(t *testing.T) (*stubS3, *httptest.Server) {
+	t.Helper()
+	stub := newStubS3()
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+	return stub, srv
+}
+
+func runAction(t *testing.T, srv *httptest.Server, f objectstore.ActionFunc, params string) (*base.ActionOutput, error) {
+	t.Helper()
+	ctx := &objectstore.Context{
+		Action: &blueprint.Action{
+			ActionID:   "a1",
+			Provider:   "aws",
+			Parameters: json.RawMessage(params),
+		},
+		Store:  storage.NewStore(),
+		Logger: &fakeLogger{},
+		NewClient: func() *s3.Client {
+			return s3.New(s3.Options{
+				BaseEndpoint: aws.String(srv.URL),
+				UsePathStyle: true,
+				Region:       "eu-west-1",
+				Credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+					return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}, nil
+				}),
+			})
+		},
+	}
+	return f(ctx)
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFile(t *testing.T, p string) string {
+	t.Helper()
+	data, err := os.ReadFile(p) // #nosec G304 -- test file
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func expectKeys(t *testing.T, got []string, expected ...string) {
+	t.Helper()
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected keys %v, got %v", expected, got)
+	}
+}
+
+func TestRehearsal(t *testing.T) {
+	ctx := &objectstore.Context{
+		Rehearsal: true,
+		Action:    &blueprint.Action{Parameters: json.RawMessage(`{"bucket": "b"}`)},
+	}
+	if _, err := objectstore.CreateBucket(ctx); err != nil {
+		t.Error(err)
+	}
+	if _, err := objectstore.DeleteObjects(ctx); err == nil {
+		t.Error("delete_objects without keys or prefix should fail")
+	}
+	ctx.Action.Parameters = json.RawMessage(`{"bucket": "b", "key": "k", "method": "POST"}`)
+	if _, err := objectstore.PresignURL(ctx); err == nil {
+		t.Error("presign_url with method POST should fail")
+	}
+	ctx.Action.Parameters = json.RawMessage(`{"paths": [{"bucket": "b", "src": "/tmp"}]}`)
+	if _, err := objectstore.UploadFiles(ctx); err == nil {
+		t.Error("upload_files without dest should fail")
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	stub, srv := newTestServer(t)
+
+	aout, err := runAction(t, srv, objectstore.CreateBucket, `{"bucket": "b1", "_waiters": ["WaitUntilBucketExists"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bucket := aout.Records[0].Value.(*objectstore.Bucket)
+	if bucket.Name != "b1" || bucket.Region != "eu-west-1" || aout.Records[0].ValueID != "b1" {
+		t.Errorf("unexpected bucket %+v", bucket)
+	}
+	if _, err := runAction(t, srv, objectstore.CreateBucket, `{"bucket": "b1"}`); err == nil {
+		t.Error("creating an existing bucket should fail")
+	}
+
+	stub.put("b1", "k1", "data")
+	if _, err := runAction(t, srv, objectstore.DeleteBucket, `{"bucket": "b1"}`); err == nil {
+		t.Error("deleting a non empty bucket should fail")
+	}
+	if _, err := runAction(t, srv, objectstore.DeleteBucket, `{"bucket": "b1", "force": true, "_waiters": ["WaitUntilBucketNotExists"]}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := stub.buckets["b1"]; exists {
+		t.Error("bucket b1 should be deleted")
+	}
+}
+
+func TestUploadDownloadFiles(t *testing.T) {
+	stub, srv := newTestServer(t)
+	stub.buckets["b1"] = make(map[string]*stubObject)
+
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{"a.txt": "aaa", "sub/b.txt": "bbb"})
+	aout, err := runAction(t, srv, objectstore.UploadFiles, fmt.Sprintf(`{"paths": [
+		{"bucket": "b1", "src": %q, "dest": "/site"},
+		{"bucket": "b1", "src": %q, "dest": "single"}
+	]}`, src, filepath.Join(src, "a.txt")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := aout.Records[0].Value.(*objectstore.Transfer).Count; count != 3 {
+		t.Errorf("expected 3 uploaded files, got %d", count)
+	}
+	expectKeys(t, stub.keys("b1"), "single/a.txt", "site/a.txt", "site/sub/b.txt")
+
+	dst := t.TempDir()
+	aout, err = runAction(t, srv, objectstore.DownloadFiles, fmt.Sprintf(`{"paths": [
+		{"bucket": "b1", "src": "site", "dest": %q},
+		{"bucket": "b1", "src": "single/a.txt", "dest": %q}
+	]}`, filepath.Join(dst, "site"), dst))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := aout.Records[0].Value.(*objectstore.Transfer).Count; count != 3 {
+		t.Errorf("expected 3 downloaded files, got %d", count)
+	}
+	if readFile(t, filepath.Join(dst, "site", "sub", "b.txt")) != "bbb" || readFile(t, filepath.Join(dst, "a.txt")) != "aaa" {
+		t.Error("unexpected downloaded content")
+	}
+
+	if _, err := runAction(t, srv, objectstore.DownloadFiles, fmt.Sprintf(`{"paths": [{"bucket": "b1", "src": "missing", "dest": %q}]}`, dst)); err == nil {
+		t.Error("downloading a missing prefix should fail")
+	}
+	stub.put("b1", "site/../../evil", "x")
+	if _, err := runAction(t, srv, objectstore.DownloadFiles, fmt.Sprintf(`{"paths": [{"bucket": "b1", "src": "site", "dest": %q}]}`, filepath.Join(dst, "site"))); err == nil {
+		t.Error("keys escaping the dest dir should fail")
+	}
+}
+
+func TestListAndDeleteObjects(t *testing.T) {
+	stub, srv := newTestServer(t)
+	stub.pageSize = 2
+	stub.buckets["b1"] = make(map[string]*stubObject)
+	for _, k := range []string{"logs/1", "logs/2", "logs/3", "logs/old/1", "www/index.html"} {
+		stub.put("b1", k, k)
+	}
+
+	aout, err := runAction(t, srv, objectstore.ListObjects, `{"bucket": "b1", "prefix": "logs/"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := aout.Records[0].Value.(*objectstore.ObjectList)
+	if list.Count != 4 || list.Truncated || list.Objects[0].Size != 6 || list.Objects[0].ETag == "" {
+		t.Errorf("unexpected list %+v", list)
+	}
+
+	aout, err = runAction(t, srv, objectstore.ListObjects, `{"bucket": "b1", "prefix": "logs/", "delimiter": "/", "max_keys": 2}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list = aout.Records[0].Value.(*objectstore.ObjectList)
+	if list.Count != 2 || !list.Truncated {
+		t.Errorf("unexpected list %+v", list)
+	}
+
+	aout, err = runAction(t, srv, objectstore.ListObjects, `{"bucket": "b1", "delimiter": "/"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list = aout.Records[0].Value.(*objectstore.ObjectList)
+	expectKeys(t, list.CommonPrefixes, "logs/", "www/")
+
+	aout, err = runAction(t, srv, objectstore.DeleteObjects, `{"bucket": "b1", "keys": ["www/index.html"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectKeys(t, aout.Records[0].Value.(*objectstore.DeletedObjects).Deleted, "www/index.html")
+
+	aout, err = runAction(t, srv, objectstore.DeleteObjects, `{"bucket": "b1", "prefix": "logs/old"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectKeys(t, aout.Records[0].Value.(*objectstore.DeletedObjects).Deleted, "logs/old/1")
+	expectKeys(t, stub.keys("b1"), "logs/1", "logs/2", "logs/3")
+}
+
+func TestPresignURL(t *testing.T) {
+	stub, srv := newTestServer(t)
+	stub.buckets["b1"] = make(map[string]*stubObject)
+	stub.put("b1", "dir/file.txt", "hello")
+
+	aout, err := runAction(t, srv, objectstore.PresignURL, `{"bucket": "b1", "key": "dir/file.txt", "expires": 60}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	purl := aout.Records[0].Value.(*objectstore.PresignedURL)
+	if purl.Method != "GET" || !strings.HasPrefix(purl.URL, srv.URL+"/b1/dir/file.txt?") || !strings.Contains(purl.URL, "X-Amz-Expires=60") {
+		t.Errorf("unexpected presigned url %+v", purl)
+	}
+	resp, err := http.Get(purl.URL) // #nosec G107 -- test server url
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "hello" {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	aout, err = runAction(t, srv, objectstore.PresignURL, `{"bucket": "b1", "key": "up.txt", "method": "PUT"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	purl = aout.Records[0].Value.(*objectstore.PresignedURL)
+	if purl.Method != "PUT" || !strings.Contains(purl.URL, "X-Amz-Expires=3600") {
+		t.Errorf("unexpected presigned url %+v", purl)
+	}
+}
+
+func TestSyncDirectory(t *testing.T) {
+	stub, srv := newTestServer(t)
+	stub.buckets["b1"] = make(map[string]*stubObject)
+	stub.put("b1", "site/stale.txt", "old")
+	stub.put("b1", "site/same.txt", "same")
+	stub.put("b1", "other/keep.txt", "keep")
+
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{"same.txt": "same", "new.txt": "new", "sub/changed.txt": "v2"})
+	stub.put("b1", "site/sub/changed.txt", "v1")
+
+	aout, err := runAction(t, srv, objectstore.SyncDirectory, fmt.Sprintf(`{"bucket": "b1", "src": %q, "dest": "site", "delete": true}`, src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := aout.Records[0].Value.(*objectstore.SyncResult)
+	expectKeys(t, result.Uploaded, "site/new.txt", "site/sub/changed.txt")
+	expectKeys(t, result.Deleted, "site/stale.txt")
+	if result.Skipped != 1 {
+		t.Errorf("expected 1 skipped file, got %d", result.Skipped)
+	}
+	expectKeys(t, stub.keys("b1"), "other/keep.txt", "site/new.txt", "site/same.txt", "site/sub/changed.txt")
+	if data, _ := stub.get("b1", "site/sub/changed.txt"); data != "v2" {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	dst := t.TempDir()
+	writeFiles(t, dst, map[string]string{"extra.txt": "x", "same.txt": "same"})
+	aout, err = runAction(t, srv, objectstore.SyncDirectory, fmt.Sprintf(`{"bucket": "b1", "src": %q, "dest": "site/", "direction": "download", "delete": true}`, dst))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result = aout.Records[0].Value.(*objectstore.SyncResult)
+	expectKeys(t, result.Downloaded, filepath.Join(dst, "new.txt"), filepath.Join(dst, "sub", "changed.txt"))
+	expectKeys(t, result.Deleted, filepath.Join(dst, "extra.txt"))
+	if result.Skipped != 1 || readFile(t, filepath.Join(dst, "sub", "changed.txt")) != "v2" {
+		t.Errorf("unexpected sync result %+v", result)
+	}
+}
@@ -0,0 +1,490 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package objectstore
+
+import (
+	"context"
+	"crypto/md5" // #nosec G501 -- used to compare against S3 ETags
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/util"
+)
+
+type transferPath struct {
+	Bucket *string `json:"bucket" validate:"required"`
+	Dst    *string `json:"dest" validate:"required"`
+	Src    *string `json:"src" validate:"required"`
+}
+
+type transferParameters struct {
+	Paths []transferPath `json:"paths" validate:"required,dive"`
+}
+
+type syncDirectoryParameters struct {
+	Bucket *string `json:"bucket" validate:"required"`
+	// Src is the local directory
+	Src *string `json:"src" validate:"required"`
+	// Dst is the key prefix inside the bucket
+	Dst       *string `json:"dest"`
+	Direction string  `json:"direction" validate:"omitempty,oneof=upload download"`
+	// Delete removes from the target the files missing in the origin
+	Delete bool `json:"delete"`
+}
+
+// TransferredFile struct
+type TransferredFile struct {
+	Bucket   string `json:"bucket"`
+	Key      string `json:"key"`
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Location string `json:"location,omitempty"`
+}
+
+// Transfer struct
+type Transfer struct {
+	Files []*TransferredFile `json:"files"`
+	Count int                `json:"count"`
+}
+
+// SyncResult struct
+type SyncResult struct {
+	Direction  string   `json:"direction"`
+	Bucket     string   `json:"bucket"`
+	Prefix     string   `json:"prefix"`
+	Dir        string   `json:"dir"`
+	Uploaded   []string `json:"uploaded"`
+	Downloaded []string `json:"downloaded"`
+	Deleted    []string `json:"deleted"`
+	Skipped    int      `json:"skipped"`
+}
+
+func uploadFile(ctx *Context, uploader *manager.Uploader, bucket string, key string, src string) (*TransferredFile, error) {
+	upfile, err := os.Open(src) // #nosec G304 -- file to upload is chosen by the blueprint
+	if err != nil {
+		return nil, err
+	}
+	defer upfile.Close()
+	fi, err := upfile.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Logger.LogDebug(fmt.Sprintf("uploading file to bucket %s and key %v", bucket, key))
+	result, err := uploader.Upload(context.TODO(), &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   upfile,
+	})
+	if err != nil {
+		var mu manager.MultiUploadFailure
+		if errors.As(err, &mu) {
+			return nil, errors.Join(err, fmt.Errorf("upload ID: %s", mu.UploadID()))
+		}
+		return nil, err
+	}
+	ctx.Logger.LogInfo(fmt.Sprintf("uploaded file %s -> %s", src, result.Location))
+	return &TransferredFile{
+		Bucket:   bucket,
+		Key:      key,
+		Path:     src,
+		Size:     fi.Size(),
+		Location: result.Location,
+	}, nil
+}
+
+func downloadFile(ctx *Context, client *s3.Client, bucket string, key string, dst string) (*TransferredFile, error) {
+	ctx.Logger.LogDebug(fmt.Sprintf("downloading key %s from bucket %s", key, bucket))
+	result, err := client.GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer result.Body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil { // #nosec G301 -- same as a regular download dir
+		return nil, err
+	}
+	// download into a tmp file next to dst so a failed download does not
+	// leave a half written file behind
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return nil, err
+	}
+	size, err := io.Copy(tmp, result.Body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dst)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	ctx.Logger.LogInfo(fmt.Sprintf("downloaded s3://%s/%s -> %s", bucket, key, dst))
+	return &TransferredFile{
+		Bucket: bucket,
+		Key:    key,
+		Path:   dst,
+		Size:   size,
+	}, nil
+}
+
+// localFiles returns the regular files under dir indexed by its slash
+// separated path relative to dir
+func localFiles(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = p
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// sameContent compares a local file with a remote object. Multipart
+// uploads have an ETag that is not the md5 of the content, so they are
+// compared by size only
+func sameContent(p string, o *Object) (bool, error) {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return false, err
+	}
+	if fi.Size() != o.Size {
+		return false, nil
+	}
+	if strings.Contains(o.ETag, "-") {
+		return true, nil
+	}
+	f, err := os.Open(p) // #nosec G304 -- file inside the synced dir
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	h := md5.New() // #nosec G401 -- used to compare against S3 ETags
+	if _, err := io.Copy(h, f); err != nil {
+		return false, err
+	}
+	return hex.EncodeToString(h.Sum(nil)) == o.ETag, nil
+}
+
+// UploadFiles func
+func UploadFiles(ctx *Context) (*base.ActionOutput, error) {
+	params := new(transferParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+
+	uploader := newUploader(ctx.NewClient())
+	transfer := &Transfer{Files: []*TransferredFile{}}
+
+	ctx.Logger.LogDebug("Uploading...")
+	for _, upp := range params.Paths {
+		cleanPrefix(ctx, upp.Dst)
+		fi, err := os.Stat(*upp.Src)
+		if err != nil {
+			return nil, err
+		}
+		// upload file
+		if !fi.IsDir() {
+			tf, err := uploadFile(ctx, uploader, *upp.Bucket, objectKey(*upp.Dst, filepath.Base(*upp.Src)), *upp.Src)
+			if err != nil {
+				return nil, err
+			}
+			transfer.Files = append(transfer.Files, tf)
+			continue
+		}
+		// upload dir
+		ctx.Logger.LogDebug("Walking files...")
+		err = filepath.WalkDir(*upp.Src, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(*upp.Src, p)
+			if err != nil {
+				return err
+			}
+			tf, err := uploadFile(ctx, uploader, *upp.Bucket, objectKey(*upp.Dst, rel), p)
+			if err != nil {
+				return err
+			}
+			transfer.Files = append(transfer.Files, tf)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	transfer.Count = len(transfer.Files)
+
+	aout := base.NewActionOutput(ctx.Action, transfer, nil)
+	return aout, nil
+}
+
+// DownloadFiles func
+func DownloadFiles(ctx *Context) (*base.ActionOutput, error) {
+	params := new(transferParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+
+	client := ctx.NewClient()
+	transfer := &Transfer{Files: []*TransferredFile{}}
+
+	ctx.Logger.LogDebug("Downloading...")
+	for _, dp := range params.Paths {
+		cleanPrefix(ctx, dp.Src)
+		objects, err := listAll(client, *dp.Bucket, *dp.Src)
+		if err != nil {
+			return nil, err
+		}
+
+		// src is the key of a single object
+		single := false
+		for _, o := range objects {
+			if o.Key == *dp.Src {
+				single = true
+				break
+			}
+		}
+		if single {
+			dst := *dp.Dst
+			if fi, err := os.Stat(dst); (err == nil && fi.IsDir()) || strings.HasSuffix(dst, string(filepath.Separator)) {
+				dst = filepath.Join(dst, filepath.Base(filepath.FromSlash(*dp.Src)))
+			}
+			tf, err := downloadFile(ctx, client, *dp.Bucket, *dp.Src, dst)
+			if err != nil {
+				return nil, err
+			}
+			transfer.Files = append(transfer.Files, tf)
+			continue
+		}
+
+		if len(objects) == 0 {
+			return nil, fmt.Errorf("no objects found in bucket %s with key or prefix %s", *dp.Bucket, *dp.Src)
+		}
+		for _, o := range objects {
+			// skip "directory" placeholders
+			if strings.HasSuffix(o.Key, "/") {
+				continue
+			}
+			dst, err := localPath(*dp.Dst, *dp.Src, o.Key)
+			if err != nil {
+				return nil, err
+			}
+			tf, err := downloadFile(ctx, client, *dp.Bucket, o.Key, dst)
+			if err != nil {
+				return nil, err
+			}
+			transfer.Files = append(transfer.Files, tf)
+		}
+	}
+	transfer.Count = len(transfer.Files)
+
+	aout := base.NewActionOutput(ctx.Action, transfer, nil)
+	return aout, nil
+}
+
+// SyncDirectory func
+func SyncDirectory(ctx *Context) (*base.ActionOutput, error) {
+	params := new(syncDirectoryParameters)
+	if err := util.UnmarshalParameters(ctx.Action.Parameters, params, ctx.Store); err != nil {
+		return nil, err
+	}
+	if ctx.Rehearsal {
+		return nil, nil
+	}
+
+	err := ctx.Store.DeepInterpolation(params)
+	if err != nil {
+		return nil, err
+	}
+	cleanPrefix(ctx, params.Dst)
+
+	if params.Direction == "" {
+		params.Direction = "upload"
+	}
+	prefix := stringValue(params.Dst)
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+	bucket := *params.Bucket
+	dir := *params.Src
+	result := &SyncResult{
+		Direction:  params.Direction,
+		Bucket:     bucket,
+		Prefix:     prefix,
+		Dir:        dir,
+		Uploaded:   []string{},
+		Downloaded: []string{},
+		Deleted:    []string{},
+	}
+
+	client := ctx.NewClient()
+	objects, err := listAll(client, bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+	remote := make(map[string]*Object)
+	for _, o := range objects {
+		if strings.HasSuffix(o.Key, "/") {
+			continue
+		}
+		remote[strings.TrimPrefix(o.Key, prefix)] = o
+	}
+
+	switch params.Direction {
+	case "download":
+		if err := os.MkdirAll(dir, 0755); err != nil { // #nosec G301 -- same as a regular download dir
+			return nil, err
+		}
+		local, err := localFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		for rel, o := range remote {
+			if p, exists := local[rel]; exists {
+				same, err := sameContent(p, o)
+				if err != nil {
+					return nil, err
+				}
+				if same {
+					result.Skipped++
+					continue
+				}
+			}
+			dst, err := localPath(dir, prefix, o.Key)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := downloadFile(ctx, client, bucket, o.Key, dst); err != nil {
+				return nil, err
+			}
+			result.Downloaded = append(result.Downloaded, dst)
+		}
+		if params.Delete {
+			for rel, p := range local {
+				if _, exists := remote[rel]; exists {
+					continue
+				}
+				ctx.Logger.LogInfo(fmt.Sprintf("deleting %s", p))
+				if err := os.Remove(p); err != nil {
+					return nil, err
+				}
+				result.Deleted = append(result.Deleted, p)
+			}
+		}
+	default:
+		local, err := localFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		uploader := newUploader(client)
+		for rel, p := range local {
+			if o, exists := remote[rel]; exists {
+				same, err := sameContent(p, o)
+				if err != nil {
+					return nil, err
+				}
+				if same {
+					result.Skipped++
+					continue
+				}
+			}
+			tf, err := uploadFile(ctx, uploader, bucket, prefix+rel, p)
+			if err != nil {
+				return nil, err
+			}
+			result.Uploaded = append(result.Uploaded, tf.Key)
+		}
+		if params.Delete {
+			var keys []string
+			for rel, o := range remote {
+				if _, exists := local[rel]; !exists {
+					keys = append(keys, o.Key)
+				}
+			}
+			if len(keys) > 0 {
+				ctx.Logger.LogInfo(fmt.Sprintf("deleting %d objects from bucket %s", len(keys), bucket))
+				deleted, err := deleteKeys(client, bucket, keys)
+				if err != nil {
+					return nil, err
+				}
+				result.Deleted = append(result.Deleted, deleted...)
+			}
+		}
+	}
+
+	sort.Strings(result.Uploaded)
+	sort.Strings(result.Downloaded)
+	sort.Strings(result.Deleted)
+	ctx.Logger.LogInfo(fmt.Sprintf("sync done: %d uploaded, %d downloaded, %d deleted, %d skipped", len(result.Uploaded), len(result.Downloaded), len(result.Deleted), result.Skipped))
+	aout := base.NewActionOutput(ctx.Action, result, nil)
+	return aout, nil
+}
@@ -0,0 +1,110 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package runtime
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/develatio/nebulant-cli/blueprint"
+)
+
+// ActionStatus values
+const (
+	ActionStatusRunning = "running"
+	ActionStatusOK      = "ok"
+	ActionStatusKO      = "ko"
+)
+
+// ActionState struct. Summary of the runs of an action
+type ActionState struct {
+	ActionID   string     `json:"action_id"`
+	ActionName string     `json:"action_name"`
+	Provider   string     `json:"provider"`
+	Status     string     `json:"status"`
+	Runs       int        `json:"runs"`
+	Running    int        `json:"running"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+type actionStates struct {
+	mu     sync.Mutex
+	states map[string]*ActionState
+}
+
+func (a *actionStates) start(action *blueprint.Action) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	state, exists := a.states[action.ActionID]
+	if !exists {
+		state = &ActionState{
+			ActionID:   action.ActionID,
+			ActionName: action.ActionName,
+			Provider:   action.Provider,
+		}
+		a.states[action.ActionID] = state
+	}
+	state.Runs++
+	state.Running++
+	state.Status = ActionStatusRunning
+	state.StartedAt = time.Now()
+	state.FinishedAt = nil
+}
+
+func (a *actionStates) end(action *blueprint.Action, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	state, exists := a.states[action.ActionID]
+	if !exists {
+		return
+	}
+	state.Running--
+	now := time.Now()
+	state.FinishedAt = &now
+	state.Error = ""
+	state.Status = ActionStatusOK
+	if err != nil {
+		state.Status = ActionStatusKO
+		state.Error = err.Error()
+	}
+	if state.Running > 0 {
+		// other threads are still running the action
+		state.Status = ActionStatusRunning
+	}
+}
+
+// slice returns a copy of the states sorted by start time
+func (a *actionStates) slice() []*ActionState {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make([]*ActionState, 0, len(a.states))
+	for _, state := range a.states {
+		st := *state
+		out = append(out, &st)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].StartedAt.Before(out[j].StartedAt) })
+	return out
+}
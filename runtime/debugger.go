@@ -35,12 +35,14 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
 	"github.com/develatio/nebulant-cli/cast"
 	"github.com/develatio/nebulant-cli/config"
 	ws "github.com/develatio/nebulant-cli/netproto/websocket"
@@ -261,7 +263,7 @@ func (d *debugger) startServerMode(started chan struct{}) error {
 	// TODO: lookup for available port?
 	id := d.runtime.irb.ExecutionUUID
 	srv := nhttpd.GetServer()
-	srv.AddView(`/debugger/`+*id, d.debuggerView)
+	srv.AddView(`^/debugger/`+regexp.QuoteMeta(*id)+`$`, d.debuggerView)
 	d.close = srv.ServeIfNot()
 	scheme := "ws"
 	if srv.GetScheme() == "https" {
@@ -873,8 +875,7 @@ func (d *debugger) ExecCmd(cc *client, cmd string) {
 					return
 				}
 				aa[0] = *refname
-				ppp := fmt.Sprintf("{{%s}}", strings.Join(aa, "."))
-				err := actxstore.Interpolate(&ppp)
+				ppp, err := printableRef(actxstore, strings.Join(aa, "."))
 				if err != nil {
 					fmt.Fprint(clientFD, err.Error())
 					fmt.Fprintf(clientFD, "\n")
@@ -892,6 +893,11 @@ func (d *debugger) ExecCmd(cc *client, cmd string) {
 					fmt.Fprintf(clientFD, "\n")
 					return
 				}
+				for refname := range v {
+					if isSecretRef(store, refname) {
+						v[refname] = json.RawMessage(`"` + secretMask + `"`)
+					}
+				}
 				enc, err := json.MarshalIndent(v, "", "    ")
 				if err != nil {
 					fmt.Fprint(clientFD, err.Error())
@@ -904,9 +910,12 @@ func (d *debugger) ExecCmd(cc *client, cmd string) {
 			}
 		}
 
-		actxstore := d.cursor.GetStore()
-		ppp := fmt.Sprintf("{{%s}}", strings.Join(aa, "."))
-		actxstore.Interpolate(&ppp)
+		ppp, err := printableRef(d.cursor.GetStore(), strings.Join(aa, "."))
+		if err != nil {
+			fmt.Fprint(clientFD, err.Error())
+			fmt.Fprintf(clientFD, "\n")
+			return
+		}
 		fmt.Fprint(clientFD, ppp)
 		fmt.Fprintf(clientFD, "\n")
 	case "u":
@@ -1277,3 +1286,31 @@ func (c *client) start(attach bool) {
 		c.dbg.hightLightCursor()
 	}
 }
+
+// secretMask is printed by the debugger instead of the secret records
+const secretMask = "[SECRET]"
+
+// isSecretRef reports whether the record of refname holds a secret
+func isSecretRef(store base.IStore, refname string) bool {
+	sr, err := store.GetByRefName(refname)
+	return err == nil && sr.Secret
+}
+
+// printableRef interpolates {{ path }} in store as the debugger prints
+// it: the whole text is masked if it uses any secret record
+func printableRef(store base.IStore, path string) (string, error) {
+	ppp := fmt.Sprintf("{{%s}}", path)
+	tpl, err := blueprint.CompileTemplate(ppp)
+	if err != nil {
+		return "", err
+	}
+	for _, node := range tpl.Nodes {
+		if node.Type == blueprint.TemplateNodeReference && isSecretRef(store, node.Name) {
+			return secretMask, nil
+		}
+	}
+	if err := store.Interpolate(&ppp); err != nil {
+		return "", err
+	}
+	return ppp, nil
+}
@@ -0,0 +1,113 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/cast"
+	"github.com/develatio/nebulant-cli/storage"
+)
+
+func TestJoinLoopReentryAny(t *testing.T) {
+	rt := NewRuntime(&blueprint.IRBlueprint{}, false)
+	fork := &blueprint.Action{ActionID: "fork"}
+	branches := []*blueprint.Action{{ActionID: "a"}, {ActionID: "b"}}
+	join := &blueprint.Action{
+		ActionID:         "join",
+		JoinThreadsPoint: true,
+		JoinParameters: &blueprint.JoinThreadsParameters{
+			Strategy:    blueprint.JoinStrategyAny,
+			MergePolicy: blueprint.JoinMergeLastWriterWins,
+		},
+	}
+
+	// fork -> a, b -> join, returns the join contexts of both branches
+	iteration := func(parent base.IActionContext) (base.IActionContext, base.IActionContext) {
+		forkctx := rt.NewAContext(parent, fork)
+		threadctx := rt.NewAContextThread(forkctx, branches)
+		children := threadctx.Children()
+		return rt.NewAContext(children[0], join), rt.NewAContext(children[1], join)
+	}
+
+	start := rt.NewAContext(nil, &blueprint.Action{ActionID: "start"})
+	st := storage.NewStore()
+	st.SetLogger(&cast.DummyLogger{})
+	start.SetStore(st)
+
+	ja1, jb1 := iteration(start)
+	if rt.cjoiner.Join(ja1) == nil {
+		t.Fatal("first branch should wait at the join point")
+	}
+	// any strategy, released with b still running
+	rt.cjoiner.Fire(ja1)
+	rt.cjoiner.Reset(ja1)
+
+	// the flow after the join loops back to the fork
+	ja2, jb2 := iteration(ja1)
+	if rt.cjoiner.Join(ja2) == nil {
+		t.Fatal("loop re-entry should wait at a new join point")
+	}
+	if rt.cjoiner.Join(jb1) != nil {
+		t.Error("late branch of the released join point should be discarded")
+	}
+	if arrived := rt.cjoiner.Arrived(ja2); arrived != 1 {
+		t.Errorf("late branch joined the new join point, arrived %d", arrived)
+	}
+	if rt.cjoiner.Join(jb2) != nil {
+		t.Error("second branch of the loop should be merged into the waiter")
+	}
+	if arrived := rt.cjoiner.Arrived(ja2); arrived != 2 {
+		t.Errorf("expected 2 arrived branches, got %d", arrived)
+	}
+}
+
+func TestJoinAncestryBound(t *testing.T) {
+	rt := NewRuntime(&blueprint.IRBlueprint{}, false)
+	step := &blueprint.Action{ActionID: "step"}
+	root := rt.NewAContext(nil, step)
+	actx := root
+	for i := 0; i < maxAncestryDepth+1; i++ {
+		actx = rt.NewAContext(actx, step)
+	}
+	if descendsFrom(actx, root) {
+		t.Error("ancestry walk should stop at maxAncestryDepth")
+	}
+	if !descendsFrom(actx, actx.Parents()[0]) {
+		t.Error("direct parent should be found")
+	}
+
+	st := storage.NewStore()
+	st.SetLogger(&cast.DummyLogger{})
+	root.SetStore(st)
+	jctx := rt.NewAContext(root, &blueprint.Action{ActionID: "join", JoinThreadsPoint: true})
+	rt.cjoiner.Join(jctx)
+	rt.cjoiner.Fire(jctx)
+	rt.cjoiner.Reset(jctx)
+	rt.cjoiner.Clear()
+	if len(rt.cjoiner.released) > 0 || len(rt.cjoiner.pt) > 0 {
+		t.Error("released join points should be dropped on clear")
+	}
+}
@@ -39,14 +39,43 @@ import (
 	"github.com/develatio/nebulant-cli/nsterm"
 )
 
+// changeNotifier wakes up every goroutine waiting for a change in the
+// runtime scheduling state (contexts activated or deactivated, branches
+// arriving to a join point, threads stopped...)
+type changeNotifier struct {
+	mu sync.Mutex
+	c  chan struct{}
+}
+
+// Changed returns a chan that will be closed on the next Notify call.
+// Obtain it before checking the state to not miss any change.
+func (n *changeNotifier) Changed() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.c == nil {
+		n.c = make(chan struct{})
+	}
+	return n.c
+}
+
+func (n *changeNotifier) Notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.c != nil {
+		close(n.c)
+		n.c = nil
+	}
+}
+
 type activeAction struct {
 	ctxs  []base.IActionContext
 	count int
 }
 
 type activeActionsID struct {
-	mu sync.Mutex
-	a  map[string]*activeAction
+	mu     sync.Mutex
+	a      map[string]*activeAction
+	notify *changeNotifier
 }
 
 // func (a *activeActionsID) _pdbg() {
@@ -69,6 +98,7 @@ func (a *activeActionsID) More(actx base.IActionContext) {
 		}
 	}
 	a.a[id].count++
+	a.notify.Notify()
 }
 
 func (a *activeActionsID) Less(actx base.IActionContext) {
@@ -79,6 +109,7 @@ func (a *activeActionsID) Less(actx base.IActionContext) {
 		return
 	}
 	a.a[id].count--
+	a.notify.Notify()
 }
 
 func (a *activeActionsID) Exists(id string) bool {
@@ -102,6 +133,32 @@ func (a *activeActionsID) ExistsAny(ids map[string]bool) bool {
 	return false
 }
 
+// ExistsAnyOrForking is like ExistsAny but also reports as running the
+// thread points forked from any of ids whose threads have not activated
+// his first context yet.
+func (a *activeActionsID) ExistsAnyOrForking(ids map[string]bool) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for id, activ := range a.a {
+		if activ.count <= 0 {
+			continue
+		}
+		if _, exists := ids[id]; exists {
+			return true
+		}
+		actx := activ.ctxs[0]
+		if actx.Type() != base.ContextTypeThread {
+			continue
+		}
+		for _, prnt := range actx.Parents() {
+			if _, exists := ids[prnt.GetAction().ActionID]; exists {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (a *activeActionsID) Slice() []string {
 	a.mu.Lock()
 	defer a.mu.Unlock()
@@ -117,11 +174,26 @@ func (a *activeActionsID) Slice() []string {
 type contextJoinerPoint struct {
 	t chan struct{}
 	p base.IActionContext
+	// params of the join_threads action
+	params *blueprint.JoinThreadsParameters
+	// ids of the actions that has reached the join point
+	arrived []string
+	// the join point has been released, late
+	// branches will be discarded
+	fired bool
+	// merge errors (fail_on_conflict policy)
+	errs []error
 }
 
 type contextJoiner struct {
 	mu sync.Mutex
 	pt map[string]*contextJoinerPoint
+	// context of the last released waiter of each join
+	// point. Branches not descending from it are late
+	// branches of that release and will be discarded.
+	// Replaced on every release and cleared on run end
+	released map[string]base.IActionContext
+	notify   *changeNotifier
 }
 
 func (j *contextJoiner) Lock() {
@@ -132,11 +204,33 @@ func (j *contextJoiner) Unlock() {
 	j.mu.Unlock()
 }
 
-func (j *contextJoiner) Join(actx base.IActionContext) chan struct{} {
+func arrivedFrom(actx base.IActionContext) string {
+	prs := actx.Parents()
+	if len(prs) <= 0 {
+		return ""
+	}
+	return prs[0].GetAction().ActionID
+}
+
+// Join registers actx into his join point. The first context
+// arriving to the join point is returned as waiter, the next ones
+// are merged into the waiter store and nil is returned
+func (j *contextJoiner) Join(actx base.IActionContext) *contextJoinerPoint {
 	j.mu.Lock()
 	defer j.mu.Unlock()
 	action := actx.GetAction()
+	if rel, exists := j.released[action.ActionID]; exists && !descendsFrom(actx, rel) {
+		// the join point has been released before this
+		// branch arrives and this is not a loop re-entry,
+		// discard it
+		return nil
+	}
 	if jpoint, exists := j.pt[action.ActionID]; exists {
+		if jpoint.fired {
+			// the join point has been released before
+			// this branch arrives, discard it
+			return nil
+		}
 		// jpctx := jpoint.p
 		// If all goes ok, actx should has one or zero parents
 		// because the merge should be done here
@@ -145,18 +239,62 @@ func (j *contextJoiner) Join(actx base.IActionContext) chan struct{} {
 		if len(prs) > 1 {
 			panic("hey dev, this is your fault :*")
 		}
-		jpoint.p.GetStore().Merge(actx.GetStore())
+		err := jpoint.p.GetStore().MergeWithPolicy(actx.GetStore(), jpoint.params.MergePolicy)
+		if err != nil {
+			jpoint.errs = append(jpoint.errs, err)
+		}
+		jpoint.arrived = append(jpoint.arrived, arrivedFrom(actx))
+		j.notify.Notify()
 		return nil
 	}
-	ticker := make(chan struct{})
-	j.pt[action.ActionID] = &contextJoinerPoint{
-		t: ticker,
-		p: actx,
+	params := action.JoinParameters
+	if params == nil {
+		params = &blueprint.JoinThreadsParameters{
+			Strategy:    blueprint.JoinStrategyAll,
+			MergePolicy: blueprint.JoinMergeLastWriterWins,
+		}
+	}
+	jpoint := &contextJoinerPoint{
+		t:       make(chan struct{}),
+		p:       actx,
+		params:  params,
+		arrived: []string{arrivedFrom(actx)},
 	}
-	return ticker
+	j.pt[action.ActionID] = jpoint
+	return jpoint
 }
 
-func (j *contextJoiner) Destroy(actx base.IActionContext) {
+// Fire releases the join point. Returns the ids of the
+// arrived branches and the merge errors if any
+func (j *contextJoiner) Fire(actx base.IActionContext) ([]string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	action := actx.GetAction()
+	jpoint, exists := j.pt[action.ActionID]
+	if !exists {
+		return nil, nil
+	}
+	jpoint.fired = true
+	arrived := make([]string, len(jpoint.arrived))
+	copy(arrived, jpoint.arrived)
+	return arrived, errors.Join(jpoint.errs...)
+}
+
+// Arrived returns the count of branches that has reached the join point
+func (j *contextJoiner) Arrived(actx base.IActionContext) int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	jpoint, exists := j.pt[actx.GetAction().ActionID]
+	if !exists {
+		return 0
+	}
+	return len(jpoint.arrived)
+}
+
+// Reset removes the released join point so it can be joined again
+// on loops. Branches still running towards the released join point
+// will be discarded on arrival
+func (j *contextJoiner) Reset(actx base.IActionContext) {
 	j.mu.Lock()
 	defer j.mu.Unlock()
 	action := actx.GetAction()
@@ -164,6 +302,51 @@ func (j *contextJoiner) Destroy(actx base.IActionContext) {
 		close(j.pt[action.ActionID].t)
 		delete(j.pt, action.ActionID)
 	}
+	j.released[action.ActionID] = actx
+}
+
+// Clear drops all the join points and releases. Should be
+// called when the run ends
+func (j *contextJoiner) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.pt = map[string]*contextJoinerPoint{}
+	j.released = map[string]base.IActionContext{}
+}
+
+// maxAncestryDepth bounds the parents walk of descendsFrom. The
+// chain grows on every loop iteration, but a loop re-entry always
+// finds the released context within the last iteration
+const maxAncestryDepth = 1024
+
+// descendsFrom reports whether ancestor is in the parents chain of
+// actx, looking up to maxAncestryDepth levels
+func descendsFrom(actx base.IActionContext, ancestor base.IActionContext) bool {
+	visited := make(map[base.IActionContext]bool)
+	level := actx.Parents()
+	for depth := 0; depth < maxAncestryDepth && len(level) > 0; depth++ {
+		var next []base.IActionContext
+		for _, p := range level {
+			if p == nil || visited[p] {
+				continue
+			}
+			if p == ancestor {
+				return true
+			}
+			visited[p] = true
+			next = append(next, p.Parents()...)
+		}
+		level = next
+	}
+	return false
+}
+
+// JoinResult struct. Output of the join_threads action
+type JoinResult struct {
+	Strategy  blueprint.JoinStrategy `json:"strategy"`
+	Arrived   []string               `json:"arrived"`
+	Cancelled int                    `json:"cancelled"`
+	TimedOut  bool                   `json:"timed_out"`
 }
 
 type runtimeEvent struct {
@@ -174,16 +357,22 @@ func (r *runtimeEvent) EventCode() base.EventCode { return r.ecode }
 func (r *runtimeEvent) String() string            { return fmt.Sprintf("runtime event: %v", r.ecode) }
 
 func NewRuntime(irb *blueprint.IRBlueprint, serverMode bool) *Runtime {
+	notify := &changeNotifier{}
 	return &Runtime{
 		irb:                irb,
 		serverMode:         serverMode,
 		actionContextStack: make([]base.IActionContext, 0, 1),
+		notify:             notify,
 		activeActionsID: &activeActionsID{
-			a: make(map[string]*activeAction),
+			a:      make(map[string]*activeAction),
+			notify: notify,
 		},
 		cjoiner: &contextJoiner{
-			pt: map[string]*contextJoinerPoint{},
+			pt:       map[string]*contextJoinerPoint{},
+			released: map[string]base.IActionContext{},
+			notify:   notify,
 		},
+		actionStates:  &actionStates{states: make(map[string]*ActionState)},
 		activeThreads: make(map[*Thread]bool),
 		evDispatcher:  base.NewEventDispatcher(),
 		exitCode:      0,
@@ -559,6 +748,7 @@ func (t *Thread) Stop() {
 		close(t.step)
 	}
 	t.state = base.RuntimeStateEnding
+	t.runtime.notify.Notify()
 }
 
 func (t *Thread) StackUp() (<-chan struct{}, bool) {
@@ -703,6 +893,14 @@ func (t *Thread) _runCurrent() {
 		return
 	}
 
+	var jpoint *contextJoinerPoint
+	if action.JoinThreadsPoint {
+		// register the branch before switching the context,
+		// so the waiter cannot see the parent deactivated
+		// before his store has been merged
+		jpoint = t.runtime.cjoiner.Join(actx)
+	}
+
 	t.runtime.switchContext(actx) // deactivate parent, activate self (actx)
 
 	t.ThreadStep = ThreadIntoAction
@@ -710,40 +908,41 @@ func (t *Thread) _runCurrent() {
 		t.ThreadStep = ThreadAfterAction
 	}()
 
+	var aout *base.ActionOutput
+	var aerr error
 	if action.JoinThreadsPoint {
-		tt := t.runtime.cjoiner.Join(actx)
-		if tt == nil {
+		if jpoint == nil {
 			// destroy this thread, there is already
 			// a thread handling the join point
 			t.runtime._deactivateContext(actx)
 			return
 		}
-
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
-		for {
-			<-ticker.C
-			if t.state == base.RuntimeStateEnding || t.state == base.RuntimeStateEnd {
-				break
-			}
-			if t.runtime.hasRunningParents(actx) {
-				continue
-			}
-			break
+		aout, aerr = t._waitJoinPoint(actx, jpoint)
+		if t.state == base.RuntimeStateEnding || t.state == base.RuntimeStateEnd {
+			return
+		}
+	} else {
+		aout, aerr = actx.RunAction()
+		if t.state == base.RuntimeStateEnding || t.state == base.RuntimeStateEnd {
+			// the thread has been stopped (or cancelled by
+			// a join point) while running, discard result
+			return
 		}
 	}
 
-	aout, aerr := actx.RunAction()
-
 	// recopilate nexts
 	if aerr != nil {
-		provider, err := actx.GetStore().GetProvider(action.Provider)
-		if err != nil {
-			// hey dev, this is your fault. Only an internal action has no
-			// provider and you should handle these actions before this
-			log.Panic(errors.Join(err, fmt.Errorf("cannot obtain provider %s", action.Provider)))
+		var err error
+		if !actx.IsJoinPoint() {
+			var provider base.IProvider
+			provider, err = actx.GetStore().GetProvider(action.Provider)
+			if err != nil {
+				// hey dev, this is your fault. Only an internal action has no
+				// provider and you should handle these actions before this
+				log.Panic(errors.Join(err, fmt.Errorf("cannot obtain provider %s", action.Provider)))
+			}
+			nexts, err = provider.OnActionErrorHook(aout)
 		}
-		nexts, err = provider.OnActionErrorHook(aout)
 		// update action err on provider err hook err (nil will be ignored)
 
 		aerr = errors.Join(fmt.Errorf("%s %s KO", action.ActionID, action.ActionName), aerr, err)
@@ -796,6 +995,77 @@ func (t *Thread) _runCurrent() {
 	}
 }
 
+// _waitJoinPoint waits until the join point is released following
+// the strategy of the join_threads action
+func (t *Thread) _waitJoinPoint(actx base.IActionContext, jpoint *contextJoinerPoint) (*base.ActionOutput, error) {
+	action := actx.GetAction()
+	params := jpoint.params
+	result := &JoinResult{Strategy: params.Strategy}
+	var joinerr error
+	var timeout <-chan time.Time
+	if params.Timeout > 0 {
+		timer := time.NewTimer(time.Duration(params.Timeout) * time.Second)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+L:
+	for {
+		// wake up on any scheduling change instead of polling
+		changed := t.runtime.notify.Changed()
+		arrived := t.runtime.cjoiner.Arrived(actx)
+		switch params.Strategy {
+		case blueprint.JoinStrategyAny:
+			if arrived >= 1 {
+				break L
+			}
+		case blueprint.JoinStrategyNOfM:
+			if arrived >= params.N {
+				break L
+			}
+		}
+		if t.state == base.RuntimeStateEnding || t.state == base.RuntimeStateEnd {
+			return nil, nil
+		}
+		if !t.runtime.hasRunningParents(actx) {
+			if params.Strategy == blueprint.JoinStrategyNOfM && t.runtime.cjoiner.Arrived(actx) < params.N {
+				joinerr = fmt.Errorf("join quorum not reached: %d of %d branches arrived", t.runtime.cjoiner.Arrived(actx), params.N)
+			}
+			break
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+			result.TimedOut = true
+			joinerr = fmt.Errorf("join timeout: branches still running after %d seconds", params.Timeout)
+			break L
+		}
+	}
+
+	arrived, merr := t.runtime.cjoiner.Fire(actx)
+	result.Arrived = arrived
+	if params.ShouldCancelPending() {
+		result.Cancelled = t.runtime.cancelJoinBranches(t, actx)
+	}
+	// allow re-join on loops whatever the strategy,
+	// late branches will be discarded on arrival
+	t.runtime.cjoiner.Reset(actx)
+	if result.Cancelled > 0 {
+		cast.LogInfo(fmt.Sprintf("Join point %s cancelled %d pending branches", action.ActionID, result.Cancelled), t.runtime.irb.ExecutionUUID)
+	}
+
+	joinerr = errors.Join(joinerr, merr)
+	aout := base.NewActionOutput(action, result, nil)
+	if joinerr != nil {
+		aout.Records[0].Fail = true
+		aout.Records[0].Error = joinerr
+	}
+	if err := actx.GetStore().Insert(aout.Records[0], action.Provider); err != nil {
+		log.Panic(err.Error())
+	}
+	return aout, joinerr
+}
+
 // closes the thread, his llops and his event listeners
 func (t *Thread) close() {
 	t.ThreadStep = ThreadClose
@@ -826,7 +1096,6 @@ func (t *Thread) GetLastRun() base.IActionContext {
 
 // commonly called by go Init()
 func (t *Thread) Init() {
-	var waitcount int
 	defer func() {
 		t.close()
 		// t._pdbg()
@@ -838,22 +1107,9 @@ func (t *Thread) Init() {
 	var more bool
 
 	for {
-		// este control del load me gusta, lo recupero y lo
-		// dejo por aquí
-		if cast.BInfo.GetLoad() > 10.0 {
-			waitcount++
-			// reduce run speed on high bus load
-			var fa time.Duration = time.Duration(cast.BInfo.GetLoad() * 10.0)
-			// for debug:
-			// fmt.Println("Bus load up 10:", cast.BusLoad, "Sleeping", fa)
-			time.Sleep(fa * time.Millisecond)
-			if waitcount > 100 {
-				fmt.Println("High bus load detected. Waiting load reduction...")
-				waitcount = 0
-			}
-			continue
-		}
-		waitcount = 0
+		// reduce run speed on high bus load: block until the bus
+		// consumer drains the buffer instead of sleeping blindly
+		cast.BInfo.WaitLoadBelow(10.0)
 
 	preload:
 		// load action after all
@@ -951,6 +1207,10 @@ type Runtime struct {
 	irb                *blueprint.IRBlueprint
 	actionContextStack []base.IActionContext
 	activeActionsID    *activeActionsID
+	// per action run summary
+	actionStates *actionStates
+	// wakes up goroutines waiting for scheduling changes
+	notify *changeNotifier
 	// join points
 	cjoiner  *contextJoiner
 	exitCode int
@@ -959,10 +1219,41 @@ type Runtime struct {
 	savedActionOutputs []*base.ActionOutput
 }
 
+// cancelJoinBranches stops the threads that are still running
+// towards the join point of actx. Returns the count of stopped threads
+func (r *Runtime) cancelJoinBranches(waiter *Thread, actx base.IActionContext) int {
+	parentIDs := actx.GetAction().KnowParentIDs
+	var threads []*Thread
+	r.mu.Lock()
+	for th := range r.activeThreads {
+		if th == waiter {
+			continue
+		}
+		current := th.GetCurrent()
+		if current == nil {
+			continue
+		}
+		if _, exists := parentIDs[current.GetAction().ActionID]; !exists {
+			continue
+		}
+		threads = append(threads, th)
+	}
+	r.mu.Unlock()
+
+	cerr := fmt.Errorf("cancelled by join point %s", actx.GetAction().ActionID)
+	for _, th := range threads {
+		th.Stop()
+		if current := th.GetCurrent(); current != nil {
+			current.Cancel(cerr)
+		}
+	}
+	return len(threads)
+}
+
 func (r *Runtime) hasRunningParents(actx base.IActionContext) bool {
 	r.cjoiner.Lock()
 	defer r.cjoiner.Unlock()
-	return r.activeActionsID.ExistsAny(actx.GetAction().KnowParentIDs)
+	return r.activeActionsID.ExistsAnyOrForking(actx.GetAction().KnowParentIDs)
 }
 
 func (r *Runtime) GetStack() []base.IActionContext {
@@ -983,6 +1274,16 @@ func (r *Runtime) SavedActionOutputs() []*base.ActionOutput {
 	return r.savedActionOutputs
 }
 
+// State func
+func (r *Runtime) State() base.RuntimeState {
+	return r.state
+}
+
+// ActionStates func. Summary of the actions run so far
+func (r *Runtime) ActionStates() []*ActionState {
+	return r.actionStates.slice()
+}
+
 func (r *Runtime) ExitCode() int {
 	return r.exitCode
 }
@@ -1043,6 +1344,7 @@ func (r *Runtime) finishThread(th *Thread) {
 
 	// no threads, no activity
 	if len(r.activeThreads) <= 0 {
+		r.cjoiner.Clear()
 		go r.evDispatcher.Dispatch(&runtimeEvent{ecode: base.RuntimeEndEvent})
 	}
 
@@ -1084,6 +1386,7 @@ func (r *Runtime) Stop() {
 	for th := range threads {
 		th.Stop()
 	}
+	r.cjoiner.Clear()
 	r.state = base.RuntimeStateEnd
 	cast.PushEvent(cast.EventRuntimeOut, r.irb.ExecutionUUID)
 	r.DispatchCurrentActiveIdsEvent()
@@ -1144,10 +1447,38 @@ func (r *Runtime) setRunFunc(actx base.IActionContext) {
 			}
 		}
 
+		// use the templates precompiled for this action
+		store.SetTemplateCache(action.Templates)
+
 		actx.WithCancelCause()
 		defer actx.Cancel(nil)
+
+		r.actionStates.start(action)
+		threadID := ""
+		if _, _, tid := cast.LoggerIDs(store.GetLogger()); tid != nil {
+			threadID = *tid
+		}
+		alog, err := cast.ActionLogs.Begin(*r.irb.ExecutionUUID, threadID, action.ActionID, action.ActionName, action.Provider)
+		if err != nil {
+			cast.LogWarn("Cannot write action log: "+err.Error(), r.irb.ExecutionUUID)
+		}
+
 		aout, aerr := provider.HandleAction(actx)
 
+		r.actionStates.end(action, aerr)
+		if alog != nil {
+			if aout != nil && len(aout.Records) > 0 {
+				if cout, ok := aout.Records[0].RawSource.(base.ICommandOutput); ok {
+					stdout, stderr := cout.CommandOutput()
+					cast.ActionLogs.Section(alog, "stdout", stdout)
+					cast.ActionLogs.Section(alog, "stderr", stderr)
+				}
+			}
+			if err := cast.ActionLogs.End(alog, aerr); err != nil {
+				cast.LogWarn("Cannot write action log: "+err.Error(), r.irb.ExecutionUUID)
+			}
+		}
+
 		if aerr != nil {
 			// ssh run could return non nil aout with
 			// result.exitcode > 0 and also aerr non
@@ -1161,7 +1492,10 @@ func (r *Runtime) setRunFunc(actx base.IActionContext) {
 
 		// aout is nil on action return nil, nil
 		if aout != nil {
+			// outputs always belong to the original action
+			aout.Action = action
 			for idx := 0; idx < len(aout.Records); idx++ {
+				aout.Records[idx].Action = action
 				err := store.Insert(aout.Records[idx], action.Provider)
 				if err != nil {
 					log.Panic(err.Error())
@@ -0,0 +1,195 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package runtime_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
+	"github.com/develatio/nebulant-cli/cast"
+	"github.com/develatio/nebulant-cli/providers/generic"
+	"github.com/develatio/nebulant-cli/runtime"
+	"github.com/develatio/nebulant-cli/storage"
+)
+
+var initOnce sync.Once
+
+func initBus() {
+	initOnce.Do(func() {
+		cast.InitSystemBus()
+		cast.SBus.RegisterProviderInitFunc("generic", generic.New)
+	})
+}
+
+type benchAction struct {
+	Provider    string              `json:"provider"`
+	ActionID    string              `json:"action_id"`
+	ActionName  string              `json:"action"`
+	FirstAction bool                `json:"first_action,omitempty"`
+	Parameters  map[string]any      `json:"parameters"`
+	NextAction  map[string][]string `json:"next_action"`
+}
+
+// chainBlueprint builds start -> n actions -> end, one after another
+func chainBlueprint(actionName string, params map[string]any, n int) []*benchAction {
+	actions := []*benchAction{{
+		Provider:    "generic",
+		ActionID:    "start",
+		ActionName:  "start",
+		FirstAction: true,
+		Parameters:  map[string]any{},
+		NextAction:  map[string][]string{"ok": {"a0"}},
+	}}
+	for i := 0; i < n; i++ {
+		next := map[string][]string{}
+		if i < n-1 {
+			next["ok"] = []string{fmt.Sprintf("a%d", i+1)}
+		}
+		actions = append(actions, &benchAction{
+			Provider:   "generic",
+			ActionID:   fmt.Sprintf("a%d", i),
+			ActionName: actionName,
+			Parameters: params,
+			NextAction: next,
+		})
+	}
+	return actions
+}
+
+// fanOutBlueprint builds start -> n noop branches -> join_threads -> noop
+func fanOutBlueprint(n int) []*benchAction {
+	start := &benchAction{
+		Provider:    "generic",
+		ActionID:    "start",
+		ActionName:  "start",
+		FirstAction: true,
+		Parameters:  map[string]any{},
+		NextAction:  map[string][]string{"ok": {}},
+	}
+	actions := []*benchAction{start}
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("b%d", i)
+		start.NextAction["ok"] = append(start.NextAction["ok"], id)
+		actions = append(actions, &benchAction{
+			Provider:   "generic",
+			ActionID:   id,
+			ActionName: "noop",
+			Parameters: map[string]any{},
+			NextAction: map[string][]string{"ok": {"join"}},
+		})
+	}
+	actions = append(actions, &benchAction{
+		Provider:   "generic",
+		ActionID:   "join",
+		ActionName: "join_threads",
+		Parameters: map[string]any{},
+		NextAction: map[string][]string{"ok": {"end"}},
+	}, &benchAction{
+		Provider:   "generic",
+		ActionID:   "end",
+		ActionName: "noop",
+		Parameters: map[string]any{},
+		NextAction: map[string][]string{},
+	})
+	return actions
+}
+
+func newIRB(tb testing.TB, actions []*benchAction) *blueprint.IRBlueprint {
+	data, err := json.Marshal(map[string]any{"actions": actions})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	bp, err := blueprint.NewFromBytes(data)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	uuid := "bench"
+	bp.ExecutionUUID = &uuid
+	irb, err := blueprint.GenerateIRB(bp, &blueprint.IRBGenConfig{})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return irb
+}
+
+// run executes the irb until the runtime ends, the same way
+// the executive manager does
+func run(tb testing.TB, irb *blueprint.IRBlueprint) {
+	rt := runtime.NewRuntime(irb, false)
+	st := storage.NewStore()
+	st.SetLogger(&cast.Logger{ExecutionUUID: irb.ExecutionUUID})
+	actx := rt.NewAContext(nil, irb.StartAction)
+	actx.SetStore(st)
+	el := rt.NewEventListener()
+	// the runtime could end before the wait starts
+	el.Arm()
+	rt.NewThread(actx)
+	el.WaitUntil([]base.EventCode{base.RuntimeEndEvent})
+	if rt.ExitCode() != 0 {
+		tb.Fatalf("runtime finished with exit code %d: %v", rt.ExitCode(), rt.Error())
+	}
+}
+
+func benchmarkBlueprint(b *testing.B, actions []*benchAction) {
+	initBus()
+	irb := newIRB(b, actions)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		run(b, irb)
+	}
+}
+
+func TestRunTrivialBlueprints(t *testing.T) {
+	initBus()
+	run(t, newIRB(t, chainBlueprint("noop", map[string]any{}, 10)))
+	run(t, newIRB(t, chainBlueprint("log", map[string]any{"content": "hi"}, 10)))
+	run(t, newIRB(t, fanOutBlueprint(4)))
+}
+
+func BenchmarkNoop1(b *testing.B) {
+	benchmarkBlueprint(b, chainBlueprint("noop", map[string]any{}, 1))
+}
+
+func BenchmarkNoop10(b *testing.B) {
+	benchmarkBlueprint(b, chainBlueprint("noop", map[string]any{}, 10))
+}
+
+func BenchmarkNoop100(b *testing.B) {
+	benchmarkBlueprint(b, chainBlueprint("noop", map[string]any{}, 100))
+}
+
+func BenchmarkLog10(b *testing.B) {
+	benchmarkBlueprint(b, chainBlueprint("log", map[string]any{"content": "benchmark"}, 10))
+}
+
+func BenchmarkLog100(b *testing.B) {
+	benchmarkBlueprint(b, chainBlueprint("log", map[string]any{"content": "benchmark"}, 100))
+}
+
+func BenchmarkFanOutJoin10(b *testing.B) {
+	benchmarkBlueprint(b, fanOutBlueprint(10))
+}
@@ -0,0 +1,42 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import "github.com/develatio/nebulant-cli/base"
+
+// EagerDuplicate copies every record into the new store, as Duplicate
+// did before the copy-on-write layers. Used to compare in benchmarks.
+func (s *Store) EagerDuplicate() base.IStore {
+	store := NewStore()
+	store.layer = s.layer.flatten()
+	store.logger = s.logger
+	for _, v := range s.providers {
+		v.DumpPrivateVars(store)
+	}
+	return store
+}
+
+// LayerDepth returns the depth of the layer chain of the store
+func (s *Store) LayerDepth() int {
+	return s.layer.depth
+}
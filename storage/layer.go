@@ -0,0 +1,228 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"sync/atomic"
+
+	"github.com/develatio/nebulant-cli/base"
+)
+
+// layerSeq provides the ids of the layers
+var layerSeq atomic.Uint64
+
+// kinds of values, used to prefix the keys of storeLayer.origin
+const (
+	originRefName  = "r:"
+	originActionID = "a:"
+	originValueID  = "v:"
+	originAout     = "o:"
+	originPrivate  = "p:"
+)
+
+// maxLayerDepth is the max count of layers a store chain can reach
+// before being flattened. Lookups walk the chain, so this bounds the
+// lookup cost on blueprints that fork inside loops.
+const maxLayerDepth = 32
+
+// storeLayer holds the values written into a store since his last
+// Duplicate call. On Duplicate the current layer of the store gets
+// frozen and shared as parent by the store and his copy, so no record
+// is copied. Frozen layers are never written again, only the top
+// layer of each store is writable.
+type storeLayer struct {
+	parent *storeLayer
+	depth  int
+	// unique id of the layer
+	id uint64
+	// only on compacted layers: ids of the layers squashed into
+	// this one and the id of the layer each value was written in,
+	// so the ancestry of the squashed chain is not lost
+	absorbed map[uint64]bool
+	origin   map[string]uint64
+	// by reference like {{ VARNAME }}
+	recordsByRefName map[string]*base.StorageRecord
+	// by action id like Action: { ActionID: "d8s8a9...." }
+	recordsByActionID map[string]*base.StorageRecord
+	// by provider id like "providerprefix -" + ec2.Image.ImageId
+	recordsByValueID map[string]*base.StorageRecord
+	// action outputs using ActionID as key
+	aoutByActionID map[string]*base.ActionOutput
+	// private vars used by providers
+	private map[string]interface{}
+}
+
+func newStoreLayer(parent *storeLayer) *storeLayer {
+	layer := &storeLayer{
+		parent:            parent,
+		id:                layerSeq.Add(1),
+		recordsByRefName:  make(map[string]*base.StorageRecord),
+		recordsByActionID: make(map[string]*base.StorageRecord),
+		recordsByValueID:  make(map[string]*base.StorageRecord),
+		aoutByActionID:    make(map[string]*base.ActionOutput),
+		private:           make(map[string]interface{}),
+	}
+	if parent != nil {
+		layer.depth = parent.depth + 1
+	}
+	return layer
+}
+
+// empty returns true if nothing has been written into the layer
+func (l *storeLayer) empty() bool {
+	return len(l.recordsByRefName) == 0 &&
+		len(l.recordsByActionID) == 0 &&
+		len(l.recordsByValueID) == 0 &&
+		len(l.aoutByActionID) == 0 &&
+		len(l.private) == 0
+}
+
+// originOf returns the id of the layer where the value of key was
+// written. key should be prefixed with his kind (originRefName...)
+func (l *storeLayer) originOf(key string) uint64 {
+	if l.origin != nil {
+		return l.origin[key]
+	}
+	return l.id
+}
+
+// apply writes the values of src into l, overriding existing ones.
+// Values whose origin layer satisfies skip are ignored. If l tracks
+// origins (compacted layer), the origin of every value is kept.
+func (l *storeLayer) apply(src *storeLayer, skip func(uint64) bool) {
+	use := func(kind string, k string) bool {
+		origin := src.originOf(kind + k)
+		if skip != nil && skip(origin) {
+			return false
+		}
+		if l.origin != nil {
+			l.origin[kind+k] = origin
+		}
+		return true
+	}
+	for k, v := range src.recordsByRefName {
+		if use(originRefName, k) {
+			l.recordsByRefName[k] = v
+		}
+	}
+	for k, v := range src.recordsByActionID {
+		if use(originActionID, k) {
+			l.recordsByActionID[k] = v
+		}
+	}
+	for k, v := range src.recordsByValueID {
+		if use(originValueID, k) {
+			l.recordsByValueID[k] = v
+		}
+	}
+	for k, v := range src.aoutByActionID {
+		if use(originAout, k) {
+			l.aoutByActionID[k] = v
+		}
+	}
+	for k, v := range src.private {
+		if use(originPrivate, k) {
+			l.private[k] = v
+		}
+	}
+}
+
+// chain returns the layers from l to the oldest one
+func (l *storeLayer) chain() []*storeLayer {
+	var chain []*storeLayer
+	for c := l; c != nil; c = c.parent {
+		chain = append(chain, c)
+	}
+	return chain
+}
+
+// flatten returns a new parentless layer with the values visible from l
+func (l *storeLayer) flatten() *storeLayer {
+	chain := l.chain()
+	flat := newStoreLayer(nil)
+	// apply from the oldest to the newest layer
+	for i := len(chain) - 1; i >= 0; i-- {
+		flat.apply(chain[i], nil)
+	}
+	return flat
+}
+
+// compact is like flatten, but the returned layer keeps the ids of
+// the squashed layers and the origin of every value, so stores that
+// share some of the squashed layers can still be merged as related
+func (l *storeLayer) compact() *storeLayer {
+	chain := l.chain()
+	flat := newStoreLayer(nil)
+	flat.absorbed = make(map[uint64]bool)
+	flat.origin = make(map[string]uint64)
+	for i := len(chain) - 1; i >= 0; i-- {
+		flat.absorbed[chain[i].id] = true
+		for id := range chain[i].absorbed {
+			flat.absorbed[id] = true
+		}
+		flat.apply(chain[i], nil)
+	}
+	return flat
+}
+
+// knows returns true if the layer with the given id is part of the
+// chain of l, directly or squashed into a compacted layer
+func (l *storeLayer) knows(id uint64) bool {
+	for c := l; c != nil; c = c.parent {
+		if c.id == id || c.absorbed[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// overlay returns a new parentless layer with the values of l written
+// after l and base diverged, this is, the values written into layers
+// not known by base. If both chains are unrelated the whole chain of
+// l is returned.
+func (l *storeLayer) overlay(base *storeLayer) *storeLayer {
+	var chain []*storeLayer
+	for c := l; c != nil && !base.knows(c.id); c = c.parent {
+		chain = append(chain, c)
+	}
+	flat := newStoreLayer(nil)
+	for i := len(chain) - 1; i >= 0; i-- {
+		flat.apply(chain[i], base.knows)
+	}
+	return flat
+}
+
+// allRecordsByRefName returns the records by reference name visible from l
+func (l *storeLayer) allRecordsByRefName() map[string]*base.StorageRecord {
+	var chain []*storeLayer
+	for c := l; c != nil; c = c.parent {
+		chain = append(chain, c)
+	}
+	records := make(map[string]*base.StorageRecord)
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range chain[i].recordsByRefName {
+			records[k] = v
+		}
+	}
+	return records
+}
@@ -30,70 +30,88 @@ import (
 	"math/rand"
 	"os"
 	"reflect"
-	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"unicode"
 
 	"github.com/bhmj/jsonslice"
 	"github.com/develatio/nebulant-cli/base"
+	"github.com/develatio/nebulant-cli/blueprint"
 	"github.com/develatio/nebulant-cli/config"
 )
 
 // Store struct
 type Store struct {
 	// Records into this store. All records are stored in three ways
-	// by reference name, action id and provider id:
-	//
-	// by reference like {{ VARNAME }}
-	recordsByRefName map[string]*base.StorageRecord
-	// by action id like Action: { ActionID: "d8s8a9...." }
-	recordsByActionID map[string]*base.StorageRecord
-	// by provider id like "providerprefix -" + ec2.Image.ImageId
-	recordsByValueID map[string]*base.StorageRecord
-	//
-	//
-	// attrTree the string key match with string key of records
-	// and it's a representation of "key.path":"value" of struct stored into
-	// base.ActionOutput.Raw|Underlying
-	// attrTree map[string]map[string]*base.AttrTreeValue
-	// records same as records but using ActionID as key
-	aoutByActionID map[string]*base.ActionOutput
+	// by reference name, action id and provider id. The records live
+	// in a chain of copy-on-write layers, see storeLayer. This is the
+	// writable top layer.
+	layer *storeLayer
 	// initialized providers
 	providers map[string]base.IProvider
 	// logger instance
 	logger base.ILogger
-	// private store to be used privately by providers and not exposed to user
-	private map[string]interface{}
+	// compiled templates of the running action
+	templates atomic.Pointer[blueprint.TemplateCache]
 }
 
 // NewStore func
 func NewStore() *Store {
 	store := Store{}
-	store.recordsByRefName = make(map[string]*base.StorageRecord)
-	store.recordsByActionID = make(map[string]*base.StorageRecord)
-	store.recordsByValueID = make(map[string]*base.StorageRecord)
-
-	store.aoutByActionID = make(map[string]*base.ActionOutput)
+	store.layer = newStoreLayer(nil)
 	store.providers = make(map[string]base.IProvider)
-	store.private = make(map[string]interface{})
 	return &store
 }
 
 // Merge func
 func (s *Store) Merge(source base.IStore) {
+	// last writer wins never fails
+	_ = s.MergeWithPolicy(source, blueprint.JoinMergeLastWriterWins)
+}
+
+// MergeWithPolicy func. Merge source store into s. If both stores come
+// from a common Duplicate call, only the values written by source after
+// that call are merged. The policy determines what to do when both stores
+// has different values for the same reference name.
+func (s *Store) MergeWithPolicy(source base.IStore, policy blueprint.JoinMergePolicy) error {
 	ss := source.(*Store)
+	if ss == s {
+		return nil
+	}
+	overlay := ss.layer.overlay(s.layer)
 
-	for k, v := range ss.recordsByRefName {
-		s.logger.LogDebug(fmt.Sprintf("mergin refname %s", v.RefName))
-		s.recordsByRefName[k] = v
+	if policy == blueprint.JoinMergeFailOnConflict {
+		var errs []error
+		for k, v := range overlay.recordsByRefName {
+			if cv, exists := s.lookupRefName(k); exists && recordsConflict(cv, v) {
+				errs = append(errs, fmt.Errorf("merge conflict: reference %s has different values", k))
+			}
+		}
+		if len(errs) > 0 {
+			return errors.Join(errs...)
+		}
+	}
+
+	for k, v := range overlay.recordsByRefName {
+		if s.logger != nil {
+			s.logger.LogDebug(fmt.Sprintf("mergin refname %s", v.RefName))
+		}
+		cv, exists := s.lookupRefName(k)
+		if policy == blueprint.JoinMergeCollect && exists && recordsConflict(cv, v) {
+			if err := s.collect(cv, v); err != nil {
+				return err
+			}
+			continue
+		}
+		s.layer.recordsByRefName[k] = v
 	}
-	for k, v := range ss.recordsByActionID {
-		s.recordsByActionID[k] = v
+	for k, v := range overlay.recordsByActionID {
+		s.layer.recordsByActionID[k] = v
 	}
-	for k, v := range ss.aoutByActionID {
-		s.aoutByActionID[k] = v
+	for k, v := range overlay.aoutByActionID {
+		s.layer.aoutByActionID[k] = v
 	}
 	// NOTE: on Store merge, skip copy providers, because there
 	// is an istance of store inside the provider, so the relation
@@ -101,46 +119,72 @@ func (s *Store) Merge(source base.IStore) {
 	// for k, v := range ss.providers {
 	// 	s.providers[k] = v
 	// }
-	for k, v := range ss.private {
-		s.private[k] = v
+	for k, v := range overlay.private {
+		s.layer.private[k] = v
 	}
+	return nil
 }
 
-// Duplicate func.
-// Make a copy of current store to be used in newly created children threads.
-func (s *Store) Duplicate() base.IStore {
-	var recordsByRefName = make(map[string]*base.StorageRecord)
-	var recordsByActionID = make(map[string]*base.StorageRecord)
-	var recordsByValueID = make(map[string]*base.StorageRecord)
-	var aoutByActionID = make(map[string]*base.ActionOutput)
-	var private = make(map[string]interface{})
-
-	for k, v := range s.recordsByRefName {
-		vv := *v
-		recordsByRefName[k] = &vv
+// recordsConflict returns true if a and b holds different values
+func recordsConflict(a *base.StorageRecord, b *base.StorageRecord) bool {
+	if a == b {
+		return false
+	}
+	if a.ValueID != b.ValueID || a.Fail != b.Fail {
+		return true
+	}
+	if a.IsString != b.IsString {
+		return true
 	}
-	for k, v := range s.recordsByActionID {
-		vv := v
-		recordsByActionID[k] = vv
+	if a.JSONValue == nil && b.JSONValue == nil {
+		return !reflect.DeepEqual(a.Value, b.Value)
 	}
-	for k, v := range s.recordsByValueID {
-		vv := v
-		recordsByValueID[k] = vv
+	return !bytes.Equal(a.JSONValue, b.JSONValue)
+}
+
+// collect stores the value of incoming record into the current
+// record using a stack var, like Push does.
+func (s *Store) collect(current *base.StorageRecord, incoming *base.StorageRecord) error {
+	var items []interface{}
+	items = append(items, incoming.Value)
+	if stack, ok := current.Value.(*base.StorageRecordStack); ok {
+		items = append(items, stack.Items...)
+	} else {
+		items = append(items, current.Value)
 	}
-	for k, v := range s.aoutByActionID {
-		vv := *v
-		aoutByActionID[k] = &vv
+	record := &base.StorageRecord{
+		RefName: current.RefName,
+		Aout:    incoming.Aout,
+		Value:   &base.StorageRecordStack{Items: items},
+		Action:  incoming.Action,
 	}
-	for pvn, pv := range s.private {
-		private[pvn] = pv
+	s.layer.recordsByRefName[record.RefName] = record
+	return record.BuildInternals()
+}
+
+// Duplicate func.
+// Make a copy of current store to be used in newly created children threads.
+// No record is copied: the current top layer is frozen and shared by both
+// stores, each one writing from now on into his own new top layer.
+func (s *Store) Duplicate() base.IStore {
+	if s.layer.depth >= maxLayerDepth {
+		// too deep, squash the chain. The compacted layer keeps
+		// the ids of the squashed ones, so stores duplicated before
+		// this point are still merged as related stores.
+		s.layer = s.layer.compact()
+	}
+	shared := s.layer
+	if shared.empty() && shared.parent != nil {
+		// nothing written since the last Duplicate call,
+		// share the already frozen parent layer
+		shared = shared.parent
+	} else {
+		s.layer = newStoreLayer(shared)
 	}
 
 	store := NewStore()
-	store.recordsByRefName = recordsByRefName
-	store.recordsByActionID = recordsByActionID
-	store.recordsByValueID = recordsByValueID
-	store.aoutByActionID = aoutByActionID
-	store.private = private
+	store.layer = newStoreLayer(shared)
+	store.templates.Store(s.templates.Load())
 	//
 	vr := reflect.ValueOf(s.logger)
 	if vr.Kind() == reflect.Ptr {
@@ -190,28 +234,38 @@ func (s *Store) SetLogger(logger base.ILogger) {
 
 // GetPrivateVar func
 func (s *Store) GetPrivateVar(varname string) interface{} {
-	if value, exists := s.private[varname]; exists {
-		return value
+	for l := s.layer; l != nil; l = l.parent {
+		if value, exists := l.private[varname]; exists {
+			return value
+		}
 	}
 	return nil
 }
 
 // SetPrivateVar func
 func (s *Store) SetPrivateVar(varname string, value interface{}) {
-	s.private[varname] = value
+	s.layer.private[varname] = value
 }
 
 // GetProvider func
 func (s *Store) ExistsRefName(refname string) bool {
-	if _, exists := s.recordsByRefName[refname]; exists {
-		return true
+	_, exists := s.lookupRefName(refname)
+	return exists
+}
+
+// lookupRefName walks the layers from the newest to the oldest
+func (s *Store) lookupRefName(refname string) (*base.StorageRecord, bool) {
+	for l := s.layer; l != nil; l = l.parent {
+		if record, exists := l.recordsByRefName[refname]; exists {
+			return record, true
+		}
 	}
-	return false
+	return nil, false
 }
 
 // GetProvider func
 func (s *Store) GetByRefName(refname string) (*base.StorageRecord, error) {
-	if record, exists := s.recordsByRefName[refname]; exists {
+	if record, exists := s.lookupRefName(refname); exists {
 		return record, nil
 	}
 	return nil, fmt.Errorf("unkown reference")
@@ -219,16 +273,20 @@ func (s *Store) GetByRefName(refname string) (*base.StorageRecord, error) {
 
 // GetProvider func
 func (s *Store) GetByValueID(valueID string, providerPrefix string) (*base.StorageRecord, error) {
-	if record, exists := s.recordsByValueID[providerPrefix+valueID]; exists {
-		return record, nil
+	for l := s.layer; l != nil; l = l.parent {
+		if record, exists := l.recordsByValueID[providerPrefix+valueID]; exists {
+			return record, nil
+		}
 	}
 	return nil, fmt.Errorf("unkown reference")
 }
 
 // GetActionOutputByActionID func
 func (s *Store) GetActionOutputByActionID(actionID *string) (*base.ActionOutput, error) {
-	if aout, exists := s.aoutByActionID[*actionID]; exists {
-		return aout, nil
+	for l := s.layer; l != nil; l = l.parent {
+		if aout, exists := l.aoutByActionID[*actionID]; exists {
+			return aout, nil
+		}
 	}
 	return nil, fmt.Errorf("output action by id does not exists")
 }
@@ -268,6 +326,7 @@ func (s *Store) Push(sr *base.StorageRecord, providerPrefix string) error {
 		Aout:    sr.Aout,
 		Value:   recordstack,
 		Action:  sr.Action,
+		Secret:  sr.Secret,
 	}, providerPrefix)
 	if err != nil {
 		return err
@@ -278,199 +337,255 @@ func (s *Store) Push(sr *base.StorageRecord, providerPrefix string) error {
 // Insert func
 func (s *Store) Insert(record *base.StorageRecord, providerPrefix string) error {
 	if record.Action != nil {
-		s.recordsByActionID[record.Action.ActionID] = record
+		s.layer.recordsByActionID[record.Action.ActionID] = record
 		if record.Aout != nil {
-			s.aoutByActionID[record.Action.ActionID] = record.Aout
+			s.layer.aoutByActionID[record.Action.ActionID] = record.Aout
 		}
 	}
 	if len(record.ValueID) > 0 {
-		s.recordsByValueID[providerPrefix+record.ValueID] = record
+		s.layer.recordsByValueID[providerPrefix+record.ValueID] = record
 	}
 	if len(record.RefName) > 0 {
-		s.recordsByRefName[record.RefName] = record
+		s.layer.recordsByRefName[record.RefName] = record
 	}
 	return record.BuildInternals()
 }
 
+// SetTemplateCache func. Sets the compiled templates cache of the action
+// that is going to use the store. Interpolate compiles the text on every
+// call if no cache is set.
+func (s *Store) SetTemplateCache(cache *blueprint.TemplateCache) {
+	s.templates.Store(cache)
+}
+
 // can be called ReferenceInterpolation? maybe InterpolateReferences?
 func (s *Store) Interpolate(sourcetext *string) error {
-	if sourcetext == nil {
+	if sourcetext == nil || !strings.Contains(*sourcetext, "{{") {
 		return nil
 	}
-	// var finaltext string = sourcetext
-	r := regexp.MustCompile(`{{([^{}]*)}}`)
-	matches := r.FindAllStringSubmatch(*sourcetext, -1)
+	tpl, err := s.templates.Load().Get(*sourcetext)
+	if err != nil {
+		return err
+	}
 	// A string like "12345" instead "{{REFERENCE.id}}" can be used with this
 	// function so an string without refereces is still valid
-	if len(matches) <= 0 {
+	if !tpl.HasPlaceholders() {
 		return nil
 	}
-
-	for _, match := range matches {
-		// match[0] == "{{ a.b.c }}""
-		// match[1] == " a.b.c "
-		var refpath string = strings.TrimSpace(match[1])
-		var refname = ""
-
-		// Catch AWS_EC2 from AWS_EC2.foo.bar or AWS_EC2[0]
-		_m := regexp.MustCompile(`(?:\\.|[^.[|\\]+)+`).FindAllStringSubmatch(refpath, -1)
-		if len(_m) <= 0 {
-			return fmt.Errorf("cannot determine reference")
+	var sb strings.Builder
+	for _, node := range tpl.Nodes {
+		val, err := s.evalTemplateNode(node)
+		if err != nil {
+			return err
 		}
+		sb.WriteString(val)
+	}
+	*sourcetext = sb.String()
+	return nil
+}
 
-		// obtain record in db referenced by refname
-		// _m ->[[AWS_EC2] [networkInterfaceSet] [0]] ...]
-		refname = _m[0][0]
-		if strings.ToLower(refname) == "env" {
-			refpath = strings.TrimPrefix(refpath, refname)
-			refpath = strings.TrimPrefix(refpath, ".")
-			if len(refpath) <= 0 {
-				return fmt.Errorf("environment var access with empty var name " + refname)
-			}
-			if strings.ToLower(strings.TrimSpace(refpath)) == "random" {
-				*sourcetext = strings.Replace(*sourcetext, match[0], fmt.Sprintf("%d", rand.Int()), 1)
-				continue
-			}
-			varval, exists := os.LookupEnv(strings.TrimSpace(refpath))
-			if !exists {
-				return fmt.Errorf("'" + refpath + "' environment var not found")
-			}
-			if varval == "" {
-				s.logger.LogWarn("Interpolation results in an empty string replacement for " + match[0])
-			}
-			*sourcetext = strings.Replace(*sourcetext, match[0], varval, 1)
-			continue
-		}
+func (s *Store) warn(msg string) {
+	if s.logger != nil {
+		s.logger.LogWarn(msg)
+	}
+}
 
-		if strings.ToLower(refname) == "runtime" {
-			refpath = strings.TrimPrefix(refpath, refname)
-			refpath = strings.TrimPrefix(refpath, ".")
-			if len(refpath) <= 0 {
-				return fmt.Errorf("runtime var access with empty var name")
-			}
-			switch strings.ToLower(refpath) {
-			case "os":
-				*sourcetext = strings.Replace(*sourcetext, match[0], runtime.GOOS, 1)
-			case "arch":
-				*sourcetext = strings.Replace(*sourcetext, match[0], runtime.GOARCH, 1)
-			case "numcpu":
-				*sourcetext = strings.Replace(*sourcetext, match[0], strconv.Itoa(runtime.NumCPU()), 1)
-			case "version":
-				*sourcetext = strings.Replace(*sourcetext, match[0], config.Version, 1)
-			case "versiondate":
-				*sourcetext = strings.Replace(*sourcetext, match[0], config.VersionDate, 1)
-			default:
-				return fmt.Errorf("Unknown runtime var name " + refpath)
-			}
-			continue
+func (s *Store) evalTemplateNode(node *blueprint.TemplateNode) (string, error) {
+	switch node.Type {
+	case blueprint.TemplateNodeLiteral:
+		return node.Source, nil
+	case blueprint.TemplateNodeEnv:
+		if strings.ToLower(node.Name) == "random" {
+			return fmt.Sprintf("%d", rand.Int()), nil // #nosec G404 -- Weak random is OK here
 		}
-
-		record, exists := s.recordsByRefName[refname]
+		varval, exists := os.LookupEnv(node.Name)
 		if !exists {
-			return fmt.Errorf("var reference %s does not exists (ES1) (store:%p)", refname, s)
-		}
-
-		// refpath -> .foo.bar or [foo.bar] or empty if no path provided
-		refpath = strings.TrimPrefix(refpath, refname)
-		if len(refpath) <= 0 {
-			if len(record.ValueID) > 0 {
-				*sourcetext = strings.Replace(*sourcetext, match[0], record.ValueID, 1)
-			} else if !record.Literal {
-				return fmt.Errorf("cannot use %s as literal value", match[0])
-			} else if reflect.ValueOf(record.Value).Kind() == reflect.String {
-				if record.Value.(string) == "" {
-					s.logger.LogWarn("Interpolation results in an empty string replacement for " + match[0])
-				}
-				*sourcetext = strings.Replace(*sourcetext, match[0], record.Value.(string), 1)
-			} else {
-				// return json by default
-				if string(record.JSONValue) == "" {
-					s.logger.LogWarn("Interpolation results in an empty string replacement for " + match[0])
-				}
-				*sourcetext = strings.Replace(*sourcetext, match[0], string(record.JSONValue), 1)
-			}
-			continue
+			return "", fmt.Errorf("'" + node.Name + "' environment var not found")
 		}
-		// add root char ($) to initial refpath,
-		// this replaces AWS_EC2.foo.bar by $.foo.bar
-		refpath = "$" + refpath
-
-		r := regexp.MustCompile(`(?:\\.|"(.*?)"|[^|\\]+)+`)
-		jpaths := r.FindAllStringSubmatch(refpath, -1)
-		if len(jpaths) <= 0 {
-			continue
+		if varval == "" {
+			s.warn("Interpolation results in an empty string replacement for " + node.Source)
 		}
+		return varval, nil
+	case blueprint.TemplateNodeRuntime:
+		switch node.Name {
+		case "os":
+			return runtime.GOOS, nil
+		case "arch":
+			return runtime.GOARCH, nil
+		case "numcpu":
+			return strconv.Itoa(runtime.NumCPU()), nil
+		case "version":
+			return config.Version, nil
+		case "versiondate":
+			return config.VersionDate, nil
+		}
+		return "", fmt.Errorf("Unknown runtime var name " + node.Name)
+	}
+
+	text, _, err := s.evalReference(node)
+	return text, err
+}
+
+// quoteJSON returns the JSON string representation of text
+func quoteJSON(text []byte) json.RawMessage {
+	enc, _ := json.Marshal(string(text))
+	return enc
+}
+
+// evalReference resolves a reference node, returning his value as text
+// (used into strings) and as JSON keeping his native type (used for
+// whole-field references)
+func (s *Store) evalReference(node *blueprint.TemplateNode) (string, json.RawMessage, error) {
+	record, exists := s.lookupRefName(node.Name)
+	if !exists {
+		return "", nil, fmt.Errorf("var reference %s does not exists (ES1) (store:%p)", node.Name, s)
+	}
 
-		var jpathTargetValue []byte
-		if record.Literal {
-			jpathTargetValue = record.JSONValue
+	recordJSON := json.RawMessage(record.JSONValue)
+	if record.IsString {
+		recordJSON = quoteJSON(record.JSONValue)
+	} else if len(recordJSON) <= 0 {
+		recordJSON = json.RawMessage("null")
+	}
+
+	if len(node.Stages) <= 0 {
+		if len(record.ValueID) > 0 {
+			return record.ValueID, quoteJSON([]byte(record.ValueID)), nil
+		}
+		if !record.Literal {
+			return "", nil, fmt.Errorf("cannot use %s as literal value", node.Source)
+		}
+		if reflect.ValueOf(record.Value).Kind() == reflect.String {
+			if record.Value.(string) == "" {
+				s.warn("Interpolation results in an empty string replacement for " + node.Source)
+			}
+			return record.Value.(string), recordJSON, nil
+		}
+		// return json by default
+		if string(record.JSONValue) == "" {
+			s.warn("Interpolation results in an empty string replacement for " + node.Source)
 		}
-		for _, jpathm := range jpaths {
-			jpath := jpathm[0]
-			if strings.ToLower(jpath) == "$.__haserror" {
+		return string(record.JSONValue), recordJSON, nil
+	}
+
+	// value to be returned
+	var jpathTargetValue []byte
+	var jpathTargetJSON json.RawMessage = json.RawMessage("null")
+	if record.Literal {
+		jpathTargetValue = record.JSONValue
+		jpathTargetJSON = recordJSON
+	}
+	// json value on which the next stage will be applied
+	jpathSourceValue := record.JSONValue
+	for _, stage := range node.Stages {
+		jpath := stage.Path
+		if stage.Type == blueprint.TemplateStageMagic {
+			switch strings.ToLower(jpath) {
+			case "$.__haserror":
 				if record.Fail {
 					jpathTargetValue = []byte("true")
 				} else {
 					jpathTargetValue = []byte("false")
 				}
-			} else if strings.ToLower(jpath) == "$.__error" {
+				jpathTargetJSON = jpathTargetValue
+			case "$.__error":
 				jpathTargetValue = []byte(record.ErrorStr)
-			} else if strings.ToLower(jpath) == "$.__internal" {
+				jpathTargetJSON = quoteJSON(jpathTargetValue)
+			case "$.__internal":
 				jpathTargetValue = []byte(fmt.Sprintf("%v", record.Value))
-			} else if strings.ToLower(jpath) == "$.__plain" {
+				jpathTargetJSON = quoteJSON(jpathTargetValue)
+			case "$.__plain":
 				jpathTargetValue = []byte(fmt.Sprintf("%v", record.PlainValue))
-			} else if strings.ToLower(jpath) == "$.__json" {
+				jpathTargetJSON = quoteJSON(jpathTargetValue)
+			case "$.__json":
 				jpathTargetValue = record.JSONValue
-			} else if strings.ToLower(jpath) == "$.__id" {
+				jpathTargetJSON = recordJSON
+			case "$.__id":
 				if len(record.ValueID) <= 0 {
-					return fmt.Errorf("var reference " + refname + " has no ID (ES2)")
+					return "", nil, fmt.Errorf("var reference " + node.Name + " has no ID (ES2)")
 				}
 				jpathTargetValue = []byte(record.ValueID)
-			} else if strings.HasPrefix(jpath, "$.__plain.") {
+				jpathTargetJSON = quoteJSON(jpathTargetValue)
+			default:
+				// $.__plain.<path>
 				attr, exists := record.PlainValue[jpath[1:]]
 				if !exists {
 					availPaths := fmt.Sprintf("%v", record.PlainValue)
-					return fmt.Errorf("path " + jpath[1:] + " does not exists (ES3). Available paths: " + availPaths)
+					return "", nil, fmt.Errorf("path " + jpath[1:] + " does not exists (ES3). Available paths: " + availPaths)
 				}
 				if attr.IsString {
 					jpathTargetValue = []byte(attr.Value.(string))
 				} else {
 					jpathTargetValue = []byte(fmt.Sprintf("%v", attr.Value))
 				}
-			} else {
-				enc, err := jsonslice.Get(record.JSONValue, strings.TrimSpace(jpath))
-				if err != nil {
-					return fmt.Errorf("Invalid path " + jpath + " " + err.Error())
-				}
-				val := string(enc)
-				if strings.HasPrefix(val, "\"") && strings.HasSuffix(val, "\"") {
-					var str string
-					err = json.Unmarshal(enc, &str)
-					if err != nil {
-						return fmt.Errorf(err.Error() + ": `" + string(enc) + "`")
-					}
-					jpathTargetValue = []byte(str)
-				} else if len(enc) <= 0 {
-					s.logger.LogWarn(fmt.Sprintf("JSON Path result in empty value. Maybe you want to fix it, here is the raw json value: %s", record.JSONValue))
-				} else {
-					var prettyJSON bytes.Buffer
-					err = json.Indent(&prettyJSON, enc, "", "    ")
-					if err != nil {
-						return fmt.Errorf(err.Error() + ": `" + string(enc) + "`")
-					}
-					jpathTargetValue = prettyJSON.Bytes()
-				}
+				jpathTargetJSON = quoteJSON(jpathTargetValue)
 			}
+			jpathSourceValue = jpathTargetValue
+			continue
+		}
+
+		enc, err := jsonslice.Get(jpathSourceValue, jpath)
+		if err != nil {
+			return "", nil, fmt.Errorf("Invalid path " + jpath + " " + err.Error())
 		}
-		if string(jpathTargetValue) == "" {
-			s.logger.LogWarn("Interpolation results in an empty string replacement for " + match[0])
+		jpathSourceValue = enc
+		jpathTargetJSON = json.RawMessage(enc)
+		val := string(enc)
+		if strings.HasPrefix(val, "\"") && strings.HasSuffix(val, "\"") {
+			var str string
+			err = json.Unmarshal(enc, &str)
+			if err != nil {
+				return "", nil, fmt.Errorf(err.Error() + ": `" + string(enc) + "`")
+			}
+			jpathTargetValue = []byte(str)
+		} else if len(enc) <= 0 {
+			s.warn(fmt.Sprintf("JSON Path result in empty value. Maybe you want to fix it, here is the raw json value: %s", record.JSONValue))
+			jpathTargetJSON = json.RawMessage("null")
+		} else {
+			var prettyJSON bytes.Buffer
+			err = json.Indent(&prettyJSON, enc, "", "    ")
+			if err != nil {
+				return "", nil, fmt.Errorf(err.Error() + ": `" + string(enc) + "`")
+			}
+			jpathTargetValue = prettyJSON.Bytes()
 		}
-		*sourcetext = strings.Replace(*sourcetext, match[0], string(jpathTargetValue), 1)
 	}
-	return nil
+	if string(jpathTargetValue) == "" {
+		s.warn("Interpolation results in an empty string replacement for " + node.Source)
+	}
+	return string(jpathTargetValue), jpathTargetJSON, nil
+}
+
+// ResolveJSON func. Returns the value referenced by text, consisting of
+// a single {{ ref }}, keeping his native JSON type (number, bool, object,
+// array...). Other texts are interpolated and returned as JSON strings.
+func (s *Store) ResolveJSON(text string) (json.RawMessage, error) {
+	tpl, err := s.templates.Load().Get(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(tpl.Nodes) == 1 && tpl.Nodes[0].Type == blueprint.TemplateNodeReference {
+		val, raw, err := s.evalReference(tpl.Nodes[0])
+		if err != nil {
+			return nil, err
+		}
+		if len(raw) > 0 {
+			return raw, nil
+		}
+		return json.Marshal(val)
+	}
+	if err := s.Interpolate(&text); err != nil {
+		return nil, err
+	}
+	return json.Marshal(text)
 }
 
 func (s *Store) DeepInterpolation(v interface{}) error {
+	if s.templates.Load().Empty() {
+		// the parameters of the running action
+		// have no references, skip the walk
+		return nil
+	}
 	return s.recursiveInterpolation(reflect.ValueOf(v), make(map[interface{}]bool))
 }
 
@@ -548,7 +663,7 @@ func (s *Store) recursiveInterpolation(v reflect.Value, il map[interface{}]bool)
 				}
 				if strv == strva {
 					// prevent not needed interpolation
-					return nil
+					continue
 				}
 				v.SetMapIndex(e, reflect.ValueOf(strv))
 			}
@@ -577,7 +692,7 @@ func (s *Store) recursiveInterpolation(v reflect.Value, il map[interface{}]bool)
 // GetPlain func
 func (s *Store) GetPlain() (map[string]string, error) {
 	result := make(map[string]string)
-	for refname, sr := range s.recordsByRefName {
+	for refname, sr := range s.layer.allRecordsByRefName() {
 		if reflect.ValueOf(sr.Value).Kind() == reflect.String {
 			result[refname+".__json"] = sr.Value.(string)
 			result[refname] = sr.Value.(string)
@@ -617,7 +732,7 @@ func (s *Store) GetPlain() (map[string]string, error) {
 
 func (s *Store) GetRawJSONValues() (map[string]json.RawMessage, error) {
 	result := make(map[string]json.RawMessage)
-	for refname, sr := range s.recordsByRefName {
+	for refname, sr := range s.layer.allRecordsByRefName() {
 		if sr.IsString {
 			enc, err := json.Marshal(string(sr.JSONValue))
 			if err != nil {
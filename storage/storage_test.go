@@ -23,8 +23,10 @@
 package storage_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -32,6 +34,7 @@ import (
 	"github.com/develatio/nebulant-cli/base"
 	"github.com/develatio/nebulant-cli/blueprint"
 	"github.com/develatio/nebulant-cli/storage"
+	"github.com/develatio/nebulant-cli/util"
 )
 
 // Provider struct
@@ -462,6 +465,70 @@ func TestMagicReferences(t *testing.T) {
 	}
 }
 
+func TestResolveJSON(t *testing.T) {
+	store := storage.NewStore()
+	type result struct {
+		Count int    `json:"count"`
+		IDs   []int  `json:"ids"`
+		Name  string `json:"name"`
+	}
+	store.Insert(&base.StorageRecord{RefName: "S", Value: "text", Literal: true}, "generic")
+	store.Insert(&base.StorageRecord{RefName: "O", Value: &result{Count: 3, IDs: []int{1, 2}, Name: "o"}}, "generic")
+	store.Insert(&base.StorageRecord{RefName: "I", Value: &result{}, ValueID: "i-1234"}, "generic")
+
+	for text, expected := range map[string]string{
+		"{{ O.count }}":    `3`,
+		"{{ O.ids }}":      `[1,2]`,
+		"{{O.__haserror}}": `false`,
+		"{{ S }}":          `"text"`,
+		"{{ I }}":          `"i-1234"`,
+		"n={{ O.count }}":  `"n=3"`,
+		"{{ O.name }}":     `"o"`,
+	} {
+		raw, err := store.ResolveJSON(text)
+		if err != nil {
+			t.Errorf("%s: %v", text, err)
+			continue
+		}
+		if strings.ReplaceAll(strings.Join(strings.Fields(string(raw)), ""), " ", "") != expected {
+			t.Errorf("%s: expected %s, got %s", text, expected, raw)
+		}
+	}
+
+	for _, text := range []string{"{{ MISSING }}", "{{ O }}", "{{ O.x | }}"} {
+		if _, err := store.ResolveJSON(text); err == nil {
+			t.Errorf("%s should fail", text)
+		}
+	}
+}
+
+func TestUnmarshalParameters(t *testing.T) {
+	store := storage.NewStore()
+	type result struct {
+		Count int      `json:"count"`
+		IDs   []string `json:"ids"`
+	}
+	store.Insert(&base.StorageRecord{RefName: "OBJ", Value: &result{Count: 3, IDs: []string{"a", "b"}}, Literal: true}, "generic")
+	type params struct {
+		Count   *int64   `json:"count"`
+		IDs     []string `json:"ids"`
+		Content *string  `json:"content"`
+		Body    string   `json:"body"`
+	}
+	data := json.RawMessage(`{"count": "{{ OBJ.count }}", "ids": "{{ OBJ.ids }}", "content": "{{ OBJ }}", "body": "{{ name }}"}`)
+	p := &params{}
+	if err := util.UnmarshalParameters(data, p, store); err != nil {
+		t.Fatal(err)
+	}
+	if *p.Count != 3 || len(p.IDs) != 2 || p.IDs[1] != "b" {
+		t.Errorf("references in non-string fields should keep his type, got %v %v", *p.Count, p.IDs)
+	}
+	// text fields are left to the actor
+	if *p.Content != "{{ OBJ }}" || p.Body != "{{ name }}" {
+		t.Errorf("string fields should be untouched, got %v %v", *p.Content, p.Body)
+	}
+}
+
 func TestEnv(t *testing.T) {
 	os.Setenv("VARNAME", "VARVALUE")
 	text := "{{ ENV.VARNAME }}"
@@ -550,10 +617,20 @@ func TestDuplicate(t *testing.T) {
 	if err == nil {
 		t.Errorf("Duplicate fail: fake id should not return value")
 	}
+	store2.Insert(&base.StorageRecord{
+		RefName: "SINGLE_VAR_NAME3",
+		Aout:    nil,
+		Value:   "varvalue3",
+	}, "generic")
 	store.Merge(store)
 	store.Merge(store2)
+	// only the values written by store2 after Duplicate are merged
 	a, _ = store.GetByRefName("SINGLE_VAR_NAME")
-	if a.Value.(string) != "varvalue" {
+	if a.Value.(string) != "varvalue2" {
+		t.Errorf("Merge fail: inherited value should not override, got %v", a.Value)
+	}
+	a, _ = store.GetByRefName("SINGLE_VAR_NAME3")
+	if a == nil || a.Value.(string) != "varvalue3" {
 		t.Errorf("Merge fail")
 	}
 	_, err = store2.GetByRefName("UNDEFINEDVAR")
@@ -738,3 +815,241 @@ func TestJSONPath(t *testing.T) {
 	}
 
 }
+
+func TestMergeWithPolicy(t *testing.T) {
+	store := storage.NewStore()
+	store.Insert(&base.StorageRecord{
+		RefName: "SINGLE_VAR_NAME",
+		Aout:    nil,
+		Value:   "varvalue",
+		Literal: true,
+	}, "generic")
+	store2 := store.Duplicate()
+	store3 := store.Duplicate()
+	store3.Insert(&base.StorageRecord{
+		RefName: "SINGLE_VAR_NAME",
+		Aout:    nil,
+		Value:   "varvalue3",
+		Literal: true,
+	}, "generic")
+
+	// unchanged values from a common parent are not a conflict
+	err := store.MergeWithPolicy(store2, blueprint.JoinMergeFailOnConflict)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	err = store.MergeWithPolicy(store3, blueprint.JoinMergeFailOnConflict)
+	if err == nil {
+		t.Errorf("fail_on_conflict should fail on different values")
+	}
+	a, _ := store.GetByRefName("SINGLE_VAR_NAME")
+	if a.Value.(string) != "varvalue" {
+		t.Errorf("fail_on_conflict should not modify the store, got %v", a.Value)
+	}
+
+	err = store.MergeWithPolicy(store3, blueprint.JoinMergeCollect)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	a, _ = store.GetByRefName("SINGLE_VAR_NAME")
+	stack, ok := a.Value.(*base.StorageRecordStack)
+	if !ok {
+		t.Errorf("collect should create a stack var, got %v", a.Value)
+		return
+	}
+	if len(stack.Items) != 2 || stack.Items[0] != "varvalue3" || stack.Items[1] != "varvalue" {
+		t.Errorf("collect fail, got %v", stack.Items)
+	}
+
+	err = store.MergeWithPolicy(store3, blueprint.JoinMergeLastWriterWins)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	a, _ = store.GetByRefName("SINGLE_VAR_NAME")
+	if a.Value.(string) != "varvalue3" {
+		t.Errorf("last_writer_wins fail, got %v", a.Value)
+	}
+}
+
+func TestCopyOnWrite(t *testing.T) {
+	store := storage.NewStore()
+	store.SetPrivateVar("PRIV", "parent")
+	store.Insert(&base.StorageRecord{RefName: "A", Value: "a0"}, "generic")
+	child := store.Duplicate()
+	child2 := store.Duplicate()
+
+	// writes after the fork are not shared
+	store.Insert(&base.StorageRecord{RefName: "A", Value: "a1"}, "generic")
+	child.Insert(&base.StorageRecord{RefName: "B", Value: "b"}, "generic")
+	child.SetPrivateVar("PRIV", "child")
+
+	a, _ := child.GetByRefName("A")
+	if a.Value.(string) != "a0" {
+		t.Errorf("child should see the value before the fork, got %v", a.Value)
+	}
+	if store.ExistsRefName("B") || child2.ExistsRefName("B") {
+		t.Errorf("child writes should not be visible from other stores")
+	}
+	if store.GetPrivateVar("PRIV").(string) != "parent" {
+		t.Errorf("child private vars should not be visible from parent")
+	}
+
+	// nested forks keep the whole chain
+	grandchild := child.Duplicate()
+	grandchild.Insert(&base.StorageRecord{RefName: "C", Value: "c"}, "generic")
+	if _, err := grandchild.GetByRefName("B"); err != nil {
+		t.Errorf("grandchild should see child values: %v", err)
+	}
+	child.Merge(grandchild)
+	store.Merge(child)
+	for ref, expected := range map[string]string{"A": "a1", "B": "b", "C": "c"} {
+		r, err := store.GetByRefName(ref)
+		if err != nil {
+			t.Errorf("%s: %v", ref, err)
+			continue
+		}
+		if r.Value.(string) != expected {
+			t.Errorf("%s: expected %v, got %v", ref, expected, r.Value)
+		}
+	}
+	if store.GetPrivateVar("PRIV").(string) != "child" {
+		t.Errorf("private vars written by child should be merged")
+	}
+
+	// collect only stacks the values written by the branches
+	collect := store.Duplicate()
+	collect.Insert(&base.StorageRecord{RefName: "A", Value: "a2"}, "generic")
+	if err := store.MergeWithPolicy(collect, blueprint.JoinMergeCollect); err != nil {
+		t.Errorf(err.Error())
+	}
+	if err := store.MergeWithPolicy(store.Duplicate(), blueprint.JoinMergeCollect); err != nil {
+		t.Errorf(err.Error())
+	}
+	r, _ := store.GetByRefName("A")
+	stack, ok := r.Value.(*base.StorageRecordStack)
+	if !ok || len(stack.Items) != 2 {
+		t.Errorf("collect should stack two values, got %v", r.Value)
+	}
+}
+
+func TestCopyOnWriteDepth(t *testing.T) {
+	var store base.IStore = storage.NewStore()
+	for i := 0; i < 100; i++ {
+		store.Insert(&base.StorageRecord{RefName: fmt.Sprintf("V%d", i), Value: "v"}, "generic")
+		store = store.Duplicate()
+	}
+	if depth := store.(*storage.Store).LayerDepth(); depth > 32 {
+		t.Errorf("layer chain should be flattened, got depth %d", depth)
+	}
+	for i := 0; i < 100; i++ {
+		if !store.ExistsRefName(fmt.Sprintf("V%d", i)) {
+			t.Errorf("V%d lost on flatten", i)
+		}
+	}
+}
+
+func TestCopyOnWriteCompactedMerge(t *testing.T) {
+	store := storage.NewStore()
+	store.Insert(&base.StorageRecord{RefName: "A", Value: "a0"}, "generic")
+	branch := store.Duplicate()
+	store.Insert(&base.StorageRecord{RefName: "A", Value: "a1"}, "generic")
+	// squash the chain of the parent store
+	for i := 0; i < 40; i++ {
+		store.Insert(&base.StorageRecord{RefName: fmt.Sprintf("V%d", i), Value: "v"}, "generic")
+		store.Duplicate()
+	}
+	branch.Insert(&base.StorageRecord{RefName: "B", Value: "b"}, "generic")
+	// squash the chain of the branch store
+	for i := 0; i < 40; i++ {
+		branch.Insert(&base.StorageRecord{RefName: fmt.Sprintf("W%d", i), Value: "w"}, "generic")
+		branch.Duplicate()
+	}
+	if err := store.MergeWithPolicy(branch, blueprint.JoinMergeFailOnConflict); err != nil {
+		t.Errorf("values inherited by the branch should not conflict: %v", err)
+	}
+	a, _ := store.GetByRefName("A")
+	if a.Value.(string) != "a1" {
+		t.Errorf("stale inherited value overrides the parent, got %v", a.Value)
+	}
+	for _, ref := range []string{"B", "W0", "W39", "V39"} {
+		if !store.ExistsRefName(ref) {
+			t.Errorf("%s lost on merge", ref)
+		}
+	}
+}
+
+func benchStore(records int) *storage.Store {
+	store := storage.NewStore()
+	store.SetLogger(&fakeLogger{})
+	for i := 0; i < records; i++ {
+		action := &blueprint.Action{ActionID: fmt.Sprintf("action%d", i)}
+		aout := base.NewActionOutput(action, &ec2.Image{
+			ImageId: aws.String(fmt.Sprintf("ami-%d", i)),
+			Name:    aws.String(fmt.Sprintf("image %d", i)),
+		}, aws.String(fmt.Sprintf("ami-%d", i)))
+		aout.Records[0].RefName = fmt.Sprintf("IMAGE%d", i)
+		if err := store.Insert(aout.Records[0], "aws"); err != nil {
+			panic(err)
+		}
+	}
+	return store
+}
+
+// benchmarkForkJoin forks the store into n branches, writes one value
+// per branch and merges them back, like a thread point and a join point
+func benchmarkForkJoin(b *testing.B, dupe func(*storage.Store) base.IStore, records int, n int) {
+	store := benchStore(records)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		branches := make([]base.IStore, n)
+		for j := 0; j < n; j++ {
+			branches[j] = dupe(store)
+			branches[j].Insert(&base.StorageRecord{RefName: fmt.Sprintf("BRANCH%d", j), Value: "v"}, "generic")
+		}
+		for j := 0; j < n; j++ {
+			store.Merge(branches[j])
+		}
+	}
+}
+
+func cowDuplicate(s *storage.Store) base.IStore   { return s.Duplicate() }
+func eagerDuplicate(s *storage.Store) base.IStore { return s.EagerDuplicate() }
+
+func BenchmarkDuplicateCOW(b *testing.B) {
+	store := benchStore(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store.Duplicate()
+	}
+}
+
+func BenchmarkDuplicateEager(b *testing.B) {
+	store := benchStore(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store.EagerDuplicate()
+	}
+}
+
+func BenchmarkForkJoinCOW(b *testing.B) {
+	benchmarkForkJoin(b, cowDuplicate, 10000, 8)
+}
+
+func BenchmarkForkJoinEager(b *testing.B) {
+	benchmarkForkJoin(b, eagerDuplicate, 10000, 8)
+}
+
+func BenchmarkLookupCOW(b *testing.B) {
+	var store base.IStore = benchStore(1000)
+	// IMAGE0 lives at the bottom of a 16 layers chain
+	for i := 0; i < 16; i++ {
+		store.Insert(&base.StorageRecord{RefName: fmt.Sprintf("LEVEL%d", i), Value: "v"}, "generic")
+		store = store.Duplicate()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := store.GetByRefName("IMAGE0"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
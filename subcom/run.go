@@ -39,6 +39,7 @@ func parseRunFs(cmdline *flag.FlagSet) (*flag.FlagSet, error) {
 	fs := flag.NewFlagSet("run", flag.ContinueOnError)
 	fs.SetOutput(cmdline.Output())
 	config.ForceFile = fs.Bool("f", false, "Run local file")
+	config.LogDirFlag = fs.String("log-dir", "", "Write the logs of each action run to its own file into this dir")
 	fs.Usage = func() {
 		fmt.Fprintf(fs.Output(), "\nUsage: nebulant [file://, nebulant://][org/coll/bp][filepath] [--varname=varvalue --varname=varvalue]\n\n")
 		fmt.Fprintf(fs.Output(), "Examples:\n")
@@ -50,6 +51,7 @@ func parseRunFs(cmdline *flag.FlagSet) (*flag.FlagSet, error) {
 		fmt.Fprintf(fs.Output(), "\tnebulant run nebulant://develatio/utils/debug\n")
 		fmt.Fprintf(fs.Output(), "\tnebulant run -f ./local/file/project.nbp\n")
 		fmt.Fprintf(fs.Output(), "\tnebulant run file://local/file/project.nbp\n")
+		fmt.Fprintf(fs.Output(), "\tnebulant run --log-dir ./logs -f ./local/file/project.nbp\n")
 		fmt.Fprintf(fs.Output(), "\n\n")
 	}
 	err := fs.Parse(cmdline.Args()[1:])
@@ -96,6 +98,12 @@ func RunCmd(nblc *subsystem.NBLcommand) (int, error) {
 		}
 		return 1, err
 	}
+	if *config.LogDirFlag != "" {
+		err = cast.ActionLogs.SetDir(*config.LogDirFlag)
+		if err != nil {
+			return 1, err
+		}
+	}
 	// Director in one run mode
 	err = executive.InitDirector(false, false)
 	if err != nil {
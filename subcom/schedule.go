@@ -0,0 +1,95 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package subcom
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/develatio/nebulant-cli/config"
+	"github.com/develatio/nebulant-cli/executive"
+	"github.com/develatio/nebulant-cli/subsystem"
+)
+
+func parseScheduleFs(cmdline *flag.FlagSet) (*flag.FlagSet, error) {
+	fs := flag.NewFlagSet("schedule", flag.ContinueOnError)
+	fs.SetOutput(cmdline.Output())
+	config.AddrFlag = fs.String("b", config.SERVER_ADDR+":"+config.SERVER_PORT, "Bind addr:port (ipv4) or [::1]:port (ipv6)")
+	config.ScheduleStateFlag = fs.String("state", filepath.Join(config.AppHomePath(), "schedule.state.json"), "File to persist the status of the runs")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "\nUsage: nebulant schedule [options] schedule.json\n")
+		fmt.Fprintf(fs.Output(), "\nOptions:\n")
+		subsystem.PrintDefaults(fs)
+		fmt.Fprintf(fs.Output(), "\nSchedule file example:\n")
+		fmt.Fprintf(fs.Output(), "\t{\"schedules\": [{\n")
+		fmt.Fprintf(fs.Output(), "\t\t\"name\": \"nightly-backup\",\n")
+		fmt.Fprintf(fs.Output(), "\t\t\"cron\": \"0 3 * * *\",\n")
+		fmt.Fprintf(fs.Output(), "\t\t\"blueprint\": \"develatio/utils/backup\",\n")
+		fmt.Fprintf(fs.Output(), "\t\t\"args\": [\"--target=db\"],\n")
+		fmt.Fprintf(fs.Output(), "\t\t\"overlap\": \"skip\"\n")
+		fmt.Fprintf(fs.Output(), "\t}]}\n")
+		fmt.Fprintf(fs.Output(), "\nSet \"file\": true to run a local file. Overlap policies: skip (default), queue, allow.\n\n")
+	}
+	err := fs.Parse(cmdline.Args()[1:])
+	if err != nil {
+		return fs, err
+	}
+
+	err = parseBindAddr(*config.AddrFlag)
+	if err != nil {
+		return fs, err
+	}
+
+	return fs, nil
+}
+
+func ScheduleCmd(nblc *subsystem.NBLcommand) (int, error) {
+	fs, err := parseScheduleFs(nblc.CommandLine())
+	if err != nil {
+		return 1, err
+	}
+	schedulePath := fs.Arg(0)
+	if schedulePath == "" {
+		fs.Usage()
+		return 1, fmt.Errorf("please provide the path to the schedule file")
+	}
+
+	// Director in server mode
+	err = executive.InitDirector(true, false)
+	if err != nil {
+		return 1, err
+	}
+	err = executive.InitScheduler(schedulePath, *config.ScheduleStateFlag)
+	if err != nil {
+		return 1, err
+	}
+	defer executive.MScheduler.Stop()
+	errc := executive.InitServerMode()
+	err = <-errc
+	if err != nil {
+		return 2, err
+	}
+	executive.MDirector.Wait()
+	return 0, nil
+}
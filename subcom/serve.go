@@ -26,6 +26,7 @@ import (
 	"flag"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/develatio/nebulant-cli/cast"
 	"github.com/develatio/nebulant-cli/config"
@@ -37,6 +38,8 @@ func parseServeFs(cmdline *flag.FlagSet) (*flag.FlagSet, error) {
 	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
 	fs.SetOutput(cmdline.Output())
 	config.AddrFlag = fs.String("b", config.SERVER_ADDR+":"+config.SERVER_PORT, "Bind addr:port (ipv4) or [::1]:port (ipv6)")
+	config.TriggersFileFlag = fs.String("triggers", "", "Load webhook triggers from file, served at /trigger/<name>")
+	config.LogHistoryDirFlag = fs.String("log-history-dir", "", "Keep the whole history of the executions, writing the older logs to this dir")
 	fs.Usage = func() {
 		fmt.Fprintf(fs.Output(), "\nUsage: nebulant serve [options]\n")
 		fmt.Fprintf(fs.Output(), "\nOptions:\n")
@@ -47,35 +50,63 @@ func parseServeFs(cmdline *flag.FlagSet) (*flag.FlagSet, error) {
 		return fs, err
 	}
 
+	err = parseBindAddr(*config.AddrFlag)
+	if err != nil {
+		return fs, err
+	}
+
+	return fs, nil
+}
+
+// parseBindAddr sets the server addr and port from
+// an addr:port (ipv4) or [::1]:port (ipv6) string
+func parseBindAddr(addr string) error {
 	var tcpaddr *net.TCPAddr
+	var err error
 	if *config.Ipv6Flag {
-		tcpaddr, err = net.ResolveTCPAddr("tcp6", *config.AddrFlag)
+		tcpaddr, err = net.ResolveTCPAddr("tcp6", addr)
 		if err != nil {
-			return fs, err
+			return err
 		}
 	} else {
-		tcpaddr, err = net.ResolveTCPAddr("tcp", *config.AddrFlag)
+		tcpaddr, err = net.ResolveTCPAddr("tcp", addr)
 		if err != nil {
-			return fs, err
+			return err
 		}
 	}
 	host, port, err := net.SplitHostPort(tcpaddr.String())
 	if err != nil {
-		return fs, err
+		return err
 	}
 
 	config.SERVER_ADDR = host
 	config.SERVER_PORT = port
 
-	return fs, nil
+	return nil
 }
 
 func ServeCmd(nblc *subsystem.NBLcommand) (int, error) {
+	if nblc.CommandLine().Arg(1) == "token" {
+		return ServeTokenCmd(nblc)
+	}
 	_, err := parseServeFs(nblc.CommandLine())
 	if err != nil {
 		return 1, err
 	}
 
+	if *config.LogHistoryDirFlag != "" {
+		err = cast.LogHistory.SetSpillDir(*config.LogHistoryDirFlag)
+		if err != nil {
+			return 1, err
+		}
+	}
+	if *config.TriggersFileFlag != "" {
+		err = executive.LoadTriggers(*config.TriggersFileFlag)
+		if err != nil {
+			return 1, err
+		}
+	}
+
 	// Director in server mode
 	err = executive.InitDirector(true, false) // Server mode
 	if err != nil {
@@ -90,3 +121,69 @@ func ServeCmd(nblc *subsystem.NBLcommand) (int, error) {
 	executive.MDirector.Wait() // None to wait if director has stoped
 	return 0, nil
 }
+
+func parseServeTokenFs(cmdline *flag.FlagSet) (*flag.FlagSet, error) {
+	fs := flag.NewFlagSet("token", flag.ContinueOnError)
+	fs.SetOutput(cmdline.Output())
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "\nUsage: nebulant serve token [command]\n")
+		fmt.Fprintf(fs.Output(), "\nCommands:\n")
+		fmt.Fprintf(fs.Output(), "  create NAME\t\tCreate a new token for the server API\n")
+		fmt.Fprintf(fs.Output(), "  list\t\t\tList the tokens\n")
+		fmt.Fprintf(fs.Output(), "  revoke ID\t\tRevoke a token\n")
+		fmt.Fprintf(fs.Output(), "\nUse the token as \"Authorization: Bearer <token>\" in /api/v1/ requests\n\n")
+	}
+	err := fs.Parse(cmdline.Args()[2:])
+	if err != nil {
+		return fs, err
+	}
+	return fs, nil
+}
+
+// ServeTokenCmd func. Handles the tokens of the server API
+func ServeTokenCmd(nblc *subsystem.NBLcommand) (int, error) {
+	fs, err := parseServeTokenFs(nblc.CommandLine())
+	if err != nil {
+		return 1, err
+	}
+
+	switch fs.Arg(0) {
+	case "create":
+		name := fs.Arg(1)
+		if name == "" {
+			fs.Usage()
+			return 1, fmt.Errorf("please provide a name for the token")
+		}
+		raw, token, err := config.CreateAPIToken(name)
+		if err != nil {
+			return 1, err
+		}
+		cast.LogInfo("Token "+token.ID+" created. Save it now, it will not be shown again:", nil)
+		fmt.Println(raw)
+	case "list":
+		tokens, err := config.ListAPITokens()
+		if err != nil {
+			return 1, err
+		}
+		if len(tokens) <= 0 {
+			cast.LogInfo("No tokens found", nil)
+		}
+		for _, token := range tokens {
+			fmt.Printf("%s\t%s\t%s\n", token.ID, token.CreatedAt.Format(time.RFC3339), token.Name)
+		}
+	case "revoke":
+		id := fs.Arg(1)
+		if id == "" {
+			fs.Usage()
+			return 1, fmt.Errorf("please provide the id of the token")
+		}
+		if err := config.RevokeAPIToken(id); err != nil {
+			return 1, err
+		}
+		cast.LogInfo("Token "+id+" revoked", nil)
+	default:
+		fs.Usage()
+		return 1, fmt.Errorf("please provide some subcommand to token")
+	}
+	return 0, nil
+}
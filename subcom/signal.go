@@ -0,0 +1,74 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package subcom
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/develatio/nebulant-cli/ipc"
+	"github.com/develatio/nebulant-cli/subsystem"
+)
+
+func parseSignal(cmdline *flag.FlagSet) (*flag.FlagSet, error) {
+	fs := flag.NewFlagSet("signal", flag.ContinueOnError)
+	fs.SetOutput(cmdline.Output())
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), "\nUsage: nebulant signal [callback token] [json body]\n")
+		fmt.Fprint(fs.Output(), "\nResume the wait_for_event action waiting for the token. Use - as body to read it from stdin\n")
+		subsystem.PrintDefaults(fs)
+	}
+	err := fs.Parse(cmdline.Args()[1:])
+	if err != nil {
+		return fs, err
+	}
+	return fs, nil
+}
+
+func SignalCmd(nblc *subsystem.NBLcommand) (int, error) {
+	fs, err := parseSignal(nblc.CommandLine())
+	if err != nil {
+		return 1, err
+	}
+
+	token := fs.Arg(0)
+	if token == "" {
+		fs.Usage()
+		return 1, fmt.Errorf("please provide the callback token")
+	}
+	body := fs.Arg(1)
+	if body == "-" {
+		data, err := io.ReadAll(io.LimitReader(os.Stdin, 60000))
+		if err != nil {
+			return 1, err
+		}
+		body = string(data)
+	}
+	err = ipc.Signal(token, body)
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
@@ -40,6 +40,14 @@ func RegisterSubcommands() {
 			Sec:           subsystem.SecMain,
 			Call:          ServeCmd,
 		},
+		"schedule": {
+			UpgradeTerm:   true,
+			WelcomeMsg:    true,
+			InitProviders: true,
+			Help:          "  schedule\t\t" + term.EmojiSet["TridentEmblem"] + " Run blueprints on a cron schedule\n",
+			Sec:           subsystem.SecMain,
+			Call:          ScheduleCmd,
+		},
 		"run": {
 			UpgradeTerm:   true,
 			WelcomeMsg:    true,
@@ -120,6 +128,14 @@ func RegisterSubcommands() {
 			Sec:           subsystem.SecRuntime,
 			Call:          ReadvarCmd,
 		},
+		"signal": {
+			UpgradeTerm:   false,
+			WelcomeMsg:    false,
+			InitProviders: false,
+			Help:          "  signal\t\t" + term.EmojiSet["FaceWithMonocle"] + " Resume an action waiting for an event\n",
+			Sec:           subsystem.SecRuntime,
+			Call:          SignalCmd,
+		},
 		"debugger": {
 			UpgradeTerm:   true,
 			WelcomeMsg:    true,
@@ -3,6 +3,7 @@ package term
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"os"
@@ -475,6 +476,12 @@ func (m *MultilineStdout) AppendLine() *oneLineWriteCloser {
 }
 
 func (m *MultilineStdout) SelectTest(prompt string, options []string) (int, error) {
+	return m.SelectTestContext(context.Background(), prompt, options)
+}
+
+// SelectTestContext func. Like SelectTest, but stops
+// waiting for the user once ctx is done.
+func (m *MultilineStdout) SelectTestContext(ctx context.Context, prompt string, options []string) (int, error) {
 	tsi.mu.Lock()
 	defer tsi.mu.Unlock()
 	oldState, err := term.MakeRaw(0)
@@ -489,7 +496,6 @@ func (m *MultilineStdout) SelectTest(prompt string, options []string) (int, erro
 	defer m.mainStdout.Write([]byte(ShowCursor)) // #nosec G104 -- Unhandle is OK here
 
 	var buff bytes.Buffer
-	reader := bufio.NewReader(os.Stdin)
 	selected := 0
 	var lines []*oneLineWriteCloser
 
@@ -546,7 +552,13 @@ func (m *MultilineStdout) SelectTest(prompt string, options []string) (int, erro
 		}
 
 		// read stdin
-		char, size, err := reader.ReadRune()
+		char, size, err := stdin.Read(ctx)
+		if ctx.Err() != nil {
+			for i := 0; i < len(lines); i++ {
+				lines[i].Write([]byte(CursorToColZero + Reset + EraseLine)) // #nosec G104 -- Unhandle is OK here
+			}
+			return -1, ctx.Err()
+		}
 		if err != nil {
 			return size, err
 		}
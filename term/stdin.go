@@ -0,0 +1,82 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package term
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"sync"
+)
+
+type stdinRune struct {
+	char rune
+	size int
+	err  error
+}
+
+// cancellableStdin reads stdin from a single goroutine, so a prompt
+// can stop waiting for input. A rune read after the prompt gave up is
+// kept for the next prompt instead of being lost.
+type cancellableStdin struct {
+	once     sync.Once
+	mu       sync.Mutex
+	req      chan struct{}
+	res      chan *stdinRune
+	inflight bool
+}
+
+var stdin = &cancellableStdin{}
+
+func (c *cancellableStdin) start() {
+	c.req = make(chan struct{})
+	c.res = make(chan *stdinRune, 1)
+	go func() {
+		reader := bufio.NewReader(os.Stdin)
+		for range c.req {
+			char, size, err := reader.ReadRune()
+			c.res <- &stdinRune{char: char, size: size, err: err}
+		}
+	}()
+}
+
+// Read reads a rune from stdin or returns the error of
+// ctx if it is done before
+func (c *cancellableStdin) Read(ctx context.Context) (rune, int, error) {
+	c.once.Do(c.start)
+	c.mu.Lock()
+	if !c.inflight {
+		c.req <- struct{}{}
+		c.inflight = true
+	}
+	c.mu.Unlock()
+	select {
+	case r := <-c.res:
+		c.mu.Lock()
+		c.inflight = false
+		c.mu.Unlock()
+		return r.char, r.size, r.err
+	case <-ctx.Done():
+		return 0, 0, ctx.Err()
+	}
+}
@@ -120,6 +120,11 @@ func isTerminal() bool {
 	return term.IsTerminal(int(os.Stdout.Fd()))
 }
 
+// IsTerminal func. Returns true if the output is an interactive terminal
+func IsTerminal() bool {
+	return isTerminal()
+}
+
 func AppendLine() *oneLineWriteCloser {
 	return mls.AppendLine()
 }
@@ -128,6 +133,12 @@ func Selectable(prompt string, options []string) (int, error) {
 	return mls.SelectTest(prompt, options)
 }
 
+// SelectableContext func. Like Selectable, but the prompt
+// is discarded once ctx is done
+func SelectableContext(ctx context.Context, prompt string, options []string) (int, error) {
+	return mls.SelectTestContext(ctx, prompt, options)
+}
+
 func openMultilineStdout() {
 	if mls == nil {
 		mls = &MultilineStdout{}
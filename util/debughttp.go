@@ -0,0 +1,338 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DebugTransportMaxBody const. Max bytes of the bodies kept in the traces
+const DebugTransportMaxBody = 4096
+
+const redacted = "[REDACTED]"
+
+var redactedHeaders = map[string]bool{
+	"Authorization":        true,
+	"Proxy-Authorization":  true,
+	"Cookie":               true,
+	"Set-Cookie":           true,
+	"X-Amz-Security-Token": true,
+	"X-Auth-Token":         true,
+	"X-Api-Key":            true,
+}
+
+// secretFieldPattern matches the names of the body fields holding
+// secrets, like the root password of a server or the private key
+// of a key pair
+const secretFieldPattern = `\w*(?:password|passwd|secret|private_?key|key_?material|session_?token|access_?token|api_?key)\w*`
+
+// redactedBodyFields replace the values of the secret fields of JSON,
+// XML and form encoded bodies. Values cut by the truncation of the
+// body are also replaced.
+var redactedBodyFields = []struct {
+	rgx  *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`(?i)("` + secretFieldPattern + `"\s*:\s*)"(?:[^"\\]|\\.)*"?`), `$1"` + redacted + `"`},
+	{regexp.MustCompile(`(?i)(<(` + secretFieldPattern + `)>)[^<]*`), `${1}` + redacted},
+	{regexp.MustCompile(`(?i)((?:^|&)` + secretFieldPattern + `=)[^&]*`), `${1}` + redacted},
+}
+
+var redactedQueryParams = map[string]bool{
+	"x-amz-signature":      true,
+	"x-amz-credential":     true,
+	"x-amz-security-token": true,
+	"token":                true,
+	"access_token":         true,
+	"api_key":              true,
+}
+
+// HTTPTrace struct. A request and his response as seen by DebugTransport
+type HTTPTrace struct {
+	Method                string            `json:"method"`
+	URL                   string            `json:"url"`
+	RequestHeaders        map[string]string `json:"request_headers"`
+	RequestBody           string            `json:"request_body,omitempty"`
+	RequestBodyTruncated  bool              `json:"request_body_truncated,omitempty"`
+	Status                string            `json:"status,omitempty"`
+	StatusCode            int               `json:"status_code,omitempty"`
+	ResponseHeaders       map[string]string `json:"response_headers,omitempty"`
+	ResponseBody          string            `json:"response_body,omitempty"`
+	ResponseBodyTruncated bool              `json:"response_body_truncated,omitempty"`
+	Error                 string            `json:"error,omitempty"`
+	StartedAt             time.Time         `json:"started_at"`
+	DurationMs            float64           `json:"duration_ms"`
+}
+
+// String func. Human readable trace, like curl -v does
+func (t *HTTPTrace) String() string {
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "> %s %s\n", t.Method, t.URL)
+	writeTraceHeaders(sb, "> ", t.RequestHeaders)
+	writeTraceBody(sb, t.RequestBody, t.RequestBodyTruncated)
+	if t.Error != "" {
+		fmt.Fprintf(sb, "! %s (%.1fms)", t.Error, t.DurationMs)
+		return sb.String()
+	}
+	fmt.Fprintf(sb, "< %s (%.1fms)\n", t.Status, t.DurationMs)
+	writeTraceHeaders(sb, "< ", t.ResponseHeaders)
+	writeTraceBody(sb, t.ResponseBody, t.ResponseBodyTruncated)
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func writeTraceHeaders(sb *strings.Builder, prefix string, headers map[string]string) {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sb.WriteString(prefix + k + ": " + headers[k] + "\n")
+	}
+}
+
+func writeTraceBody(sb *strings.Builder, body string, truncated bool) {
+	if body == "" {
+		return
+	}
+	sb.WriteString(body)
+	if truncated {
+		sb.WriteString("...[Truncated]")
+	}
+	sb.WriteString("\n")
+}
+
+// DebugTransport struct. http.RoundTripper recording every request
+// through Base and passing the traces to OnTrace. Without OnTrace, the
+// tracer of the request context is used (see WithHTTPTracer), and the
+// requests without tracer are not recorded.
+type DebugTransport struct {
+	Base    http.RoundTripper
+	OnTrace func(t *HTTPTrace)
+}
+
+type httpTracerKey struct{}
+
+// WithHTTPTracer func. Returns a copy of ctx whose requests are traced
+// by onTrace when they go through a DebugTransport without OnTrace, so
+// a shared client can trace only some of his requests
+func WithHTTPTracer(ctx context.Context, onTrace func(t *HTTPTrace)) context.Context {
+	return context.WithValue(ctx, httpTracerKey{}, onTrace)
+}
+
+// NewDebugTransport func. base can be nil to use http.DefaultTransport
+func NewDebugTransport(base http.RoundTripper, onTrace func(t *HTTPTrace)) *DebugTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &DebugTransport{Base: base, OnTrace: onTrace}
+}
+
+// NewDebugHTTPClient func. Copy of client, or of http.DefaultClient if
+// nil, tracing his requests
+func NewDebugHTTPClient(client *http.Client, onTrace func(t *HTTPTrace)) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	debugClient := *client
+	debugClient.Transport = NewDebugTransport(client.Transport, onTrace)
+	return &debugClient
+}
+
+// RoundTrip func
+func (d *DebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	onTrace := d.OnTrace
+	if onTrace == nil {
+		onTrace, _ = req.Context().Value(httpTracerKey{}).(func(t *HTTPTrace))
+	}
+	if onTrace == nil {
+		return d.Base.RoundTrip(req)
+	}
+	trace := &HTTPTrace{
+		Method:         req.Method,
+		URL:            redactURL(req.URL),
+		RequestHeaders: redactHeaders(req.Header),
+		StartedAt:      time.Now().UTC(),
+	}
+
+	var reqBody *captureReader
+	if req.Body != nil && req.Body != http.NoBody {
+		// the body is recorded while the base transport sends it
+		reqBody = &captureReader{ReadCloser: req.Body}
+		req = req.Clone(req.Context())
+		req.Body = reqBody
+	}
+
+	resp, err := d.Base.RoundTrip(req)
+	trace.DurationMs = float64(time.Since(trace.StartedAt).Microseconds()) / 1000
+	if reqBody != nil {
+		trace.RequestBody, trace.RequestBodyTruncated = reqBody.captured()
+	}
+	if err != nil {
+		trace.Error = err.Error()
+		onTrace(trace)
+		return resp, err
+	}
+
+	trace.Status = resp.Status
+	trace.StatusCode = resp.StatusCode
+	trace.ResponseHeaders = redactHeaders(resp.Header)
+	if resp.Body == nil || resp.Body == http.NoBody {
+		onTrace(trace)
+		return resp, nil
+	}
+	// the body is recorded while the caller reads it, the trace
+	// is passed once the body is read, closed or big enough
+	resp.Body = &tracedBody{
+		captureReader: captureReader{ReadCloser: resp.Body},
+		trace:         trace,
+		onTrace:       onTrace,
+	}
+	return resp, nil
+}
+
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	cu := *u
+	if cu.User != nil {
+		cu.User = url.User(cu.User.Username())
+	}
+	if cu.RawQuery != "" {
+		q := cu.Query()
+		changed := false
+		for k := range q {
+			if redactedQueryParams[strings.ToLower(k)] {
+				q.Set(k, redacted)
+				changed = true
+			}
+		}
+		if changed {
+			cu.RawQuery = q.Encode()
+		}
+	}
+	return cu.String()
+}
+
+func redactHeaders(h http.Header) map[string]string {
+	headers := make(map[string]string, len(h))
+	for k, v := range h {
+		if redactedHeaders[http.CanonicalHeaderKey(k)] {
+			headers[k] = redacted
+			continue
+		}
+		headers[k] = strings.Join(v, ", ")
+	}
+	return headers
+}
+
+// traceBody returns the printable and redacted start of the body
+// and if it has been truncated
+func traceBody(b []byte) (string, bool) {
+	truncated := len(b) > DebugTransportMaxBody
+	for _, rf := range redactedBodyFields {
+		b = rf.rgx.ReplaceAll(b, []byte(rf.repl))
+	}
+	if len(b) > DebugTransportMaxBody {
+		b = b[:DebugTransportMaxBody]
+		// do not split the last rune
+		for i := 0; i < utf8.UTFMax && len(b) > 0 && !utf8.Valid(b); i++ {
+			b = b[:len(b)-1]
+		}
+	}
+	if !utf8.Valid(b) {
+		return "[binary data]", truncated
+	}
+	return string(b), truncated
+}
+
+type captureReader struct {
+	io.ReadCloser
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (c *captureReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if room := DebugTransportMaxBody + 1 - len(c.buf); room > 0 {
+		if room > n {
+			room = n
+		}
+		c.buf = append(c.buf, p[:room]...)
+	}
+	return n, err
+}
+
+// full returns true once the capture holds more than the max body
+func (c *captureReader) full() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.buf) > DebugTransportMaxBody
+}
+
+func (c *captureReader) captured() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return traceBody(c.buf)
+}
+
+// tracedBody is a response body passing his trace once
+type tracedBody struct {
+	captureReader
+	trace   *HTTPTrace
+	onTrace func(t *HTTPTrace)
+	once    sync.Once
+}
+
+func (t *tracedBody) Read(p []byte) (int, error) {
+	n, err := t.captureReader.Read(p)
+	if err != nil || t.full() {
+		t.done()
+	}
+	return n, err
+}
+
+func (t *tracedBody) Close() error {
+	t.done()
+	return t.captureReader.Close()
+}
+
+func (t *tracedBody) done() {
+	t.once.Do(func() {
+		t.trace.ResponseBody, t.trace.ResponseBodyTruncated = t.captured()
+		t.onTrace(t.trace)
+	})
+}
@@ -0,0 +1,191 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package util_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/develatio/nebulant-cli/util"
+)
+
+func TestDebugTransport(t *testing.T) {
+	big := strings.Repeat("x", util.DebugTransportMaxBody+100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "ping" {
+			t.Errorf("server got body %q", body)
+		}
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, big)
+	}))
+	defer srv.Close()
+
+	var traces []*util.HTTPTrace
+	client := util.NewDebugHTTPClient(nil, func(tr *util.HTTPTrace) {
+		traces = append(traces, tr)
+	})
+	req, err := http.NewRequest("POST", srv.URL+"/path?X-Amz-Signature=abc&page=2", strings.NewReader("ping"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Custom", "visible")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != big {
+		t.Errorf("response body altered, got %d bytes", len(body))
+	}
+
+	if len(traces) != 1 {
+		t.Fatalf("expected 1 trace, got %d", len(traces))
+	}
+	tr := traces[0]
+	if strings.Contains(tr.String(), "secret") || strings.Contains(tr.URL, "abc") {
+		t.Errorf("secrets not redacted: %s", tr.String())
+	}
+	if !strings.Contains(tr.URL, "page=2") {
+		t.Errorf("unexpected url %s", tr.URL)
+	}
+	if tr.RequestHeaders["X-Custom"] != "visible" {
+		t.Errorf("unexpected request headers %v", tr.RequestHeaders)
+	}
+	if tr.RequestBody != "ping" || tr.RequestBodyTruncated {
+		t.Errorf("unexpected request body %q", tr.RequestBody)
+	}
+	if tr.StatusCode != http.StatusTeapot {
+		t.Errorf("unexpected status %d", tr.StatusCode)
+	}
+	if len(tr.ResponseBody) != util.DebugTransportMaxBody || !tr.ResponseBodyTruncated {
+		t.Errorf("response body not truncated, got %d bytes", len(tr.ResponseBody))
+	}
+}
+
+func TestDebugTransportContextTracer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "pong")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: util.NewDebugTransport(nil, nil)}
+	var traces []*util.HTTPTrace
+	traced := util.WithHTTPTracer(context.Background(), func(tr *util.HTTPTrace) {
+		traces = append(traces, tr)
+	})
+	for _, ctx := range []context.Context{context.Background(), traced} {
+		req, err := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "pong" {
+			t.Errorf("response body altered, got %q", body)
+		}
+	}
+	if len(traces) != 1 {
+		t.Fatalf("only the request with tracer should be traced, got %d traces", len(traces))
+	}
+	if traces[0].ResponseBody != "pong" {
+		t.Errorf("unexpected response body %q", traces[0].ResponseBody)
+	}
+}
+
+func TestDebugTransportRedactBodies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"server": {"id": 1}, "root_password": "s3cr3t"}<keyMaterial>-----BEGIN RSA`)
+	}))
+	defer srv.Close()
+
+	var traces []*util.HTTPTrace
+	client := util.NewDebugHTTPClient(nil, func(tr *util.HTTPTrace) {
+		traces = append(traces, tr)
+	})
+	resp, err := client.Post(srv.URL, "application/x-www-form-urlencoded", strings.NewReader("Action=Create&MasterUserPassword=hunter2&Name=db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if len(traces) != 1 {
+		t.Fatalf("expected 1 trace, got %d", len(traces))
+	}
+	tr := traces[0]
+	for _, secret := range []string{"s3cr3t", "hunter2", "BEGIN RSA"} {
+		if strings.Contains(tr.String(), secret) {
+			t.Errorf("secret %q not redacted: %s", secret, tr.String())
+		}
+	}
+	if !strings.Contains(tr.RequestBody, "Name=db") || !strings.Contains(tr.ResponseBody, `"id": 1`) {
+		t.Errorf("unexpected bodies %q %q", tr.RequestBody, tr.ResponseBody)
+	}
+}
+
+func TestDebugTransportStreamingResponse(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "first")
+		w.(http.Flusher).Flush()
+		<-release
+		io.WriteString(w, "last")
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	var traces []*util.HTTPTrace
+	client := util.NewDebugHTTPClient(nil, func(tr *util.HTTPTrace) {
+		traces = append(traces, tr)
+	})
+	// the round trip must not wait for the rest of the body
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(resp.Body, buf); err != nil || string(buf) != "first" {
+		t.Fatalf("unexpected read %q: %v", buf, err)
+	}
+	if len(traces) != 0 {
+		t.Errorf("trace passed before the body is done")
+	}
+	resp.Body.Close()
+	if len(traces) != 1 || traces[0].ResponseBody != "first" {
+		t.Errorf("unexpected traces %v", traces)
+	}
+}
@@ -0,0 +1,223 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+var jsonRawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// JSONResolver interface. Resolves a string consisting of a single
+// {{ ref }} to the referenced value, keeping his native JSON type.
+type JSONResolver interface {
+	ResolveJSON(text string) (json.RawMessage, error)
+}
+
+// isWholePlaceholder returns true if s consists of a single {{ ref }}
+func isWholePlaceholder(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "{{") && strings.HasSuffix(s, "}}") && strings.Count(s, "{{") == 1
+}
+
+// adaptState struct. Context of an adaptJSON call.
+type adaptState struct {
+	// resolver of the references, nil if the parameters
+	// are not being run (rehearsal)
+	resolver JSONResolver
+	// placeholders are the namespaces of the fields left
+	// empty in rehearsal, as the validator names them
+	placeholders []string
+}
+
+// jsonField struct. A field of a struct as seen by encoding/json
+type jsonField struct {
+	typ reflect.Type
+	// ns is the field name, prefixed by the embedded structs
+	ns string
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "/" + key
+}
+
+func joinNamespace(ns string, field string) string {
+	if ns == "" {
+		return field
+	}
+	return ns + "." + field
+}
+
+// adaptJSON converts the values of data to the types expected by t:
+//   - {{ ref }} strings in non-string fields are replaced with the
+//     native JSON value of the reference, or left empty if there is
+//     no resolver (rehearsal). The namespaces of the fields left empty
+//     are collected in placeholders. String fields are left untouched,
+//     to be interpolated as text by the actor.
+//   - numbers, bools, objects and arrays coming from a reference are
+//     used as text where a string is expected
+func (st *adaptState) adaptJSON(data json.RawMessage, t reflect.Type, path string, ns string, resolved bool) (json.RawMessage, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface || t == jsonRawMessageType || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return data, nil
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) <= 0 || string(data) == "null" {
+		return data, nil
+	}
+
+	var str string
+	isString := data[0] == '"' && json.Unmarshal(data, &str) == nil
+	if isString && !resolved && t.Kind() != reflect.String && isWholePlaceholder(str) {
+		if st.resolver == nil {
+			st.placeholders = append(st.placeholders, ns)
+			return json.RawMessage("null"), nil
+		}
+		raw, err := st.resolver.ResolveJSON(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for parameter %s: %v", path, err)
+		}
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 && raw[0] == '"' {
+			return nil, fmt.Errorf("invalid value for parameter %s: %s references a string, expected %s", path, strings.TrimSpace(str), t.Kind())
+		}
+		return st.adaptJSON(raw, t, path, ns, true)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if isString || !resolved {
+			return data, nil
+		}
+		text := data
+		if data[0] == '{' || data[0] == '[' {
+			var pretty bytes.Buffer
+			if err := json.Indent(&pretty, data, "", "    "); err == nil {
+				text = pretty.Bytes()
+			}
+		}
+		enc, _ := json.Marshal(string(text))
+		return enc, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 || data[0] != '[' {
+			return data, nil
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return data, nil
+		}
+		for i := range items {
+			item, err := st.adaptJSON(items[i], t.Elem(), joinPath(path, strconv.Itoa(i)), ns+"["+strconv.Itoa(i)+"]", resolved)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		enc, _ := json.Marshal(items)
+		return enc, nil
+	case reflect.Map:
+		if data[0] != '{' {
+			return data, nil
+		}
+		var items map[string]json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return data, nil
+		}
+		for k := range items {
+			item, err := st.adaptJSON(items[k], t.Elem(), joinPath(path, k), ns+"["+k+"]", resolved)
+			if err != nil {
+				return nil, err
+			}
+			items[k] = item
+		}
+		enc, _ := json.Marshal(items)
+		return enc, nil
+	case reflect.Struct:
+		if data[0] != '{' {
+			return data, nil
+		}
+		var items map[string]json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return data, nil
+		}
+		fields := make(map[string]jsonField)
+		jsonFields(t, "", fields)
+		for k := range items {
+			f, exists := fields[strings.ToLower(k)]
+			if !exists {
+				continue
+			}
+			item, err := st.adaptJSON(items[k], f.typ, joinPath(path, k), joinNamespace(ns, f.ns), resolved)
+			if err != nil {
+				return nil, err
+			}
+			items[k] = item
+		}
+		enc, _ := json.Marshal(items)
+		return enc, nil
+	}
+	return data, nil
+}
+
+// jsonFields collects the (lowercased) json names of the fields of t,
+// including the fields of the embedded structs
+func jsonFields(t reflect.Type, ns string, fields map[string]jsonField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				jsonFields(ft, joinNamespace(ns, f.Name), fields)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if _, exists := fields[strings.ToLower(name)]; !exists {
+			fields[strings.ToLower(name)] = jsonField{typ: f.Type, ns: joinNamespace(ns, f.Name)}
+		}
+	}
+}
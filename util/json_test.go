@@ -0,0 +1,128 @@
+// MIT License
+//
+// Copyright (C) 2024  Develatio Technologies S.L.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package util_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/develatio/nebulant-cli/util"
+)
+
+type adaptParams struct {
+	Count *int64   `json:"count"`
+	Name  *string  `json:"name"`
+	Tags  []string `json:"tags"`
+}
+
+// fakeResolver resolves {{ ref }} from a map of JSON values
+type fakeResolver map[string]string
+
+func (f fakeResolver) ResolveJSON(text string) (json.RawMessage, error) {
+	ref := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(text), "{{"), "}}"))
+	if v, exists := f[ref]; exists {
+		return json.RawMessage(v), nil
+	}
+	return nil, fmt.Errorf("unknown reference %s", ref)
+}
+
+func TestUnmarshalParameters(t *testing.T) {
+	resolver := fakeResolver{"N": `3`, "TAGS": `[{"a": 1}, "b"]`, "S": `"text"`}
+
+	// references in non-string fields keep his type, resolved
+	// values are used as text where a string is expected
+	data := json.RawMessage(`{"count": "{{ N }}", "name": "{{ MISSING }}", "tags": "{{ TAGS }}"}`)
+	params := &adaptParams{}
+	if err := util.UnmarshalParameters(data, params, resolver); err != nil {
+		t.Fatal(err)
+	}
+	if *params.Count != 3 || *params.Name != "{{ MISSING }}" || params.Tags[1] != "b" || !strings.Contains(params.Tags[0], `"a": 1`) {
+		t.Errorf("unexpected params %v %v %v", *params.Count, *params.Name, params.Tags)
+	}
+
+	// literal values are never adapted
+	data = json.RawMessage(`{"count": "3", "name": 42}`)
+	if err := util.UnmarshalParameters(data, &adaptParams{}, resolver); err == nil {
+		t.Errorf("literal values should not be adapted")
+	}
+}
+
+func TestUnmarshalPlaceholders(t *testing.T) {
+	resolver := fakeResolver{"S": `"text"`}
+
+	// a string reference can not fill a number
+	data := json.RawMessage(`{"count": "{{ S }}"}`)
+	err := util.UnmarshalParameters(data, &adaptParams{}, resolver)
+	if err == nil || !strings.Contains(err.Error(), "count") {
+		t.Errorf("expected a type error for count, got %v", err)
+	}
+
+	// unknown references fail
+	data = json.RawMessage(`{"count": "{{ MISSING }}"}`)
+	if err := util.UnmarshalParameters(data, &adaptParams{}, resolver); err == nil {
+		t.Errorf("unknown reference should fail")
+	}
+
+	// without resolver (rehearsal) they are left empty
+	params := &adaptParams{}
+	if err := util.UnmarshalValidJSON(data, params); err != nil || params.Count != nil {
+		t.Errorf("unexpected result %v (%v)", params.Count, err)
+	}
+}
+
+type requiredTarget struct {
+	ID *string `json:"id" validate:"required"`
+}
+
+type requiredEmbedded struct {
+	Target *requiredTarget `json:"target" validate:"required"`
+}
+
+type requiredParams struct {
+	requiredEmbedded
+	Resources []*string `json:"resources" validate:"required,min=1"`
+	Count     *int64    `json:"count" validate:"required"`
+	Name      *string   `json:"name" validate:"required"`
+}
+
+func TestUnmarshalPlaceholdersRequired(t *testing.T) {
+	// in rehearsal the fields filled by a reference
+	// are not checked by the json tag validator
+	data := json.RawMessage(`{"resources": "{{ a.b }}", "count": "{{ n.c }}", "target": "{{ t }}", "name": "x"}`)
+	if err := util.UnmarshalParameters(data, &requiredParams{}, nil); err != nil {
+		t.Errorf("placeholders should pass validation, got %v", err)
+	}
+
+	// the rest of the fields are still validated
+	data = json.RawMessage(`{"resources": "{{ a.b }}", "count": "{{ n.c }}", "target": "{{ t }}"}`)
+	err := util.UnmarshalParameters(data, &requiredParams{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "Name") || strings.Contains(err.Error(), "Count") {
+		t.Errorf("expected only a required error for name, got %v", err)
+	}
+	data = json.RawMessage(`{"resources": [], "count": "{{ n.c }}", "target": "{{ t }}", "name": "x"}`)
+	if err := util.UnmarshalParameters(data, &requiredParams{}, nil); err == nil {
+		t.Errorf("literal empty resources should fail")
+	}
+}
@@ -47,14 +47,53 @@ type UIStore interface {
 
 // UnmarshalValidJSON func
 func UnmarshalValidJSON(data []byte, v interface{}) error {
-	jsonErr := json.Unmarshal(data, v)
+	return UnmarshalParameters(data, v, nil)
+}
+
+// UnmarshalParameters func. Like UnmarshalValidJSON, but the fields of
+// v that are not strings and are filled with a single {{ ref }} get the
+// native JSON value of the reference, resolved by resolver. A nil
+// resolver (rehearsal) leaves those fields empty and out of the json
+// tag validation, so a reference can fill a required field.
+func UnmarshalParameters(data []byte, v interface{}, resolver JSONResolver) error {
+	var jsonErr error
+	st := &adaptState{resolver: resolver}
+	if resolver != nil && bytes.Contains(data, []byte("{{")) {
+		adapted, err := st.adaptJSON(data, reflect.TypeOf(v), "", "", false)
+		if err != nil {
+			return err
+		}
+		jsonErr = json.Unmarshal(adapted, v)
+	} else {
+		jsonErr = json.Unmarshal(data, v)
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(jsonErr, &typeErr) {
+			// not yet resolved references could not
+			// match the type of the parameter, retry
+			// leaving them empty
+			adapted, err := st.adaptJSON(data, reflect.TypeOf(v), "", "", false)
+			if err != nil {
+				return err
+			}
+			jsonErr = json.Unmarshal(adapted, v)
+		}
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(jsonErr, &typeErr) {
+		return fmt.Errorf("invalid value for parameter %s: cannot use %s as %s", typeErr.Field, typeErr.Value, typeErr.Type)
+	}
 	if jsonErr != nil {
 		return jsonErr
 	}
 
 	// json tag validator
 	validate := validator.New()
-	vErr := validate.Struct(v)
+	var vErr error
+	if len(st.placeholders) > 0 {
+		vErr = validate.StructExcept(v, st.placeholders...)
+	} else {
+		vErr = validate.Struct(v)
+	}
 	if vErr != nil {
 		return vErr
 	}